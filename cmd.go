@@ -1,7 +0,0 @@
-//+build !linux
-
-package main
-
-import "os/exec"
-
-func setDeathSignal(cmd *exec.Cmd) {}
@@ -1,14 +0,0 @@
-//+build linux
-
-package main
-
-import (
-	"os/exec"
-	"syscall"
-)
-
-func setDeathSignal(cmd *exec.Cmd) {
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Pdeathsig: syscall.SIGKILL,
-	}
-}
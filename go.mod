@@ -1,12 +1,20 @@
 module code.rocketnine.space/tslocum/godoc-static
 
-go 1.15
+go 1.19
 
 require (
 	github.com/PuerkitoBio/goquery v1.7.1
-	github.com/andybalholm/cascadia v1.3.1 // indirect
+	github.com/alecthomas/chroma v0.10.0
 	github.com/yuin/goldmark v1.4.1
-	golang.org/x/mod v0.5.1
-	golang.org/x/net v0.0.0-20211005215030-d2e5035098b3
+	golang.org/x/exp v0.0.0-20220722155223-a9213eeb770e
+	golang.org/x/mod v0.6.0-dev.0.20220106191415-9b9b3d81d5e3
+	golang.org/x/net v0.0.0-20211015210444-4f30a5c0130f
+	golang.org/x/sys v0.0.0-20211019181941-9d821ace8654
+	golang.org/x/tools v0.1.10
+)
+
+require (
+	github.com/andybalholm/cascadia v1.3.1 // indirect
+	github.com/dlclark/regexp2 v1.4.0 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 )
@@ -0,0 +1,133 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type a11yIssue struct {
+	Page    string
+	Message string
+}
+
+var (
+	a11yIssues     []a11yIssue
+	a11yIssuesLock sync.Mutex
+)
+
+// auditPage runs a heuristic accessibility check over a generated page and
+// records any issues found.
+func auditPage(page string, data []byte) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+
+	var issues []string
+	if strings.TrimSpace(doc.Find("html").AttrOr("lang", "")) == "" {
+		issues = append(issues, "html element is missing a lang attribute")
+	}
+	if strings.TrimSpace(doc.Find("title").First().Text()) == "" {
+		issues = append(issues, "page has no title")
+	}
+
+	doc.Find("img").Each(func(_ int, selection *goquery.Selection) {
+		if _, ok := selection.Attr("alt"); !ok {
+			issues = append(issues, fmt.Sprintf("image %q has no alt text", selection.AttrOr("src", "")))
+		}
+	})
+
+	doc.Find("a").Each(func(_ int, selection *goquery.Selection) {
+		if _, ok := selection.Attr("href"); !ok {
+			return
+		}
+		if strings.TrimSpace(selection.Text()) == "" && selection.AttrOr("aria-label", "") == "" && selection.AttrOr("title", "") == "" && selection.Find("img[alt]").Length() == 0 {
+			issues = append(issues, fmt.Sprintf("link to %q has no accessible name", selection.AttrOr("href", "")))
+		}
+	})
+
+	doc.Find("input, select, textarea").Each(func(_ int, selection *goquery.Selection) {
+		if selection.AttrOr("type", "") == "hidden" || selection.AttrOr("aria-label", "") != "" || selection.AttrOr("title", "") != "" {
+			return
+		}
+		id := selection.AttrOr("id", "")
+		if id == "" || doc.Find(`label[for="`+id+`"]`).Length() == 0 {
+			issues = append(issues, fmt.Sprintf("form control %s has no label", goquery.NodeName(selection)))
+		}
+	})
+
+	doc.Find("table").Each(func(_ int, selection *goquery.Selection) {
+		if selection.Find("th").Length() == 0 && selection.Find("tr").Length() > 1 {
+			issues = append(issues, "table has no header cells")
+		}
+	})
+
+	lastLevel := 0
+	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(_ int, selection *goquery.Selection) {
+		level, _ := strconv.Atoi(goquery.NodeName(selection)[1:])
+		if lastLevel > 0 && level > lastLevel+1 {
+			issues = append(issues, fmt.Sprintf("heading level skips from h%d to h%d (%q)", lastLevel, level, strings.TrimSpace(selection.Text())))
+		}
+		lastLevel = level
+	})
+
+	ids := make(map[string]bool)
+	doc.Find("[id]").Each(func(_ int, selection *goquery.Selection) {
+		id := selection.AttrOr("id", "")
+		if ids[id] {
+			issues = append(issues, fmt.Sprintf("duplicate id %q", id))
+		}
+		ids[id] = true
+	})
+
+	if len(issues) == 0 {
+		return
+	}
+
+	a11yIssuesLock.Lock()
+	defer a11yIssuesLock.Unlock()
+
+	for _, issue := range issues {
+		a11yIssues = append(a11yIssues, a11yIssue{Page: page, Message: issue})
+	}
+}
+
+// writeA11yReport writes the issues recorded by auditPage to the report file.
+func writeA11yReport(ctx context.Context, buf *bytes.Buffer) error {
+	// The issues are copied so that the lock is not held while writing the
+	// report.
+	a11yIssuesLock.Lock()
+	issues := make([]a11yIssue, len(a11yIssues))
+	copy(issues, a11yIssues)
+	a11yIssuesLock.Unlock()
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Page < issues[j].Page
+	})
+
+	pages := make(map[string]bool)
+	for _, issue := range issues {
+		pages[issue.Page] = true
+	}
+
+	buf.Reset()
+	buf.WriteString(fmt.Sprintf("Accessibility report: %d issues on %d pages\n", len(issues), len(pages)))
+
+	var lastPage string
+	for _, issue := range issues {
+		if issue.Page != lastPage {
+			buf.WriteString("\n" + issue.Page + "\n")
+			lastPage = issue.Page
+		}
+		buf.WriteString("\t" + issue.Message + "\n")
+	}
+
+	return writeFile(ctx, buf, "", a11yReport)
+}
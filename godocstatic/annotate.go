@@ -0,0 +1,267 @@
+package godocstatic
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+	severityNote    = "note"
+)
+
+const annotationCSS = `
+.annotations { margin: 0.625rem 0; font-size: 0.875rem; }
+.annotations ul { margin: 0.3125rem 0; }
+.annotation-error { color: var(--alert); }
+.annotation-warning { color: var(--warning-text); }
+.annotation-note { color: var(--link); }
+pre .ln.annotation-error { background-color: rgba(204, 0, 0, 0.2); box-shadow: inset 0.25rem 0 0 #c00; }
+pre .ln.annotation-warning { background-color: rgba(204, 153, 0, 0.2); box-shadow: inset 0.25rem 0 0 #c90; }
+pre .ln.annotation-note { background-color: rgba(55, 94, 171, 0.2); box-shadow: inset 0.25rem 0 0 #375eab; }
+`
+
+var annotationsFile string
+
+// annotation is a message about a line of a source file, such as a lint,
+// fuzzing or security finding.
+type annotation struct {
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+	Tool     string `json:"tool,omitempty"`
+}
+
+// annotations lists the annotations of each line of each source file, by
+// absolute path of the file.
+var annotations map[string]map[int][]annotation
+
+var severityRank = map[string]int{
+	severityNote:    0,
+	severityWarning: 1,
+	severityError:   2,
+}
+
+// normalizeSeverity maps the severities and SARIF levels reported by tools to
+// error, warning or note.
+func normalizeSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "error", "fatal", "critical", "high":
+		return severityError
+	case "note", "info", "information", "none", "low":
+		return severityNote
+	default:
+		return severityWarning
+	}
+}
+
+// annotationPath resolves the path or URI of an annotated file, relative to
+// the directory of the annotations file.
+func annotationPath(dir string, p string) string {
+	if u, err := url.Parse(p); err == nil && u.Scheme == "file" {
+		p = u.Path
+	} else if unescaped, err := url.PathUnescape(p); err == nil {
+		p = unescaped
+	}
+
+	p = filepath.FromSlash(p)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	return filepath.Clean(p)
+}
+
+func addAnnotation(file string, line int, a annotation) {
+	if annotations[file] == nil {
+		annotations[file] = make(map[int][]annotation)
+	}
+	a.Severity = normalizeSeverity(a.Severity)
+	annotations[file][line] = append(annotations[file][line], a)
+}
+
+// readSARIFAnnotations reads the results of a SARIF log.
+func readSARIFAnnotations(dir string, data []byte) error {
+	var sarif struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Name string `json:"name"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID  string `json:"ruleId"`
+				Level   string `json:"level"`
+				Message struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	err := json.Unmarshal(data, &sarif)
+	if err != nil {
+		return err
+	}
+
+	for _, run := range sarif.Runs {
+		for _, result := range run.Results {
+			message := result.Message.Text
+			if result.RuleID != "" {
+				message = result.RuleID + ": " + message
+			}
+			for _, location := range result.Locations {
+				l := location.PhysicalLocation
+				if l.ArtifactLocation.URI == "" || l.Region.StartLine <= 0 {
+					continue
+				}
+				addAnnotation(annotationPath(dir, l.ArtifactLocation.URI), l.Region.StartLine, annotation{
+					Message:  message,
+					Severity: result.Level,
+					Tool:     run.Tool.Driver.Name,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// readSimpleAnnotations reads a JSON object mapping file:line to an
+// annotation or a list of annotations.
+func readSimpleAnnotations(dir string, data []byte) error {
+	var entries map[string]json.RawMessage
+	err := json.Unmarshal(data, &entries)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range entries {
+		colon := strings.LastIndexByte(key, ':')
+		if colon <= 0 {
+			return fmt.Errorf("invalid location %q: expected file:line", key)
+		}
+		line, err := strconv.Atoi(key[colon+1:])
+		if err != nil || line <= 0 {
+			return fmt.Errorf("invalid location %q: expected file:line", key)
+		}
+		file := annotationPath(dir, key[:colon])
+
+		var list []annotation
+		if strings.HasPrefix(strings.TrimSpace(string(value)), "[") {
+			err = json.Unmarshal(value, &list)
+		} else {
+			var a annotation
+			err = json.Unmarshal(value, &a)
+			list = append(list, a)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid annotation of %s: %s", key, err)
+		}
+
+		for _, a := range list {
+			addAnnotation(file, line, a)
+		}
+	}
+	return nil
+}
+
+// loadAnnotations reads the annotations file, which is either a SARIF log or
+// a JSON object mapping file:line to annotations.
+func loadAnnotations() error {
+	annotations = make(map[string]map[int][]annotation)
+
+	data, err := ioutil.ReadFile(annotationsFile)
+	if err != nil {
+		return err
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(annotationsFile))
+	if err != nil {
+		return err
+	}
+
+	var probe struct {
+		Runs json.RawMessage `json:"runs"`
+	}
+	err = json.Unmarshal(data, &probe)
+	if err != nil {
+		return err
+	}
+	if probe.Runs != nil {
+		return readSARIFAnnotations(dir, data)
+	}
+	return readSimpleAnnotations(dir, data)
+}
+
+// addAnnotations marks the annotated lines of a source page and lists the
+// annotations above its code.
+func addAnnotations(doc *goquery.Document, file string) {
+	fileAnnotations := annotations[filepath.Clean(file)]
+	if len(fileAnnotations) == 0 {
+		return
+	}
+
+	pre := doc.Find("#page pre").First()
+	if pre.Length() == 0 {
+		return
+	}
+
+	var lines []int
+	for line := range fileAnnotations {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+
+	var list strings.Builder
+	var count int
+	for _, line := range lines {
+		severity := severityNote
+		var titles []string
+		for _, a := range fileAnnotations[line] {
+			if severityRank[a.Severity] > severityRank[severity] {
+				severity = a.Severity
+			}
+
+			title := a.Severity + ": " + a.Message
+			if a.Tool != "" {
+				title += " (" + a.Tool + ")"
+			}
+			titles = append(titles, title)
+
+			list.WriteString(`<li class="annotation-` + a.Severity + `"><a href="#L` + strconv.Itoa(line) + `">Line ` + strconv.Itoa(line) + `</a>: ` + html.EscapeString(title) + `</li>
+`)
+			count++
+		}
+
+		pre.Find("#L"+strconv.Itoa(line)).First().
+			AddClass("annotated", "annotation-"+severity).
+			SetAttr("title", strings.Join(titles, "\n"))
+	}
+
+	label := "annotations"
+	if count == 1 {
+		label = "annotation"
+	}
+	pre.BeforeHtml(`<details class="annotations">
+<summary>` + strconv.Itoa(count) + ` ` + label + `</summary>
+<ul>
+` + list.String() + `</ul>
+</details>`)
+}
@@ -0,0 +1,154 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/doc"
+	"go/printer"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const apiListingFile = "api.txt"
+
+var apiListing bool
+
+var apiLineBreak = regexp.MustCompile(`\s*\n\s*`)
+
+// apiListingLines returns the exported declarations of a package, one per
+// line. Struct fields and interface methods are listed on lines of their own
+// so that changes to them are isolated when the listing is compared.
+func apiListingLines(fset *token.FileSet, d *doc.Package) []string {
+	node := func(n interface{}) string {
+		var buf bytes.Buffer
+		err := (&printer.Config{Mode: printer.UseSpaces, Tabwidth: 8}).Fprint(&buf, fset, n)
+		if err != nil {
+			return ""
+		}
+		return apiLineBreak.ReplaceAllString(buf.String(), " ")
+	}
+
+	var lines []string
+	addValues := func(kind string, values []*doc.Value) {
+		for _, v := range values {
+			var typ ast.Expr
+			for _, spec := range v.Decl.Specs {
+				s := spec.(*ast.ValueSpec)
+				if s.Type != nil || len(s.Values) > 0 {
+					typ = s.Type
+				}
+
+				for i, name := range s.Names {
+					if !name.IsExported() {
+						continue
+					}
+
+					line := kind + " " + name.Name
+					if typ != nil {
+						line += " " + node(typ)
+					}
+					if i < len(s.Values) && (kind == "const" || s.Type == nil) {
+						line += " = " + node(s.Values[i])
+					}
+					lines = append(lines, line)
+				}
+			}
+		}
+	}
+	addFunc := func(f *doc.Func) {
+		signature := strings.TrimPrefix(node(f.Decl.Type), "func")
+		if f.Decl.Recv == nil {
+			lines = append(lines, "func "+f.Name+signature)
+			return
+		}
+		lines = append(lines, "method ("+node(f.Decl.Recv.List[0].Type)+") "+f.Name+signature)
+	}
+
+	addValues("const", d.Consts)
+	addValues("var", d.Vars)
+	for _, f := range d.Funcs {
+		addFunc(f)
+	}
+	for _, t := range d.Types {
+		addValues("const", t.Consts)
+		addValues("var", t.Vars)
+		for _, f := range t.Funcs {
+			addFunc(f)
+		}
+		for _, m := range t.Methods {
+			addFunc(m)
+		}
+
+		for _, spec := range t.Decl.Specs {
+			s := spec.(*ast.TypeSpec)
+			if s.Name.Name != t.Name {
+				continue
+			}
+
+			switch typ := s.Type.(type) {
+			case *ast.StructType:
+				lines = append(lines, "type "+t.Name+" struct")
+				for _, field := range typ.Fields.List {
+					if len(field.Names) == 0 {
+						lines = append(lines, "type "+t.Name+" struct, embedded "+node(field.Type))
+						continue
+					}
+					for _, name := range field.Names {
+						if name.IsExported() {
+							lines = append(lines, "type "+t.Name+" struct, "+name.Name+" "+node(field.Type))
+						}
+					}
+				}
+			case *ast.InterfaceType:
+				lines = append(lines, "type "+t.Name+" interface")
+				for _, method := range typ.Methods.List {
+					if len(method.Names) == 0 {
+						lines = append(lines, "type "+t.Name+" interface, embedded "+node(method.Type))
+						continue
+					}
+					for _, name := range method.Names {
+						if name.IsExported() {
+							lines = append(lines, "type "+t.Name+" interface, "+name.Name+strings.TrimPrefix(node(method.Type), "func"))
+						}
+					}
+				}
+			default:
+				if s.Assign.IsValid() {
+					lines = append(lines, "type "+t.Name+" = "+node(s.Type))
+				} else {
+					lines = append(lines, "type "+t.Name+" "+node(s.Type))
+				}
+			}
+		}
+	}
+
+	sort.Strings(lines)
+	return lines
+}
+
+// writeAPIListing writes api.txt, listing the exported declarations of a
+// package. Nothing is written for commands and directories without Go files.
+func writeAPIListing(ctx context.Context, buf *bytes.Buffer, pkg string, outPkg string) error {
+	p, err := loadNativePackage(ctx, pkg)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	} else if err != nil {
+		return nil // This is expected for directories without source files
+	}
+
+	fset, d, err := parsePackageDoc(p, 0)
+	if err != nil {
+		return err
+	} else if d == nil || d.Name == "main" {
+		return nil
+	}
+
+	buf.Reset()
+	for _, line := range apiListingLines(fset, d) {
+		buf.WriteString(line + "\n")
+	}
+	return writeFile(ctx, buf, outPkg, apiListingFile)
+}
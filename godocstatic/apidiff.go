@@ -0,0 +1,225 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"html"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"golang.org/x/exp/apidiff"
+	"golang.org/x/mod/semver"
+)
+
+const apiChangesPage = "changes.html"
+
+var apiDiff bool
+
+// versionAPIs lists the packages supplied as paths, type-checked at each
+// version listed by -versions, by import path.
+var versionAPIs map[string]map[string]*types.Package
+
+// previousVersion returns the version the API of a version is compared to:
+// the highest semantic version lower than it, or for a branch, the highest
+// semantic version listed. An empty string is returned when there is none.
+func previousVersion(version string) string {
+	var previous string
+	for _, v := range versionList {
+		if !semver.IsValid(v) || v == version || (semver.IsValid(version) && semver.Compare(v, version) > 0) {
+			continue
+		} else if previous == "" || semver.Compare(v, previous) > 0 {
+			previous = v
+		}
+	}
+	return previous
+}
+
+// loadPackageAPIs type-checks the packages in each directory from source,
+// returning them by import path.
+func loadPackageAPIs(ctx context.Context, dirs []string) (map[string]*types.Package, error) {
+	var (
+		apis    = make(map[string]*types.Package)
+		fset    = token.NewFileSet()
+		checked = make(map[string]*types.Package)
+	)
+	for _, dir := range dirs {
+		pkgs, pkgErrs, err := typeCheckPackages(ctx, dir, fset, checked, "./...")
+		if err != nil {
+			return nil, err
+		}
+
+		for pkg, p := range pkgs {
+			if err := pkgErrs[pkg]; err != nil {
+				if verbose {
+					logger.Printf("Warning: failed to type-check %s: %s", pkg, err)
+				}
+				continue
+			} else if p.Name() == "main" {
+				continue
+			}
+			apis[pkg] = p
+		}
+	}
+	return apis, nil
+}
+
+// loadVersionAPI checks out the packages supplied as paths at a version and
+// type-checks them. The checked out worktrees are always removed.
+func loadVersionAPI(ctx context.Context, pkgs []string, version string) (map[string]*types.Package, error) {
+	tmpDir, err := ioutil.TempDir(getTmpDir(), "godoc-static-api")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var dirs []string
+	for i, pkg := range pkgs {
+		if info, err := os.Stat(pkg); err != nil || !info.IsDir() {
+			continue
+		}
+
+		workTree := filepath.Join(tmpDir, strconv.Itoa(i))
+		defer removeWorktree(pkg, workTree)
+
+		dir, err := checkoutVersion(ctx, pkg, version, workTree)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, dir)
+	}
+	if len(dirs) == 0 {
+		return nil, errors.New("--api-diff requires at least one package supplied as a path")
+	}
+	return loadPackageAPIs(ctx, dirs)
+}
+
+// loadVersionAPIs checks out the packages supplied as paths at each version
+// listed by -versions and type-checks them, so that the API of each version
+// may be compared to the previous version while generating documentation.
+func loadVersionAPIs(ctx context.Context, pkgs []string) error {
+	versionAPIs = make(map[string]map[string]*types.Package)
+	for _, version := range versionList {
+		if !quiet {
+			logger.Printf("Loading API of %s...", version)
+		}
+
+		apis, err := loadVersionAPI(ctx, pkgs, version)
+		if err != nil {
+			return fmt.Errorf("failed to load API of %s: %s", version, err)
+		}
+		versionAPIs[version] = apis
+	}
+	return nil
+}
+
+// apiChangesLink returns a link to the page listing the changes to the API
+// of the current version, or an empty string when there is none.
+func apiChangesLink(basePath string) string {
+	if !apiDiff || currentVersion == "" || previousVersion(currentVersion) == "" {
+		return ""
+	}
+	return `<a class="version-changes" href="` + basePath + apiChangesPage + `">What changed in ` + html.EscapeString(currentVersion) + `</a>`
+}
+
+// writeAPIChangesPage writes a page listing the packages added and removed
+// since the previous version, and the changes to the API of the packages in
+// both versions, incompatible changes first.
+func writeAPIChangesPage(ctx context.Context, buf *bytes.Buffer) error {
+	previous := previousVersion(currentVersion)
+	if previous == "" {
+		return nil
+	}
+	oldAPIs, newAPIs := versionAPIs[previous], versionAPIs[currentVersion]
+
+	var index, versionIndex string
+	if linkIndex {
+		index = "/index.html"
+		versionIndex = "index.html"
+	}
+
+	var all []string
+	for pkg := range oldAPIs {
+		all = append(all, pkg)
+	}
+	for pkg := range newAPIs {
+		if _, ok := oldAPIs[pkg]; !ok {
+			all = append(all, pkg)
+		}
+	}
+	all = filterPkgsWithExcludes(filterPkgsWithIncludes(all))
+	sortByVanityPath(all)
+
+	buf.Reset()
+	buf.WriteString(sitePageHeader("What changed in " + html.EscapeString(currentVersion) + " - " + siteName))
+	buf.WriteString(`
+<h1>
+	What changed in ` + html.EscapeString(currentVersion) + `
+</h1>
+<p>Changes to the exported API since <a href="../` + html.EscapeString(versionDir(previous)) + `/` + versionIndex + `">` + html.EscapeString(previous) + `</a>, as reported by <a href="https://pkg.go.dev/golang.org/x/exp/apidiff">apidiff</a>.</p>
+<div id="pkg-changes">
+`)
+
+	var changed int
+	for _, pkg := range all {
+		outPkg := vanityPath(pkg)
+		name := html.EscapeString(displayPath(outPkg))
+
+		oldAPI, newAPI := oldAPIs[pkg], newAPIs[pkg]
+		if oldAPI == nil {
+			buf.WriteString(`<h3><a href="` + outPkg + index + `">` + name + `</a></h3>
+<p>Package added.</p>
+`)
+			changed++
+			continue
+		} else if newAPI == nil {
+			buf.WriteString(`<h3>` + name + `</h3>
+<p>Package removed.</p>
+`)
+			changed++
+			continue
+		}
+
+		report := apidiff.Changes(oldAPI, newAPI)
+		if len(report.Changes) == 0 {
+			continue
+		}
+		sort.SliceStable(report.Changes, func(i, j int) bool {
+			return !report.Changes[i].Compatible && report.Changes[j].Compatible
+		})
+
+		buf.WriteString(`<h3><a href="` + outPkg + index + `">` + name + `</a></h3>
+<ul>
+`)
+		for _, change := range report.Changes {
+			kind := "Compatible"
+			if !change.Compatible {
+				kind = "Incompatible"
+			}
+			buf.WriteString(`<li><strong>` + kind + `:</strong> <code>` + html.EscapeString(change.Message) + `</code></li>
+`)
+		}
+		buf.WriteString(`</ul>
+`)
+		changed++
+	}
+	if changed == 0 {
+		buf.WriteString(`<p>The exported API is unchanged.</p>
+`)
+	}
+
+	buf.WriteString(`</div>
+<div id="footer">` + siteFooterText("") + `</div>
+</div>
+</div>
+` + searchTags("") + `</body>
+</html>
+`)
+	return writeFile(ctx, buf, "", apiChangesPage)
+}
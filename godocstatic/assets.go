@@ -0,0 +1,54 @@
+package godocstatic
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var assetsDir string
+
+var (
+	// sharedAssets lists the files written to lib, by name and renderer,
+	// which are produced once and reused by later generations, such as
+	// those of each version.
+	sharedAssets     = make(map[string][]byte)
+	sharedAssetsLock sync.Mutex
+)
+
+// sharedAsset returns the contents of a file written to lib. A file of the
+// same name in -assets-dir is used when present. Otherwise the file is
+// produced by load the first time it is needed.
+func sharedAsset(name string, load func() ([]byte, error)) ([]byte, error) {
+	if assetsDir != "" {
+		data, err := ioutil.ReadFile(filepath.Join(assetsDir, name))
+		if err == nil {
+			return data, nil
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	sharedAssetsLock.Lock()
+	defer sharedAssetsLock.Unlock()
+
+	key := name + " " + renderer
+	if data, ok := sharedAssets[key]; ok {
+		return data, nil
+	}
+
+	data, err := load()
+	if err != nil {
+		return nil, err
+	}
+	sharedAssets[key] = data
+	return data, nil
+}
+
+// constantAsset returns a function which loads an asset from a constant.
+func constantAsset(content string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return []byte(content), nil
+	}
+}
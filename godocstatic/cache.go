@@ -0,0 +1,408 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// cacheFormat is incremented when the format of the cache file or the pages
+// written change in a way which invalidates existing caches.
+const cacheFormat = 2
+
+// cacheIgnoredFlags lists options which do not affect the pages written.
+var cacheIgnoredFlags = map[string]bool{
+	"cache":      true,
+	"config":     true,
+	"deadline":   true,
+	"load-state": true,
+	"priority":   true,
+	"quiet":      true,
+	"save-state": true,
+	"timeout":    true,
+	"verbose":    true,
+	"watch":      true,
+	"work-dir":   true,
+	"workers":    true,
+}
+
+// cachedPackage records the source a package was generated from and the
+// files written for it.
+type cachedPackage struct {
+	Source string
+	Files  map[string]string
+	Search []searchEntry `json:",omitempty"`
+}
+
+type buildCache struct {
+	Settings string
+	Packages map[string]*cachedPackage
+}
+
+type cacheRecordKey struct{}
+
+var (
+	cacheFile string
+
+	// previousCache is the cache read at the start of generation and
+	// currentCache is the cache written once generation completes.
+	previousCache *buildCache
+	currentCache  *buildCache
+	cacheLock     sync.Mutex
+
+	// cacheReuse lists the packages whose pages are reused from the previous
+	// generation.
+	cacheReuse map[string]bool
+
+	// cacheDependencies lists the documented packages each package depends
+	// on, when identifiers in source files are linked.
+	cacheDependencies map[string][]string
+
+	filesHashes     map[string]string
+	filesHashesLock sync.Mutex
+)
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheSettings returns a hash of the options and packages affecting the
+// pages written.
+func cacheSettings(pkgs []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "format %d\n", cacheFormat)
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprintf(&b, "godoc-static %s %s\n", info.Main.Version, info.Main.Sum)
+	}
+	commands[defaultCommand].Flags.VisitAll(func(f *flag.Flag) {
+		if !cacheIgnoredFlags[f.Name] {
+			fmt.Fprintf(&b, "%s=%q\n", f.Name, f.Value.String())
+		}
+	})
+	fmt.Fprintf(&b, "description=%q\nfooter=%q\n", siteDescription, siteFooter)
+	if annotationsFile != "" {
+		data, _ := ioutil.ReadFile(annotationsFile)
+		fmt.Fprintf(&b, "annotations=%s\n", hashBytes(data))
+	}
+	if ownersFile != "" {
+		data, _ := ioutil.ReadFile(ownersFile)
+		fmt.Fprintf(&b, "owners=%s\n", hashBytes(data))
+	}
+	if excludeFile != "" {
+		data, _ := ioutil.ReadFile(excludeFile)
+		fmt.Fprintf(&b, "exclude=%s\n", hashBytes(data))
+	}
+	if h := translationsHash(); h != "" {
+		fmt.Fprintf(&b, "translations=%s\n", h)
+	}
+	if h := templatesHash(); h != "" {
+		fmt.Fprintf(&b, "templates=%s\n", h)
+	}
+	for _, pkg := range pkgs {
+		b.WriteString(pkg + "\n")
+	}
+	return hashBytes([]byte(b.String()))
+}
+
+// packageFilesHash returns a hash of the files in the directory of a
+// package and the go.mod file of its module, or an empty string when the
+// package has no directory. Hashes are computed once per generation.
+func packageFilesHash(pkg string) string {
+	filesHashesLock.Lock()
+	defer filesHashesLock.Unlock()
+
+	if sum, ok := filesHashes[pkg]; ok {
+		return sum
+	}
+	sum := hashPackageFiles(pkg)
+	filesHashes[pkg] = sum
+	return sum
+}
+
+func hashPackageFiles(pkg string) string {
+	dir := pkgDirs[pkg]
+	if dir == "" {
+		return ""
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	var files []string
+	for _, info := range infos {
+		if info.Mode().IsRegular() {
+			files = append(files, filepath.Join(dir, info.Name()))
+		}
+	}
+	if m := packageModule(pkg); m != nil {
+		files = append(files, filepath.Join(m.Dir, "go.mod"))
+	}
+
+	h := sha256.New()
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return ""
+		}
+		fmt.Fprintf(h, "%s %d\n", filepath.Base(file), len(data))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// packageSourceHash returns a hash of the files of a package, and of the
+// inputs from other packages listed on its pages, or an empty string when
+// the package has no directory.
+func packageSourceHash(pkg string) string {
+	files := packageFilesHash(pkg)
+	if files == "" {
+		return ""
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", files)
+	if provenance {
+		p := packageProvenance(context.Background(), pkg)
+		fmt.Fprintf(h, "%s %s %s\n", p.Module, p.Version, p.Commit)
+	}
+	if importPanel {
+		fmt.Fprintf(h, "%s\n", importVersion(context.Background(), pkg))
+	}
+	if references {
+		fmt.Fprintf(h, "references\n%s\n", referencesKey(pkg))
+	}
+	if implementers {
+		fmt.Fprintf(h, "implementers\n%s\n", implementersKey(pkg))
+	}
+	if sourceLinks {
+		// Identifiers in source files link to the declarations of the
+		// documented packages the package and its tests depend on.
+		for _, dep := range cacheDependencies[pkg] {
+			fmt.Fprintf(h, "dependency %s %s\n", dep, packageFilesHash(dep))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// loadCacheDependencies lists the documented packages each package and its
+// tests depend on.
+func loadCacheDependencies(ctx context.Context, pkgs []string) error {
+	cacheDependencies = make(map[string][]string)
+	for _, pkg := range pkgs {
+		if _, ok := listFailures[pkg]; ok || pkgDirs[pkg] == "" {
+			continue
+		}
+
+		dir := pkgPaths[pkg]
+		if dir == "" {
+			dir = getTmpDir()
+		}
+		lines, err := goListLines(ctx, dir, "-e", "-deps", "-test", "-f", "{{.ImportPath}}", pkg)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		} else if err != nil {
+			return fmt.Errorf("failed to list dependencies of %s: %s", pkg, err)
+		}
+
+		var deps []string
+		for _, line := range lines {
+			// Test variants are listed as "path [pkg.test]".
+			dep := strings.Fields(line)[0]
+			if dep != pkg && documentedPackages[dep] {
+				deps = append(deps, dep)
+			}
+		}
+		sort.Strings(deps)
+		cacheDependencies[pkg] = uniqueStrings(deps)
+	}
+	return nil
+}
+
+// loadCache reads the cache file and determines which packages may be
+// reused: those whose source and the options used are unchanged, and whose
+// files are unmodified.
+func loadCache(ctx context.Context, pkgs []string) error {
+	filesHashes = make(map[string]string)
+	if sourceLinks {
+		err := loadCacheDependencies(ctx, pkgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	settings := cacheSettings(pkgs)
+	currentCache = &buildCache{Settings: settings, Packages: make(map[string]*cachedPackage)}
+	previousCache = nil
+	cacheReuse = make(map[string]bool)
+
+	data, err := ioutil.ReadFile(cacheFile)
+	if err != nil {
+		return nil
+	}
+
+	c := &buildCache{}
+	err = json.Unmarshal(data, c)
+	if err != nil || c.Settings != settings {
+		return nil
+	}
+	previousCache = c
+
+	for _, pkg := range pkgs {
+		cached := c.Packages[pkg]
+		if cached == nil || cached.Source == "" || cached.Source != packageSourceHash(pkg) {
+			continue
+		}
+
+		reuse := true
+		for file, hash := range cached.Files {
+			data, err := ioutil.ReadFile(filepath.Join(siteDestination, filepath.FromSlash(file)))
+			if err != nil || hashBytes(data) != hash {
+				reuse = false
+				break
+			}
+		}
+		if reuse {
+			cacheReuse[pkg] = true
+		}
+	}
+
+	if verbose {
+		logger.Printf("Reusing %d of %d packages from %s.", len(cacheReuse), len(pkgs), cacheFile)
+	}
+	return nil
+}
+
+// reusePackage restores the search entries of a package from the previous
+// generation and adds its files to the accessibility report and ZIP file.
+func reusePackage(ctx context.Context, pkg string) error {
+	cached := previousCache.Packages[pkg]
+
+	cacheLock.Lock()
+	currentCache.Packages[pkg] = cached
+	cacheLock.Unlock()
+
+	if searchMode != "" {
+		searchEntriesLock.Lock()
+		searchEntries = append(searchEntries, cached.Search...)
+		searchEntriesLock.Unlock()
+	}
+
+	var files []string
+	for file := range cached.Files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(siteDestination, filepath.FromSlash(file)))
+		if err != nil {
+			return fmt.Errorf("failed to read cached file %s: %s", file, err)
+		}
+
+		if a11yReport != "" && strings.HasSuffix(file, ".html") {
+			auditPage(file, data)
+		}
+		addSitePage(file)
+
+		if outZip != nil {
+			err = writeZipFile(file, data)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cachePackages wraps a function which writes the pages of a package,
+// skipping packages reused from the previous generation and recording the
+// files written for the others.
+func cachePackages(scrape func(ctx context.Context, buf *bytes.Buffer, pkg string) error, docs bool) func(ctx context.Context, buf *bytes.Buffer, pkg string) error {
+	if cacheFile == "" {
+		return scrape
+	}
+
+	return func(ctx context.Context, buf *bytes.Buffer, pkg string) error {
+		if cacheReuse[pkg] {
+			if docs {
+				return reusePackage(ctx, pkg)
+			}
+			return nil
+		}
+
+		cacheLock.Lock()
+		cached := currentCache.Packages[pkg]
+		if cached == nil {
+			cached = &cachedPackage{Source: packageSourceHash(pkg), Files: make(map[string]string)}
+			currentCache.Packages[pkg] = cached
+		}
+		cacheLock.Unlock()
+
+		err := scrape(context.WithValue(ctx, cacheRecordKey{}, cached), buf, pkg)
+		if err != nil || !docs || searchMode == "" {
+			return err
+		}
+
+		outPkg := vanityPath(pkg)
+		searchEntriesLock.Lock()
+		for _, entry := range searchEntries {
+			if entry.Package == outPkg {
+				cached.Search = append(cached.Search, entry)
+			}
+		}
+		searchEntriesLock.Unlock()
+		return nil
+	}
+}
+
+// recordCachedFile records a file written for the package being generated.
+func recordCachedFile(ctx context.Context, fileDir string, fileName string, data []byte) {
+	cached, ok := ctx.Value(cacheRecordKey{}).(*cachedPackage)
+	if !ok {
+		return
+	}
+
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+
+	cached.Files[path.Join(fileDir, fileName)] = hashBytes(data)
+}
+
+// writeCache writes the cache file.
+func writeCache() error {
+	for pkg, cached := range currentCache.Packages {
+		if cached.Source == "" {
+			delete(currentCache.Packages, pkg)
+		}
+	}
+
+	data, err := json.Marshal(currentCache)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(cacheFile), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(cacheFile, data, 0644)
+}
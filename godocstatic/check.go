@@ -0,0 +1,128 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var checkAnchors bool
+
+func registerCheckFlags(flags *flag.FlagSet) {
+	flags.StringVar(&siteDestination, "destination", "", "path to generated site")
+	flags.BoolVar(&checkAnchors, "anchors", false, "also check that linked anchors exist")
+	registerCommonFlags(flags)
+}
+
+// localLink returns the site-relative path and fragment a link on page
+// points to, or false when the link leaves the site.
+func localLink(page string, href string) (string, string, bool) {
+	u, err := url.Parse(href)
+	if err != nil || u.Scheme != "" || u.Host != "" || strings.HasPrefix(href, "//") {
+		return "", "", false
+	}
+
+	target := page
+	if u.Path != "" {
+		target = path.Join(path.Dir(page), u.Path)
+		if strings.HasSuffix(u.Path, "/") {
+			target += "/"
+		}
+	}
+	return target, u.Fragment, true
+}
+
+func runCheck(ctx context.Context, args []string) error {
+	if siteDestination == "" {
+		return errors.New("--destination must be set")
+	}
+
+	files, err := siteFiles(siteDestination)
+	if err != nil {
+		return fmt.Errorf("failed to list files of %s: %s", siteDestination, err)
+	}
+
+	ids := make(map[string]map[string]bool)
+	pageIDs := func(page string) map[string]bool {
+		if ids[page] != nil {
+			return ids[page]
+		}
+		ids[page] = make(map[string]bool)
+
+		data, err := ioutil.ReadFile(filepath.Join(siteDestination, filepath.FromSlash(page)))
+		if err != nil {
+			return ids[page]
+		}
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+		if err != nil {
+			return ids[page]
+		}
+		doc.Find("[id], a[name]").Each(func(_ int, selection *goquery.Selection) {
+			ids[page][selection.AttrOr("id", selection.AttrOr("name", ""))] = true
+		})
+		return ids[page]
+	}
+
+	var pages []string
+	for file := range files {
+		if strings.HasSuffix(file, ".html") {
+			pages = append(pages, file)
+		}
+	}
+	sort.Strings(pages)
+
+	var broken int
+	for _, page := range pages {
+		data, err := ioutil.ReadFile(filepath.Join(siteDestination, filepath.FromSlash(page)))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %s", page, err)
+		}
+
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %s", page, err)
+		}
+
+		doc.Find("a[href], link[href], script[src], img[src]").Each(func(_ int, selection *goquery.Selection) {
+			href := selection.AttrOr("href", selection.AttrOr("src", ""))
+			target, fragment, ok := localLink(page, href)
+			if !ok {
+				return
+			}
+
+			if strings.HasSuffix(target, "/") || (!files[target] && files[path.Join(target, "index.html")]) {
+				target = path.Join(target, "index.html")
+			}
+
+			if !files[target] {
+				fmt.Fprintf(os.Stdout, "%s: broken link to %s\n", page, href)
+				broken++
+				return
+			}
+
+			if checkAnchors && fragment != "" && strings.HasSuffix(target, ".html") && !pageIDs(target)[fragment] {
+				fmt.Fprintf(os.Stdout, "%s: missing anchor %s\n", page, href)
+				broken++
+			}
+		})
+	}
+
+	if broken > 0 {
+		return fmt.Errorf("found %d broken links in %d pages", broken, len(pages))
+	}
+	if verbose {
+		logger.Printf("Checked %d pages.", len(pages))
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !windows && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!windows,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package godocstatic
+
+import (
+	"os"
+	"os/exec"
+)
+
+func setDeathSignal(cmd *exec.Cmd) {}
+
+func forwardSignal(sig os.Signal) {}
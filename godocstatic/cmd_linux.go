@@ -0,0 +1,20 @@
+//go:build linux
+// +build linux
+
+package godocstatic
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+func setDeathSignal(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Pdeathsig: syscall.SIGKILL,
+	}
+}
+
+// forwardSignal does nothing, as the processes started by godoc-static
+// belong to its process group and receive the signals sent to it.
+func forwardSignal(sig os.Signal) {}
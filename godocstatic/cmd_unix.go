@@ -0,0 +1,100 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+// +build darwin dragonfly freebsd netbsd openbsd
+
+package godocstatic
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchdogScript kills the process group of the watchdog, which includes the
+// processes started by godoc-static, once its standard input is closed as it
+// is when godoc-static exits. Signals sent to the group are ignored.
+const watchdogScript = `trap '' INT TERM HUP
+while read line; do :; done
+kill -KILL 0`
+
+var (
+	watchdogOnce sync.Once
+	// watchdogPgid is the process group of the watchdog, or 0 when it is not
+	// running.
+	watchdogPgid int32
+	// watchdogInput is the write end of the standard input of the watchdog,
+	// which is held open until godoc-static exits.
+	watchdogInput *os.File
+)
+
+// startWatchdog starts a shell in a process group of its own which the
+// processes started by godoc-static join, so that they are killed when it
+// exits, even when it is killed.
+func startWatchdog() {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return
+	}
+	defer r.Close()
+
+	cmd := exec.Command("/bin/sh", "-c", watchdogScript)
+	cmd.Stdin = r
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	err = cmd.Start()
+	if err != nil {
+		w.Close()
+		return
+	}
+
+	watchdogInput = w
+	atomic.StoreInt32(&watchdogPgid, int32(cmd.Process.Pid))
+	go func() {
+		cmd.Wait()
+		atomic.StoreInt32(&watchdogPgid, 0)
+	}()
+}
+
+// terminalForeground returns whether godoc-static belongs to the foreground
+// process group of its controlling terminal.
+func terminalForeground() bool {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return false
+	}
+	defer tty.Close()
+
+	pgid, err := unix.IoctlGetInt(int(tty.Fd()), unix.TIOCGPGRP)
+	return err == nil && pgid == unix.Getpgrp()
+}
+
+// setDeathSignal ensures the processes started by godoc-static are killed
+// when it exits. Death signals are specific to Linux, so processes join the
+// process group of a watchdog which kills the group when godoc-static exits.
+// Processes started while godoc-static runs in the foreground of a terminal
+// are left in its process group instead, so that they may read from the
+// terminal, as when prompting for credentials, without being stopped. They
+// receive the signals sent from the terminal directly.
+func setDeathSignal(cmd *exec.Cmd) {
+	if terminalForeground() {
+		return
+	}
+
+	watchdogOnce.Do(startWatchdog)
+
+	if pgid := atomic.LoadInt32(&watchdogPgid); pgid != 0 {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: int(pgid)}
+	}
+}
+
+// forwardSignal sends a signal received by godoc-static to the processes it
+// started in the process group of the watchdog, which do not receive the
+// signals sent to the process group of the terminal.
+func forwardSignal(sig os.Signal) {
+	s, ok := sig.(syscall.Signal)
+	if pgid := atomic.LoadInt32(&watchdogPgid); ok && pgid != 0 {
+		syscall.Kill(-int(pgid), s)
+	}
+}
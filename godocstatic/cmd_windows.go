@@ -0,0 +1,50 @@
+//go:build windows
+// +build windows
+
+package godocstatic
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var jobOnce sync.Once
+
+// setDeathSignal ensures the processes started by godoc-static are killed
+// when it exits. Windows has no death signal, so godoc-static assigns itself
+// to a job object which kills all of its processes once closed, as it is when
+// godoc-static exits. Processes started afterwards belong to the job as well.
+func setDeathSignal(cmd *exec.Cmd) {
+	jobOnce.Do(func() {
+		job, err := windows.CreateJobObject(nil, nil)
+		if err != nil {
+			return
+		}
+
+		info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{
+			BasicLimitInformation: windows.JOBOBJECT_BASIC_LIMIT_INFORMATION{
+				LimitFlags: windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE,
+			},
+		}
+		_, err = windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation, uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)))
+		if err != nil {
+			windows.CloseHandle(job)
+			return
+		}
+
+		// The handle is left open until godoc-static exits. Assignment fails
+		// when godoc-static belongs to a job which may not be nested, in
+		// which case processes are left to that job.
+		if windows.AssignProcessToJobObject(job, windows.CurrentProcess()) != nil {
+			windows.CloseHandle(job)
+		}
+	})
+}
+
+// forwardSignal does nothing, as console signals are received by all of the
+// processes attached to the console.
+func forwardSignal(sig os.Signal) {}
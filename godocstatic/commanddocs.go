@@ -0,0 +1,126 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// commandHelpTimeout limits how long a command may run to print its usage.
+const commandHelpTimeout = 10 * time.Second
+
+var commandHelp bool
+
+// captureCommandHelp builds a command and returns what it prints when run
+// with -help, naming the command rather than the temporary binary.
+func captureCommandHelp(ctx context.Context, p *nativePackage) (string, error) {
+	dir, err := ioutil.TempDir(getTmpDir(), "godoc-static-cmd")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Base(p.ImportPath)
+	bin := filepath.Join(dir, name)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "build", "-o", bin, p.ImportPath)
+	cmd.Env = godocEnv
+	cmd.Dir = p.Dir
+	cmd.Stderr = &stderr
+	setDeathSignal(cmd)
+
+	err = cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to build: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	helpCtx, cancel := context.WithTimeout(ctx, commandHelpTimeout)
+	defer cancel()
+
+	var out bytes.Buffer
+	cmd = exec.CommandContext(helpCtx, bin, "-help")
+	cmd.Env = godocEnv
+	cmd.Dir = p.Dir
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	setDeathSignal(cmd)
+
+	cmd.Run() // Commands commonly exit with an error after printing their usage
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	} else if helpCtx.Err() != nil {
+		return "", fmt.Errorf("did not exit within %s", commandHelpTimeout)
+	}
+	return strings.TrimSpace(strings.Replace(out.String(), bin, name, -1)), nil
+}
+
+// addCommandDocs adds the usage of a command, as printed when run with -help
+// when -command-help is supplied, and a list of its source files to its page,
+// which otherwise only includes its doc comment.
+func addCommandDocs(ctx context.Context, doc *goquery.Document, pkg string, basePath string) error {
+	p, err := loadNativePackage(ctx, pkg)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	} else if err != nil || p.Name != "main" {
+		return nil // This is expected for packages without source files
+	}
+
+	outPkg := vanityPath(pkg)
+
+	var b, nav strings.Builder
+	if commandHelp {
+		usage, err := captureCommandHelp(ctx, p)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		} else if err != nil {
+			logger.Printf("Warning: failed to capture -help output of %s: %s", pkg, err)
+		} else if usage != "" {
+			b.WriteString(`<div id="cmd-usage">
+<h2>Usage</h2>
+<pre>` + html.EscapeString(usage) + `</pre>
+</div>
+`)
+			nav.WriteString(`<dd><a href="#cmd-usage">Usage</a></dd>`)
+		}
+	}
+
+	files := append(append([]string{}, p.GoFiles...), p.CgoFiles...)
+	if len(files) > 0 {
+		b.WriteString(`<div id="cmd-files">
+<h2>Files</h2>
+<p>
+`)
+		for _, file := range files {
+			b.WriteString(`<a href="` + basePath + "src/" + outPkg + "/" + file + `.html">` + html.EscapeString(file) + `</a>
+`)
+		}
+		b.WriteString(`</p>
+</div>
+`)
+		nav.WriteString(`<dd><a href="#cmd-files">Files</a></dd>`)
+	}
+
+	doc.Find("#footer").Last().BeforeHtml(b.String())
+
+	if shortNav := doc.Find("#short-nav").First(); shortNav.Length() > 0 {
+		shortNav.Find("dl").Last().AppendHtml(nav.String())
+	} else {
+		doc.Find("#page h1").First().AfterHtml(`<div id="short-nav">
+<dl>
+` + nav.String() + `
+</dl>
+</div>`)
+	}
+	return nil
+}
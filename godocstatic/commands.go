@@ -0,0 +1,151 @@
+package godocstatic
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+type command struct {
+	Name        string
+	Description string
+	Args        string
+	Flags       *flag.FlagSet
+	Run         func(ctx context.Context, args []string) error
+}
+
+// defaultCommand is run when the first argument is not a command name.
+const defaultCommand = "generate"
+
+var commands = map[string]*command{}
+
+func init() {
+	addCommand("generate", "generate documentation", "[package or path...]", registerGenerateFlags, runGenerate)
+	addCommand("serve", "serve generated documentation locally", "", registerServeFlags, runServe)
+	addCommand("publish", "copy generated documentation to a publishing directory", "", registerPublishFlags, runPublish)
+	addCommand("check", "check generated documentation for broken links", "", registerCheckFlags, runCheck)
+	addCommand("diff", "list pages which differ between two generated sites", "old-destination new-destination", registerCommonFlags, runDiff)
+	addCommand("init", "write a starter configuration and generation script to a module", "", registerInitFlags, runInit)
+	addCommand("completion", "print shell completion script", "bash|zsh|fish", nil, runCompletion)
+}
+
+func addCommand(name string, description string, args string, register func(flags *flag.FlagSet), run func(ctx context.Context, args []string) error) {
+	c := &command{
+		Name:        name,
+		Description: description,
+		Args:        args,
+		Flags:       flag.NewFlagSet(name, flag.ExitOnError),
+		Run:         run,
+	}
+	if register != nil {
+		register(c.Flags)
+	}
+	c.Flags.Usage = func() {
+		fmt.Fprintf(c.Flags.Output(), "usage: godoc-static %s\n\n%s.\n", strings.TrimSpace(c.Name+" [options] "+c.Args), strings.ToUpper(c.Description[:1])+c.Description[1:])
+		var hasFlags bool
+		c.Flags.VisitAll(func(*flag.Flag) {
+			hasFlags = true
+		})
+		if hasFlags {
+			fmt.Fprint(c.Flags.Output(), "\nOptions:\n")
+			c.Flags.PrintDefaults()
+		}
+	}
+	commands[name] = c
+}
+
+func registerCommonFlags(flags *flag.FlagSet) {
+	flags.StringVar(&configFile, "config", "", "path to configuration file")
+	flags.BoolVar(&quiet, "quiet", false, "disable all logging except errors")
+	flags.BoolVar(&verbose, "verbose", false, "enable verbose logging")
+}
+
+func commandNames() []string {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func printUsage() {
+	fmt.Fprint(os.Stderr, "usage: godoc-static [command] [options] [arguments]\n\nCommands:\n")
+	for _, name := range commandNames() {
+		fmt.Fprintf(os.Stderr, "  %-12s%s\n", name, commands[name].Description)
+	}
+	fmt.Fprintf(os.Stderr, "\nWhen no command is supplied, %s is run.\nRun godoc-static help [command] for the options of a command.\n", defaultCommand)
+}
+
+// runCommand runs the command named by the first argument, or the default
+// command when the first argument is not a command name.
+func runCommand(args []string) error {
+	c := commands[defaultCommand]
+	if len(args) > 0 {
+		if args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+			if len(args) > 1 && commands[args[1]] != nil {
+				commands[args[1]].Flags.Usage()
+			} else {
+				printUsage()
+			}
+			return nil
+		}
+
+		if commands[args[0]] != nil {
+			c = commands[args[0]]
+			args = args[1:]
+		}
+	}
+
+	if c.Name == "completion" {
+		return c.Run(context.Background(), args)
+	}
+
+	c.Flags.Parse(args)
+
+	err := applyConfig(c.Flags)
+	if err != nil {
+		return err
+	}
+
+	if quiet {
+		log.SetOutput(ioutil.Discard)
+	}
+
+	// Interrupt and termination signals are forwarded to the processes
+	// started by every command, which may not receive them otherwise.
+	// Generation is cancelled, removing its partial output, while other
+	// commands are terminated by the signal as they would be otherwise.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	go func() {
+		select {
+		case sig := <-sigs:
+			forwardSignal(sig)
+			if c.Name == "generate" {
+				receivedSignal = sig
+				cancel()
+				return
+			}
+
+			signal.Stop(sigs)
+			if p, err := os.FindProcess(os.Getpid()); err != nil || p.Signal(sig) != nil {
+				os.Exit(1)
+			}
+		case <-ctx.Done():
+		}
+	}()
+
+	return c.Run(ctx, c.Flags.Args())
+}
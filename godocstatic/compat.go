@@ -0,0 +1,92 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var compatReport string
+
+// rewriteMiss records an element of a page served by godoc which was expected
+// to be rewritten, but which was not found. Rewrite misses are caused by
+// changes to the layout of the pages served by godoc, and result in pages
+// which are unstyled or broken.
+type rewriteMiss struct {
+	Page     string
+	Rewrite  string
+	Selector string
+}
+
+var (
+	rewriteMisses     []rewriteMiss
+	rewriteMissesLock sync.Mutex
+)
+
+// expectElement returns the elements of a page matching selector, recording a
+// rewrite miss when there are none.
+func expectElement(doc *goquery.Document, page string, rewrite string, selector string) *goquery.Selection {
+	selection := doc.Find(selector)
+	if selection.Length() == 0 {
+		recordRewriteMiss(page, rewrite, selector)
+	}
+	return selection
+}
+
+func recordRewriteMiss(page string, rewrite string, selector string) {
+	rewriteMissesLock.Lock()
+	defer rewriteMissesLock.Unlock()
+
+	rewriteMisses = append(rewriteMisses, rewriteMiss{Page: page, Rewrite: rewrite, Selector: selector})
+}
+
+// missedRewrites returns the rewrites which missed, sorted by name, and the
+// pages each rewrite missed on.
+func missedRewrites() ([]string, map[string][]string) {
+	rewriteMissesLock.Lock()
+	defer rewriteMissesLock.Unlock()
+
+	pages := make(map[string][]string)
+	for _, miss := range rewriteMisses {
+		key := miss.Rewrite + " (" + miss.Selector + ")"
+		pages[key] = append(pages[key], miss.Page)
+	}
+
+	rewrites := make([]string, 0, len(pages))
+	for rewrite := range pages {
+		rewrites = append(rewrites, rewrite)
+		sort.Strings(pages[rewrite])
+	}
+	sort.Strings(rewrites)
+	return rewrites, pages
+}
+
+// warnRewriteMisses logs a warning for each rewrite which missed.
+func warnRewriteMisses() {
+	rewrites, pages := missedRewrites()
+	for _, rewrite := range rewrites {
+		logger.Printf("Warning: %s was not rewritten on %d pages, such as %s. The pages served by godoc may have changed.", rewrite, len(pages[rewrite]), pages[rewrite][0])
+	}
+}
+
+// writeCompatReport writes the rewrite misses recorded while generating
+// documentation to the report file.
+func writeCompatReport(ctx context.Context, buf *bytes.Buffer) error {
+	rewrites, pages := missedRewrites()
+
+	buf.Reset()
+	buf.WriteString(fmt.Sprintf("Compatibility report: %d rewrites missed\n", len(rewrites)))
+
+	for _, rewrite := range rewrites {
+		buf.WriteString("\n" + rewrite + "\n")
+		for _, page := range pages[rewrite] {
+			buf.WriteString("\t" + page + "\n")
+		}
+	}
+
+	return writeFile(ctx, buf, "", compatReport)
+}
@@ -0,0 +1,208 @@
+package godocstatic
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// moduleSearchDepth is how many directories deep local modules are discovered.
+const moduleSearchDepth = 3
+
+type completionFlag struct {
+	Name  string
+	Usage string
+	Bool  bool
+	Path  string // "file", "dir" or empty
+}
+
+func completionFlags(flags *flag.FlagSet) []completionFlag {
+	var completionFlags []completionFlag
+	flags.VisitAll(func(f *flag.Flag) {
+		c := completionFlag{Name: f.Name, Usage: f.Usage}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			c.Bool = true
+		}
+		if strings.HasSuffix(f.Name, "-file") || f.Name == "config" {
+			c.Path = "file"
+		} else if f.Name == "destination" {
+			c.Path = "dir"
+		}
+		completionFlags = append(completionFlags, c)
+	})
+	return completionFlags
+}
+
+// localModules returns the directories below dir which contain a go.mod file.
+func localModules(dir string) []string {
+	var modules []string
+	filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(dir, p)
+		if info.IsDir() {
+			name := info.Name()
+			if rel != "." && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			if strings.Count(rel, string(filepath.Separator)) >= moduleSearchDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.Name() == "go.mod" {
+			modulePath := filepath.Dir(rel)
+			if modulePath == "." {
+				modules = append(modules, ".")
+			} else {
+				modules = append(modules, "."+string(filepath.Separator)+modulePath)
+			}
+		}
+		return nil
+	})
+	sort.Strings(modules)
+	return modules
+}
+
+func runCompletion(ctx context.Context, args []string) error {
+	return writeCompletion(os.Stdout, args)
+}
+
+func writeCompletion(w io.Writer, args []string) error {
+	if len(args) == 1 && args[0] == "-list-modules" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		for _, module := range localModules(wd) {
+			fmt.Fprintln(w, module)
+		}
+		return nil
+	}
+
+	if len(args) != 1 {
+		return errors.New("usage: godoc-static completion bash|zsh|fish")
+	}
+
+	flags := completionFlags(commands[defaultCommand].Flags)
+	names := commandNames()
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(w, names, flags)
+	case "zsh":
+		writeZshCompletion(w, names, flags)
+	case "fish":
+		writeFishCompletion(w, names, flags)
+	default:
+		return fmt.Errorf("unsupported shell %s: must be one of bash, zsh, fish", args[0])
+	}
+	return nil
+}
+
+func writeBashCompletion(w io.Writer, commandNames []string, flags []completionFlag) {
+	var names, files, dirs, values []string
+	for _, f := range flags {
+		names = append(names, "-"+f.Name)
+		switch {
+		case f.Path == "file":
+			files = append(files, "-"+f.Name)
+		case f.Path == "dir":
+			dirs = append(dirs, "-"+f.Name)
+		case !f.Bool:
+			values = append(values, "-"+f.Name)
+		}
+	}
+
+	fmt.Fprintf(w, `# bash completion for godoc-static
+_godoc_static() {
+	local cur prev
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+	case "$prev" in
+	%s)
+		COMPREPLY=( $(compgen -f -- "$cur") )
+		return
+		;;
+	%s)
+		COMPREPLY=( $(compgen -d -- "$cur") )
+		return
+		;;
+	%s)
+		return
+		;;
+	esac
+
+	if [[ "$cur" == -* ]]; then
+		COMPREPLY=( $(compgen -W "%s" -- "$cur") )
+		return
+	fi
+
+	local commands=""
+	if [[ $COMP_CWORD -eq 1 ]]; then
+		commands="%s"
+	fi
+
+	COMPREPLY=( $(compgen -W "$commands $(godoc-static completion -list-modules 2>/dev/null)" -- "$cur") $(compgen -d -- "$cur") )
+}
+complete -F _godoc_static godoc-static
+`, strings.Join(files, "|"), strings.Join(dirs, "|"), strings.Join(values, "|"), strings.Join(names, " "), strings.Join(commandNames, " "))
+}
+
+func writeZshCompletion(w io.Writer, commandNames []string, flags []completionFlag) {
+	fmt.Fprint(w, `#compdef godoc-static
+
+_godoc_static_modules() {
+	local -a commands modules
+	if (( CURRENT == 2 )); then
+		commands=( `+strings.Join(commandNames, " ")+` )
+		_describe 'command' commands
+	fi
+	modules=( ${(f)"$(godoc-static completion -list-modules 2>/dev/null)"} )
+	_describe 'local module' modules
+	_files -/
+}
+
+_arguments \
+`)
+	for _, f := range flags {
+		usage := strings.NewReplacer(`'`, `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(f.Usage)
+		switch {
+		case f.Bool:
+			fmt.Fprintf(w, "\t'-%s[%s]' \\\n", f.Name, usage)
+		case f.Path == "file":
+			fmt.Fprintf(w, "\t'-%s=[%s]:file:_files' \\\n", f.Name, usage)
+		case f.Path == "dir":
+			fmt.Fprintf(w, "\t'-%s=[%s]:directory:_files -/' \\\n", f.Name, usage)
+		default:
+			fmt.Fprintf(w, "\t'-%s=[%s]:value:' \\\n", f.Name, usage)
+		}
+	}
+	fmt.Fprint(w, "\t'*:package:_godoc_static_modules'\n")
+}
+
+func writeFishCompletion(w io.Writer, commandNames []string, flags []completionFlag) {
+	fmt.Fprint(w, "# fish completion for godoc-static\n")
+	fmt.Fprintf(w, "complete -c godoc-static -n '__fish_use_subcommand' -f -a '%s'\n", strings.Join(commandNames, " "))
+	for _, f := range flags {
+		usage := strings.Replace(f.Usage, `'`, `\'`, -1)
+		switch {
+		case f.Bool:
+			fmt.Fprintf(w, "complete -c godoc-static -o %s -d '%s'\n", f.Name, usage)
+		case f.Path != "":
+			fmt.Fprintf(w, "complete -c godoc-static -o %s -r -F -d '%s'\n", f.Name, usage)
+		default:
+			fmt.Fprintf(w, "complete -c godoc-static -o %s -r -f -d '%s'\n", f.Name, usage)
+		}
+	}
+	fmt.Fprint(w, "complete -c godoc-static -a '(godoc-static completion -list-modules 2>/dev/null)' -d 'local module'\n")
+}
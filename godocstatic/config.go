@@ -0,0 +1,135 @@
+package godocstatic
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const envPrefix = "GODOC_STATIC_"
+
+// envName returns the environment variable which may be used to set a flag.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(flagName, "-", "_", -1))
+}
+
+// readConfig reads a configuration file consisting of flag names and values
+// separated by an equals sign, one per line. Options which may be repeated are
+// set to each of their values when listed multiple times. Blank lines and
+// lines starting with # are ignored.
+func readConfig(configPath string) (map[string][]string, error) {
+	f, err := os.Open(configPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	config := make(map[string][]string)
+
+	scanner := bufio.NewScanner(f)
+	var line int
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		equals := strings.IndexRune(text, '=')
+		if equals <= 0 {
+			return nil, fmt.Errorf("invalid configuration on line %d: expected name = value", line)
+		}
+
+		name := strings.TrimPrefix(strings.TrimSpace(text[:equals]), "-")
+		value := strings.TrimSpace(text[equals+1:])
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = value[1 : len(value)-1]
+		}
+
+		config[name] = append(config[name], value)
+	}
+	return config, scanner.Err()
+}
+
+// envValues returns the values of a flag set by an environment variable.
+// Options which may be repeated are set to each line of the variable.
+func envValues(f *flag.Flag, value string) []string {
+	if _, ok := f.Value.(*stringsFlag); !ok {
+		return []string{value}
+	}
+
+	var values []string
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			values = append(values, line)
+		}
+	}
+	return values
+}
+
+// applyConfig sets each flag not supplied on the command line from its
+// GODOC_STATIC_* environment variable or, when that is unset, from the
+// configuration file.
+func applyConfig(flags *flag.FlagSet) error {
+	setFlags := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
+
+	if !setFlags["config"] {
+		if v, ok := os.LookupEnv(envName("config")); ok {
+			configFile = v
+		}
+	}
+
+	var config map[string][]string
+	if configFile != "" {
+		var err error
+		config, err = readConfig(configFile)
+		if err != nil {
+			return fmt.Errorf("failed to read config file %s: %s", configFile, err)
+		}
+
+	CONFIGOPTIONS:
+		for name := range config {
+			for _, c := range commands {
+				if c.Flags.Lookup(name) != nil {
+					continue CONFIGOPTIONS
+				}
+			}
+			return fmt.Errorf("failed to read config file %s: unknown option %s", configFile, name)
+		}
+	}
+
+	var err error
+	flags.VisitAll(func(f *flag.Flag) {
+		if err != nil || setFlags[f.Name] || f.Name == "config" {
+			return
+		}
+
+		var values []string
+		value, ok := os.LookupEnv(envName(f.Name))
+		source := envName(f.Name)
+		if ok {
+			values = envValues(f, value)
+		} else {
+			values, ok = config[f.Name]
+			source = configFile
+		}
+		if !ok {
+			return
+		}
+
+		for _, value := range values {
+			setErr := flags.Set(f.Name, value)
+			if setErr != nil {
+				err = fmt.Errorf("invalid value %q for option %s from %s: %s", value, f.Name, source, setErr)
+				return
+			}
+		}
+	})
+	return err
+}
@@ -0,0 +1,202 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	gohtml "golang.org/x/net/html"
+)
+
+const statusPage = "status.html"
+
+var deadline time.Duration
+
+var (
+	// skippedDocs and skippedSources list the packages whose documentation
+	// and source pages were not written before the deadline.
+	skippedDocs    []string
+	skippedSources []string
+)
+
+// completedPackages records the packages a scrape function completes.
+type completedPackages struct {
+	pkgs map[string]bool
+	lock sync.Mutex
+}
+
+func newCompletedPackages() *completedPackages {
+	return &completedPackages{pkgs: make(map[string]bool)}
+}
+
+// track returns a scrape function recording the packages scrape completes.
+func (c *completedPackages) track(scrape func(ctx context.Context, buf *bytes.Buffer, pkg string) error) func(ctx context.Context, buf *bytes.Buffer, pkg string) error {
+	return func(ctx context.Context, buf *bytes.Buffer, pkg string) error {
+		err := scrape(ctx, buf, pkg)
+		if err == nil {
+			c.lock.Lock()
+			c.pkgs[pkg] = true
+			c.lock.Unlock()
+		}
+		return err
+	}
+}
+
+// missing returns the packages which were not completed.
+func (c *completedPackages) missing(pkgs []string) []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var missing []string
+	for _, pkg := range pkgs {
+		if !c.pkgs[pkg] {
+			missing = append(missing, pkg)
+		}
+	}
+	return missing
+}
+
+// packageContext returns the context package pages are written with, which
+// expires at the deadline when -deadline is supplied.
+func packageContext(ctx context.Context, started time.Time) (context.Context, context.CancelFunc) {
+	if deadline <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, started.Add(deadline))
+}
+
+// deadlineReached reports whether package pages stopped being written
+// because the deadline was reached, rather than because generation was
+// cancelled.
+func deadlineReached(ctx context.Context, pkgCtx context.Context) bool {
+	return deadline > 0 && ctx.Err() == nil && pkgCtx.Err() == context.DeadlineExceeded
+}
+
+// skipUnfinishedPackages records the packages whose pages were not written before the
+// deadline, writing a page in place of the documentation of each package
+// which was skipped, and forgets them in the cache so that they are written
+// by the next run.
+func skipUnfinishedPackages(ctx context.Context, buf *bytes.Buffer, pkgs []string, docs *completedPackages, sources *completedPackages) error {
+	skippedDocs = docs.missing(pkgs)
+	skippedSources = sources.missing(pkgs)
+
+	logger.Printf("Warning: the deadline of %s was reached, skipping the documentation of %d packages and the source files of %d packages. Skipped packages are listed on %s.", deadline, len(skippedDocs), len(skippedSources), statusPage)
+
+	if cacheFile != "" {
+		cacheLock.Lock()
+		for _, pkg := range append(append([]string{}, skippedDocs...), skippedSources...) {
+			delete(currentCache.Packages, pkg)
+		}
+		cacheLock.Unlock()
+	}
+
+	for _, pkg := range skippedDocs {
+		err := writeSkippedPage(ctx, buf, pkg)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSkippedPage writes a stub page in place of the documentation of a
+// package which was skipped at the deadline.
+func writeSkippedPage(ctx context.Context, buf *bytes.Buffer, pkg string) error {
+	outPkg := vanityPath(pkg)
+	basePath := relativeBasePath(outPkg)
+
+	page := nativePage(path.Base(pkg), `<h1>Package `+html.EscapeString(path.Base(outPkg))+`</h1>
+<div class="module-warning">
+<p><strong>Documentation unavailable:</strong> the documentation of package `+html.EscapeString(outPkg)+` was skipped when the deadline of generation was reached. See the <a href="`+basePath+statusPage+`">generation status</a>.</p>
+</div>`)
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return fmt.Errorf("failed to parse page of %s: %s", pkg, err)
+	}
+
+	doc.Find("title").First().SetHtml(fmt.Sprintf("%s - %s", pageTitle(pkg), siteName))
+
+	updatePage(doc, path.Join(outPkg, "index.html"), basePath, siteName)
+
+	localPkgPath := filepath.Join(siteDestination, outPkg)
+
+	err = os.MkdirAll(localPkgPath, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to make directory %s: %s", localPkgPath, err)
+	}
+
+	buf.Reset()
+	err = gohtml.Render(buf, doc.Nodes[0])
+	if err != nil {
+		return fmt.Errorf("failed to render HTML: %s", err)
+	}
+	return writeFile(ctx, buf, outPkg, "index.html")
+}
+
+// writeStatusPage writes a page stating whether documentation was generated
+// for every package before the deadline, listing the packages skipped.
+func writeStatusPage(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	var index string
+	if linkIndex {
+		index = "/index.html"
+	}
+
+	buf.Reset()
+	buf.WriteString(sitePageHeader("Generation status - " + siteName))
+	buf.WriteString(`
+<h1>
+	Generation status
+</h1>
+<div id="generation-status">
+`)
+
+	if len(skippedDocs) == 0 && len(skippedSources) == 0 {
+		buf.WriteString(`<p>Documentation was generated for all ` + strconv.Itoa(len(pkgs)) + ` packages within the deadline of ` + deadline.String() + `.</p>
+`)
+	} else {
+		buf.WriteString(`<p>Generation stopped at the deadline of ` + deadline.String() + `. Documentation was generated for ` + strconv.Itoa(len(pkgs)-len(skippedDocs)) + ` of ` + strconv.Itoa(len(pkgs)) + ` packages.</p>
+`)
+	}
+
+	for _, section := range []struct {
+		id      string
+		heading string
+		skipped []string
+	}{
+		{"skipped-docs", "Skipped documentation", skippedDocs},
+		{"skipped-sources", "Skipped source files", skippedSources},
+	} {
+		if len(section.skipped) == 0 {
+			continue
+		}
+
+		buf.WriteString(`<h2 id="` + section.id + `">` + section.heading + ` (` + strconv.Itoa(len(section.skipped)) + `)</h2>
+<ul>
+`)
+		for _, pkg := range section.skipped {
+			outPkg := vanityPath(pkg)
+			buf.WriteString(`<li><a href="` + outPkg + index + `">` + html.EscapeString(displayPath(outPkg)) + `</a></li>
+`)
+		}
+		buf.WriteString(`</ul>
+`)
+	}
+
+	buf.WriteString(`</div>
+<div id="footer">` + siteFooterText("") + `</div>
+</div>
+</div>
+` + searchTags("") + `</body>
+</html>
+`)
+	return writeFile(ctx, buf, "", statusPage)
+}
@@ -0,0 +1,194 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// moduleDependenciesPage is the name of the page listing the dependency graph
+// of a module, written to the directory of each documented module.
+const moduleDependenciesPage = "dependencies.html"
+
+var dependencyGraph bool
+
+// moduleDependencyPages lists the path of the dependency graph page of each
+// module which has one, by module path.
+var moduleDependencyPages = make(map[string]string)
+
+// moduleGraph is the requirement graph of a module reported by go mod graph,
+// identifying modules as path@version and the main module by its path.
+type moduleGraph struct {
+	Requires   map[string][]string
+	RequiredBy map[string][]string
+}
+
+// loadModuleGraph runs go mod graph in the directory of a module.
+func loadModuleGraph(ctx context.Context, m *moduleInfo) (*moduleGraph, error) {
+	var buf, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "go", "mod", "graph")
+	cmd.Env = godocEnv
+	cmd.Dir = m.Dir
+	cmd.Stdout = &buf
+	cmd.Stderr = &stderr
+	setDeathSignal(cmd)
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	g := &moduleGraph{
+		Requires:   make(map[string][]string),
+		RequiredBy: make(map[string][]string),
+	}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		g.Requires[fields[0]] = append(g.Requires[fields[0]], fields[1])
+		g.RequiredBy[fields[1]] = append(g.RequiredBy[fields[1]], fields[0])
+	}
+	return g, nil
+}
+
+// splitModuleVersion splits a module identified as path@version.
+func splitModuleVersion(mod string) (string, string) {
+	if at := strings.LastIndex(mod, "@"); at > 0 {
+		return mod[:at], mod[at+1:]
+	}
+	return mod, ""
+}
+
+// dependencyAnchor returns the anchor of a module on a dependency graph page.
+func dependencyAnchor(mod string) string {
+	return "dep-" + strings.NewReplacer("/", "-", "@", "-").Replace(mod)
+}
+
+// dependencyURL returns the URL of the documentation of a module at a version:
+// its documentation on this site when any of its packages are documented, or
+// else its documentation on pkg.go.dev. An empty string is returned for the
+// go and toolchain requirements.
+func dependencyURL(modulePath string, version string, basePath string) string {
+	if modulePath == "go" || modulePath == "toolchain" {
+		return ""
+	}
+
+	for pkg := range documentedPackages {
+		if pkg == modulePath || strings.HasPrefix(pkg, modulePath+"/") {
+			var index string
+			if linkIndex {
+				index = "index.html"
+			}
+			return basePath + vanityPath(modulePath) + "/" + index
+		}
+	}
+
+	if version == "" {
+		return "https://pkg.go.dev/mod/" + modulePath
+	}
+	return "https://pkg.go.dev/mod/" + modulePath + "@" + version
+}
+
+// dependencyLinks returns links to the rows of modules on a dependency graph
+// page.
+func dependencyLinks(mods []string) string {
+	if len(mods) == 0 {
+		return "-"
+	}
+
+	links := make([]string, len(mods))
+	for i, mod := range mods {
+		links[i] = `<a href="#` + html.EscapeString(dependencyAnchor(mod)) + `">` + html.EscapeString(mod) + `</a>`
+	}
+	return strings.Join(links, "<br>")
+}
+
+// writeModuleDependencyPages writes a page for each documented module listing
+// the modules in its requirement graph, each linked to its documentation and
+// the modules it requires and is required by.
+func writeModuleDependencyPages(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	moduleDependencyPages = make(map[string]string)
+
+	documented := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if m := packageModule(pkg); m != nil {
+			documented[m.Path] = true
+		}
+	}
+
+	// Dependencies documented from the module cache are not listed.
+	moduleCache := commandOutput(ctx, getTmpDir(), "go", "env", "GOMODCACHE")
+
+	for _, name := range moduleNames() {
+		if !documented[name] || (moduleCache != "" && strings.HasPrefix(modules[name].Dir, moduleCache+string(filepath.Separator))) {
+			continue
+		}
+
+		g, err := loadModuleGraph(ctx, modules[name])
+		if ctx.Err() != nil {
+			return ctx.Err()
+		} else if err != nil {
+			logger.Printf("Warning: failed to list the dependencies of %s: %s", name, err)
+			continue
+		}
+
+		var mods []string
+		for mod := range g.RequiredBy {
+			mods = append(mods, mod)
+		}
+		sort.Strings(mods)
+		mods = uniqueStrings(append([]string{name}, mods...))
+
+		outDir := vanityPath(name)
+		basePath := relativeBasePath(outDir)
+
+		buf.Reset()
+		buf.WriteString(pageHeader("Dependencies of "+html.EscapeString(displayPath(outDir))+" - "+siteName, basePath))
+		buf.WriteString(`
+<h1>
+	Dependencies of ` + html.EscapeString(displayPath(outDir)) + `
+</h1>
+<p>Modules in the requirement graph of the module, as reported by <code>go mod graph</code>. The version of each module selected by the build is the highest version listed.</p>
+<table id="module-dependencies">
+<tr><th>Module</th><th>Version</th><th>Requires</th><th>Required by</th></tr>
+`)
+		for _, mod := range mods {
+			modulePath, version := splitModuleVersion(mod)
+			name := html.EscapeString(modulePath)
+			if u := dependencyURL(modulePath, version, basePath); u != "" {
+				name = `<a href="` + html.EscapeString(u) + `">` + name + `</a>`
+			}
+			buf.WriteString(`<tr id="` + html.EscapeString(dependencyAnchor(mod)) + `"><td>` + name + `</td><td>` + html.EscapeString(version) + `</td><td>` + dependencyLinks(g.Requires[mod]) + `</td><td>` + dependencyLinks(g.RequiredBy[mod]) + `</td></tr>
+`)
+		}
+		buf.WriteString(`</table>
+<div id="footer">` + siteFooterText(basePath) + `</div>
+</div>
+</div>
+` + searchTags(basePath) + `</body>
+</html>
+`)
+
+		err = os.MkdirAll(filepath.Join(siteDestination, outDir), 0755)
+		if err != nil {
+			return fmt.Errorf("failed to make directory %s: %s", outDir, err)
+		}
+
+		err = writeFile(ctx, buf, outDir, moduleDependenciesPage)
+		if err != nil {
+			return err
+		}
+		moduleDependencyPages[name] = path.Join(outDir, moduleDependenciesPage)
+	}
+	return nil
+}
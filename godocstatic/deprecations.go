@@ -0,0 +1,305 @@
+package godocstatic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const deprecationsPage = "deprecations.html"
+
+var (
+	deprecations            bool
+	deprecationRemovalsFile string
+)
+
+// packageDeprecation describes a Deprecated: paragraph in the doc comment of
+// a package or one of its exported symbols.
+type packageDeprecation struct {
+	// Symbol is the exported symbol which is deprecated, identified as on
+	// package pages, or an empty string when the package is deprecated.
+	Symbol string
+	Notice string
+	File   string
+	Line   int
+	// Date and Commit identify the commit which last changed the line
+	// starting the paragraph, as reported by git blame. They are unset when
+	// the package is not in a git repository.
+	Date   time.Time
+	Commit string
+}
+
+// readDeprecationRemovals reads a file listing the versions deprecated APIs
+// are planned to be removed in, consisting of an import path, optionally
+// followed by # and a symbol, and a version separated by whitespace, one per
+// line, such as example.com/pkg#Client.Do v2.0.0. Blank lines and lines
+// starting with # are ignored.
+func readDeprecationRemovals(removalsPath string) (map[string]string, error) {
+	f, err := os.Open(removalsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	removals := make(map[string]string)
+
+	scanner := bufio.NewScanner(f)
+	var line int
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid removal on line %d: expected import path or import path#Symbol and version", line)
+		}
+		removals[strings.TrimSuffix(fields[0], "#")] = fields[1]
+	}
+	return removals, scanner.Err()
+}
+
+// deprecationNotice returns the Deprecated: paragraph of a doc comment and the
+// line it starts on, or an empty string when the comment has none.
+func deprecationNotice(fset *token.FileSet, group *ast.CommentGroup) (string, int) {
+	if group == nil {
+		return "", 0
+	}
+
+	var line int
+	for _, c := range group.List {
+		for i, text := range strings.Split(c.Text, "\n") {
+			text = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(text, "//"), "/*"))
+			if strings.HasPrefix(text, "Deprecated:") {
+				line = fset.Position(c.Pos()).Line + i
+				break
+			}
+		}
+		if line != 0 {
+			break
+		}
+	}
+	if line == 0 {
+		return "", 0
+	}
+
+	for _, paragraph := range strings.Split(group.Text(), "\n\n") {
+		if strings.HasPrefix(paragraph, "Deprecated:") {
+			notice := strings.TrimSpace(strings.TrimPrefix(paragraph, "Deprecated:"))
+			return strings.Join(strings.Fields(notice), " "), line
+		}
+	}
+	return "", 0
+}
+
+// fileDeprecations returns the deprecations of the package clause and the
+// exported declarations of a file.
+func fileDeprecations(fset *token.FileSet, f *ast.File) []packageDeprecation {
+	var found []packageDeprecation
+	add := func(group *ast.CommentGroup, symbol string) {
+		if notice, line := deprecationNotice(fset, group); line != 0 {
+			found = append(found, packageDeprecation{Symbol: symbol, Notice: notice, Line: line})
+		}
+	}
+
+	add(f.Doc, "")
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if !decl.Name.IsExported() {
+				continue
+			} else if decl.Recv == nil {
+				add(decl.Doc, decl.Name.Name)
+			} else if recv := exportedReceiver(decl.Recv); recv != "" {
+				add(decl.Doc, recv+"."+decl.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				var name *ast.Ident
+				var group *ast.CommentGroup
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					name, group = spec.Name, spec.Doc
+				case *ast.ValueSpec:
+					name, group = spec.Names[0], spec.Doc
+				default:
+					continue
+				}
+
+				if !name.IsExported() {
+					continue
+				} else if len(decl.Specs) == 1 || group == nil {
+					group = decl.Doc
+				}
+				add(group, name.Name)
+			}
+		}
+	}
+	return found
+}
+
+// blameLine returns the date and commit of the last change to a line of a
+// file, or a zero time when the file is not in a git repository.
+func blameLine(ctx context.Context, file string, line int) (time.Time, string) {
+	l := strconv.Itoa(line)
+	output := commandOutput(ctx, filepath.Dir(file), "git", "blame", "--porcelain", "-L", l+","+l, "--", filepath.Base(file))
+	if output == "" {
+		return time.Time{}, ""
+	}
+
+	var (
+		date   time.Time
+		commit = strings.Fields(output)[0]
+	)
+	for _, field := range strings.Split(output, "\n") {
+		if strings.HasPrefix(field, "author-time ") {
+			seconds, err := strconv.ParseInt(strings.TrimPrefix(field, "author-time "), 10, 64)
+			if err == nil {
+				date = time.Unix(seconds, 0).UTC()
+			}
+			break
+		}
+	}
+	if strings.Trim(commit, "0") == "" {
+		// The line is not committed yet.
+		return time.Time{}, ""
+	}
+	return date, commit
+}
+
+// loadDeprecations returns the deprecations of a package, dated using git
+// blame.
+func loadDeprecations(ctx context.Context, pkg string) ([]packageDeprecation, error) {
+	p, err := loadNativePackage(ctx, pkg)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	} else if err != nil {
+		return nil, nil // This is expected for packages without source files
+	}
+
+	fset := token.NewFileSet()
+	var found []packageDeprecation
+	for _, file := range append(append([]string{}, p.GoFiles...), p.CgoFiles...) {
+		filePath := filepath.Join(p.Dir, file)
+		f, err := parser.ParseFile(fset, filePath, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, d := range fileDeprecations(fset, f) {
+			d.File = file
+			d.Date, d.Commit = blameLine(ctx, filePath, d.Line)
+			found = append(found, d)
+		}
+	}
+	return found, nil
+}
+
+// writeDeprecationsPage writes a page listing the deprecated packages and
+// symbols of every documented package, most recently deprecated first, with
+// the versions they are planned to be removed in.
+func writeDeprecationsPage(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	var index string
+	if linkIndex {
+		index = "/index.html"
+	}
+
+	var removals map[string]string
+	if deprecationRemovalsFile != "" {
+		var err error
+		removals, err = readDeprecationRemovals(deprecationRemovalsFile)
+		if err != nil {
+			return fmt.Errorf("failed to read deprecation removals file %s: %s", deprecationRemovalsFile, err)
+		}
+	}
+
+	type deprecatedAPI struct {
+		packageDeprecation
+		Pkg string
+	}
+	var apis []deprecatedAPI
+	for _, pkg := range pkgs {
+		if _, ok := listFailures[pkg]; ok {
+			continue
+		}
+
+		found, err := loadDeprecations(ctx, pkg)
+		if err != nil {
+			return fmt.Errorf("failed to read deprecations of %s: %s", pkg, err)
+		}
+		for _, d := range found {
+			apis = append(apis, deprecatedAPI{packageDeprecation: d, Pkg: pkg})
+		}
+	}
+
+	// Undated deprecations are listed last, in the order of the packages.
+	sort.SliceStable(apis, func(i, j int) bool {
+		if apis[i].Date.IsZero() || apis[j].Date.IsZero() {
+			return !apis[i].Date.IsZero() && apis[j].Date.IsZero()
+		}
+		return apis[i].Date.After(apis[j].Date)
+	})
+
+	buf.Reset()
+	buf.WriteString(sitePageHeader("Deprecations - " + siteName))
+	buf.WriteString(`
+<h1>
+	Deprecations
+</h1>
+<div id="pkg-deprecations">
+`)
+	if len(apis) == 0 {
+		buf.WriteString(`<p>No packages or symbols are deprecated.</p>
+`)
+	} else {
+		buf.WriteString(`<table>
+<tr><th>Deprecated</th><th>API</th><th>Notice</th><th>Removal</th><th>Source</th></tr>
+`)
+		for _, api := range apis {
+			outPkg := vanityPath(api.Pkg)
+
+			date := "Unknown"
+			if !api.Date.IsZero() {
+				date = `<time datetime="` + api.Date.Format(time.RFC3339) + `" title="` + html.EscapeString(api.Commit) + `">` + api.Date.Format("2006-01-02") + `</time>`
+			}
+
+			name := `<a href="` + outPkg + index + `">` + html.EscapeString(displayPath(outPkg)) + `</a>`
+			removal := removals[api.Pkg]
+			if api.Symbol != "" {
+				name = `<a href="` + outPkg + index + `#` + api.Symbol + `">` + html.EscapeString(displayPath(outPkg)+"."+api.Symbol) + `</a>`
+				if r, ok := removals[api.Pkg+"#"+api.Symbol]; ok {
+					removal = r
+				}
+			}
+
+			line := strconv.Itoa(api.Line)
+			buf.WriteString(`<tr><td>` + date + `</td><td>` + name + `</td><td>` + html.EscapeString(api.Notice) + `</td><td>` + html.EscapeString(removal) + `</td><td><a href="src/` + outPkg + "/" + api.File + ".html#L" + line + `">` + html.EscapeString(api.File) + ":" + line + `</a></td></tr>
+`)
+		}
+		buf.WriteString(`</table>
+`)
+	}
+
+	buf.WriteString(`</div>
+<div id="footer">` + siteFooterText("") + `</div>
+</div>
+</div>
+` + searchTags("") + `</body>
+</html>
+`)
+	return writeFile(ctx, buf, "", deprecationsPage)
+}
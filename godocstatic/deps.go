@@ -0,0 +1,187 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+const (
+	depsDirect = "direct"
+	depsAll    = "all"
+	depsVendor = "vendor"
+)
+
+// depsFlag is the value of -with-deps, which documents the direct
+// dependencies of modules when supplied without a value.
+type depsFlag string
+
+func (f *depsFlag) String() string {
+	return string(*f)
+}
+
+func (f *depsFlag) Set(value string) error {
+	switch value {
+	case "true":
+		value = depsDirect
+	case "false":
+		value = ""
+	}
+	*f = depsFlag(value)
+	return nil
+}
+
+func (f *depsFlag) IsBoolFlag() bool {
+	return true
+}
+
+var withDeps depsFlag
+
+func validateWithDeps() error {
+	switch withDeps {
+	case "", depsDirect, depsAll, depsVendor:
+		return nil
+	default:
+		return fmt.Errorf("unknown dependencies %s: must be one of %s, %s, %s", withDeps, depsDirect, depsAll, depsVendor)
+	}
+}
+
+// dependencyPackage is a package of a dependency of a supplied module.
+type dependencyPackage struct {
+	listedPackage
+	// ModuleDir is the directory of the module depending on the package,
+	// from which the package is listed.
+	ModuleDir string
+	// DependencyPath and DependencyDir are the path of the module
+	// containing the package and its directory in the module cache.
+	DependencyPath string
+	DependencyDir  string
+}
+
+// addDependencyModule records a dependency in the module cache as a module,
+// including a dependency without a go.mod file.
+func addDependencyModule(modulePath string, dir string) {
+	if _, ok := modules[modulePath]; ok {
+		return
+	}
+
+	loadModule(dir)
+	if _, ok := modules[modulePath]; !ok {
+		addModule(dir, &modfile.File{Module: &modfile.Module{Mod: module.Version{Path: modulePath}}})
+	}
+}
+
+// goListLines runs go list in dir, returning each line of its output.
+func goListLines(ctx context.Context, dir string, args ...string) ([]string, error) {
+	var buf, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "go", append([]string{"list"}, args...)...)
+	cmd.Env = godocEnv
+	cmd.Dir = dir
+	cmd.Stdout = &buf
+	cmd.Stderr = &stderr
+	setDeathSignal(cmd)
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var lines []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// listDependencies lists the packages of the direct, or with -with-deps=all
+// all, dependencies of each supplied module which are in the module cache.
+// Dependencies are not downloaded.
+func listDependencies(ctx context.Context) ([]dependencyPackage, error) {
+	var (
+		pkgs   []dependencyPackage
+		listed = make(map[string]bool)
+	)
+	for _, name := range moduleNames() {
+		m := modules[name]
+
+		var depPaths []string
+		if withDeps == depsAll {
+			lines, err := goListLines(ctx, m.Dir, "-m", "-f", `{{ if not .Main }}{{ .Path }}{{ end }}`, "all")
+			if err != nil {
+				return nil, fmt.Errorf("failed to list dependencies of %s: %s", name, err)
+			}
+			depPaths = lines
+		} else {
+			for _, r := range m.File.Require {
+				if !r.Indirect {
+					depPaths = append(depPaths, r.Mod.Path)
+				}
+			}
+		}
+
+		var patterns []string
+		for _, depPath := range depPaths {
+			if _, ok := modules[depPath]; !ok && !listed[depPath] {
+				patterns = append(patterns, depPath)
+			}
+		}
+		if len(patterns) == 0 {
+			continue
+		}
+
+		lines, err := goListLines(ctx, m.Dir, append([]string{"-m", "-f", `{{ .Path }} {{ .Dir }}`}, patterns...)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate dependencies of %s: %s", name, err)
+		}
+
+		depDirs := make(map[string]string)
+		patterns = patterns[:0]
+		for _, line := range lines {
+			fields := strings.SplitN(line, " ", 2)
+			if len(fields) != 2 || fields[1] == "" {
+				logger.Printf("Warning: dependency %s of %s is not in the module cache and is not documented. Run go mod download to download it.", fields[0], name)
+				continue
+			}
+			depDirs[fields[0]] = fields[1]
+			listed[fields[0]] = true
+			patterns = append(patterns, fields[0]+"/...")
+		}
+		if len(patterns) == 0 {
+			continue
+		}
+
+		lines, err = goListLines(ctx, m.Dir, append([]string{"-find", "-f", `{{ .ImportPath }} {{ .Module.Path }} {{ .Dir }}`}, patterns...)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list packages of the dependencies of %s: %s", name, err)
+		}
+		for _, line := range lines {
+			fields := strings.SplitN(line, " ", 3)
+			if len(fields) != 3 {
+				continue
+			}
+
+			// Patterns also match the packages of nested modules which
+			// are not dependencies themselves.
+			depDir, ok := depDirs[fields[1]]
+			if !ok || (fields[2] != depDir && !strings.HasPrefix(fields[2], depDir+string(filepath.Separator))) {
+				continue
+			}
+			pkgs = append(pkgs, dependencyPackage{
+				listedPackage:  listedPackage{Pkg: fields[0], Dir: fields[2]},
+				ModuleDir:      m.Dir,
+				DependencyPath: fields[1],
+				DependencyDir:  depDir,
+			})
+		}
+	}
+	return pkgs, nil
+}
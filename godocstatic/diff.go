@@ -0,0 +1,62 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+func runDiff(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: godoc-static diff old-destination new-destination")
+	}
+
+	oldFiles, err := siteFiles(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to list files of %s: %s", args[0], err)
+	}
+	newFiles, err := siteFiles(args[1])
+	if err != nil {
+		return fmt.Errorf("failed to list files of %s: %s", args[1], err)
+	}
+
+	var files []string
+	for file := range oldFiles {
+		files = append(files, file)
+	}
+	for file := range newFiles {
+		if !oldFiles[file] {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		if !oldFiles[file] {
+			fmt.Fprintf(os.Stdout, "A %s\n", file)
+			continue
+		} else if !newFiles[file] {
+			fmt.Fprintf(os.Stdout, "D %s\n", file)
+			continue
+		}
+
+		oldData, err := ioutil.ReadFile(filepath.Join(args[0], filepath.FromSlash(file)))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %s", file, err)
+		}
+		newData, err := ioutil.ReadFile(filepath.Join(args[1], filepath.FromSlash(file)))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %s", file, err)
+		}
+
+		if !bytes.Equal(oldData, newData) {
+			fmt.Fprintf(os.Stdout, "M %s\n", file)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package godocstatic
+
+import (
+	"bytes"
+	"fmt"
+	"go/doc"
+	"go/doc/comment"
+	"strings"
+)
+
+const (
+	// docCommentsGo119 renders doc comments using go/doc/comment, supporting
+	// links to symbols such as [Name] and [pkg.Name], lists and # headings.
+	docCommentsGo119 = "go1.19"
+	// docCommentsLegacy renders doc comments as godoc did originally.
+	docCommentsLegacy = "legacy"
+)
+
+var docComments string
+
+func validateDocComments() error {
+	switch docComments {
+	case docCommentsGo119, docCommentsLegacy:
+		return nil
+	default:
+		return fmt.Errorf("unknown doc comment syntax %s: must be one of %s, %s", docComments, docCommentsGo119, docCommentsLegacy)
+	}
+}
+
+// docLinkURL returns the URL of a link to a symbol or package in a doc
+// comment. Links to other packages refer to the URLs served by godoc, which
+// are rewritten along with the other links of the page.
+func docLinkURL(link *comment.DocLink) string {
+	fragment := link.Name
+	if link.Recv != "" {
+		fragment = link.Recv + "." + link.Name
+	}
+
+	if link.ImportPath == "" {
+		return "#" + fragment
+	}
+
+	url := "/pkg/" + link.ImportPath + "/"
+	if fragment != "" {
+		url += "#" + fragment
+	}
+	return url
+}
+
+// docCommentHTML renders a doc comment of a package as HTML. Links to symbols
+// of the package are resolved when the documentation of the package is
+// supplied.
+func docCommentHTML(d *doc.Package, text string) string {
+	if docComments == docCommentsLegacy {
+		var buf bytes.Buffer
+		doc.ToHTML(&buf, text, nil)
+		return buf.String()
+	}
+
+	parser, printer := &comment.Parser{}, &comment.Printer{}
+	if d != nil {
+		parser, printer = d.Parser(), d.Printer()
+	}
+	printer.DocLinkURL = docLinkURL
+	return string(printer.HTML(parser.Parse(text)))
+}
+
+// docCommentTemplate replaces the comment_html function of a godoc template
+// with one resolving links to the symbols of the documented package.
+func docCommentTemplate(name string, data string) string {
+	if name != "package.html" {
+		return data
+	}
+	return strings.Replace(data, "{{comment_html .Doc}}", "{{doc_comment_html $.PDoc .Doc}}", -1)
+}
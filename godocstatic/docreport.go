@@ -0,0 +1,392 @@
+package godocstatic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"html"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// defaultDictionary is used to find unknown words in doc comments when no
+// dictionary is supplied.
+const defaultDictionary = "/usr/share/dict/words"
+
+var (
+	docReport     string
+	docDictionary string
+
+	dictionary map[string]bool
+)
+
+// commonMisspellings maps frequently misspelled words to their spelling.
+// They are reported whether or not a dictionary is available.
+var commonMisspellings = map[string]string{
+	"accross":     "across",
+	"adress":      "address",
+	"agressive":   "aggressive",
+	"alot":        "a lot",
+	"arguement":   "argument",
+	"begining":    "beginning",
+	"calender":    "calendar",
+	"commited":    "committed",
+	"definately":  "definitely",
+	"dependant":   "dependent",
+	"enviroment":  "environment",
+	"existance":   "existence",
+	"independant": "independent",
+	"lenght":      "length",
+	"neccessary":  "necessary",
+	"occured":     "occurred",
+	"occurence":   "occurrence",
+	"paramter":    "parameter",
+	"persistant":  "persistent",
+	"recieve":     "receive",
+	"recieved":    "received",
+	"reciever":    "receiver",
+	"refered":     "referred",
+	"retreive":    "retrieve",
+	"seperate":    "separate",
+	"similiar":    "similar",
+	"succesful":   "successful",
+	"sucess":      "success",
+	"teh":         "the",
+	"threshhold":  "threshold",
+	"untill":      "until",
+	"usefull":     "useful",
+	"wich":        "which",
+	"writting":    "writing",
+}
+
+// goWords lists words common in Go documentation which are missing from
+// most dictionaries.
+var goWords = []string{
+	"api", "apis", "args", "ascii", "bool", "boolean", "booleans", "config",
+	"func", "funcs", "goroutine", "goroutines", "html", "http", "https",
+	"init", "int", "json", "mutex", "nil", "println", "printf", "runtime",
+	"stderr", "stdin", "stdout", "struct", "structs", "timestamp", "uint",
+	"unmarshal", "unmarshals", "url", "urls", "utf", "xml",
+}
+
+type docIssue struct {
+	Package string
+	Anchor  string
+	Name    string
+	Message string
+}
+
+// loadDictionary reads the word lists supplied with -doc-dictionary, or the
+// system word list when none are supplied and it exists.
+func loadDictionary() error {
+	dictionary = nil
+
+	files := strings.Split(docDictionary, ",")
+	if docDictionary == "" {
+		if _, err := os.Stat(defaultDictionary); err != nil {
+			return nil
+		}
+		files = []string{defaultDictionary}
+	}
+
+	dictionary = make(map[string]bool)
+	for _, word := range goWords {
+		dictionary[word] = true
+	}
+	for _, file := range files {
+		file = strings.TrimSpace(file)
+		if file == "" {
+			continue
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("failed to read dictionary %s: %s", file, err)
+		}
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			if word != "" && !strings.HasPrefix(word, "#") {
+				dictionary[word] = true
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read dictionary %s: %s", file, err)
+		}
+	}
+	return nil
+}
+
+// knownWord returns whether a word, or the word without a common suffix, is
+// in the dictionary.
+func knownWord(word string) bool {
+	if dictionary[word] {
+		return true
+	}
+	for _, suffix := range []string{"'s", "s", "es", "ed", "d", "ing", "ly"} {
+		if strings.HasSuffix(word, suffix) && dictionary[strings.TrimSuffix(word, suffix)] {
+			return true
+		}
+	}
+	return false
+}
+
+// spellingIssues returns the likely typos in a doc comment. Words containing
+// upper case letters after the first, digits or underscores are assumed to be
+// identifiers and are not checked, nor are code blocks, paths and URLs.
+func spellingIssues(text string, identifiers map[string]bool) []string {
+	var issues []string
+	reported := make(map[string]bool)
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+
+		for _, field := range strings.Fields(line) {
+			word := strings.TrimFunc(field, func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+			})
+			if strings.Contains(field, "://") || strings.ContainsAny(word, "_./") {
+				continue
+			}
+
+			if len(word) < 3 || identifiers[word] {
+				continue
+			}
+			if r := rune(word[0]); r >= 'A' && r <= 'Z' {
+				word = string(r-'A'+'a') + word[1:]
+			}
+			if reported[word] {
+				continue
+			}
+
+			var lower = true
+			for _, r := range word {
+				if (r < 'a' || r > 'z') && r != '\'' {
+					lower = false
+					break
+				}
+			}
+			if !lower {
+				continue
+			}
+
+			if correct, ok := commonMisspellings[word]; ok {
+				issues = append(issues, fmt.Sprintf("%q is likely a typo of %q", word, correct))
+				reported[word] = true
+			} else if dictionary != nil && !knownWord(word) {
+				issues = append(issues, fmt.Sprintf("%q is not in the dictionary", word))
+				reported[word] = true
+			}
+		}
+	}
+	return issues
+}
+
+// styleIssue returns the issue with the start of the doc comment of a
+// declaration, which should begin with its name, or an empty string.
+func styleIssue(text string, name string, article bool) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+
+	start := text
+	if article {
+		for _, a := range []string{"A ", "An ", "The "} {
+			start = strings.TrimPrefix(start, a)
+		}
+	}
+	if start == name || strings.HasPrefix(start, name+" ") || strings.HasPrefix(start, name+",") {
+		return ""
+	}
+
+	first := text
+	if i := strings.IndexAny(first, ".\n"); i >= 0 {
+		first = first[:i]
+	}
+	if len(first) > 40 {
+		first = first[:40] + "..."
+	}
+	return fmt.Sprintf("doc comment should begin with %q, not %q", name, first)
+}
+
+// packageDocIssues returns the likely typos and non-idiomatic doc comments of
+// a package.
+func packageDocIssues(pkg string, d *doc.Package) []docIssue {
+	outPkg := vanityPath(pkg)
+
+	identifiers := make(map[string]bool)
+	addName := func(name string) {
+		identifiers[name] = true
+		identifiers[strings.ToLower(name)] = true
+	}
+	addName(d.Name)
+	addValueNames := func(values []*doc.Value) {
+		for _, v := range values {
+			for _, spec := range v.Decl.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					addName(name.Name)
+				}
+			}
+		}
+	}
+	addValueNames(d.Consts)
+	addValueNames(d.Vars)
+	for _, f := range d.Funcs {
+		addName(f.Name)
+	}
+	for _, t := range d.Types {
+		addName(t.Name)
+		addValueNames(t.Consts)
+		addValueNames(t.Vars)
+		for _, f := range t.Funcs {
+			addName(f.Name)
+		}
+		for _, m := range t.Methods {
+			addName(m.Name)
+		}
+	}
+
+	var issues []docIssue
+	check := func(anchor string, name string, text string, start string, article bool) {
+		for _, issue := range spellingIssues(text, identifiers) {
+			issues = append(issues, docIssue{Package: outPkg, Anchor: anchor, Name: name, Message: issue})
+		}
+		if start == "" {
+			return
+		}
+		if issue := styleIssue(text, start, article); issue != "" {
+			issues = append(issues, docIssue{Package: outPkg, Anchor: anchor, Name: name, Message: issue})
+		}
+	}
+	checkValues := func(values []*doc.Value, anchor string) {
+		for _, v := range values {
+			var start string
+			if len(v.Decl.Specs) == 1 && len(v.Decl.Specs[0].(*ast.ValueSpec).Names) == 1 {
+				start = v.Decl.Specs[0].(*ast.ValueSpec).Names[0].Name
+			}
+			check(anchor, start, v.Doc, start, true)
+		}
+	}
+
+	if d.Name == "main" {
+		check("pkg-overview", "", d.Doc, "", false)
+	} else {
+		check("pkg-overview", "", d.Doc, "Package "+d.Name, false)
+	}
+	checkValues(d.Consts, "pkg-constants")
+	checkValues(d.Vars, "pkg-variables")
+	for _, f := range d.Funcs {
+		check(f.Name, f.Name, f.Doc, f.Name, false)
+	}
+	for _, t := range d.Types {
+		check(t.Name, t.Name, t.Doc, t.Name, true)
+		checkValues(t.Consts, t.Name)
+		checkValues(t.Vars, t.Name)
+		for _, f := range t.Funcs {
+			check(f.Name, f.Name, f.Doc, f.Name, false)
+		}
+		for _, m := range t.Methods {
+			check(t.Name+"."+m.Name, t.Name+"."+m.Name, m.Doc, m.Name, false)
+		}
+	}
+	return issues
+}
+
+// writeDocReport writes a page listing likely typos and non-idiomatic doc
+// comments of each package.
+func writeDocReport(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	err := loadDictionary()
+	if err != nil {
+		return err
+	}
+
+	var issues []docIssue
+	for _, pkg := range pkgs {
+		if _, ok := listFailures[pkg]; ok {
+			continue
+		}
+
+		p, err := loadNativePackage(ctx, pkg)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		} else if err != nil {
+			continue // This is expected for directories without source files
+		}
+
+		_, d, err := parsePackageDoc(p, 0)
+		if err != nil {
+			return err
+		} else if d == nil {
+			continue
+		}
+
+		issues = append(issues, packageDocIssues(pkg, d)...)
+	}
+
+	var index string
+	if linkIndex {
+		index = "/index.html"
+	}
+
+	packages := make(map[string]bool)
+	for _, issue := range issues {
+		packages[issue.Package] = true
+	}
+
+	buf.Reset()
+	buf.WriteString(sitePageHeader("Documentation report - " + siteName))
+	buf.WriteString(`
+<h1>
+	Documentation report
+</h1>
+<p>` + fmt.Sprintf("%d likely typos and non-idiomatic doc comments in %d packages.", len(issues), len(packages)) + `</p>
+`)
+	if dictionary == nil {
+		buf.WriteString(`<p>No dictionary was found: only common misspellings were checked.</p>
+`)
+	}
+
+	var lastPkg string
+	for _, issue := range issues {
+		if issue.Package != lastPkg {
+			if lastPkg != "" {
+				buf.WriteString(`</ul>
+`)
+			}
+			buf.WriteString(`<h2 id="` + html.EscapeString(issue.Package) + `"><a href="` + issue.Package + index + `">` + html.EscapeString(displayPath(issue.Package)) + `</a></h2>
+<ul>
+`)
+			lastPkg = issue.Package
+		}
+
+		buf.WriteString(`<li>`)
+		if issue.Name != "" {
+			buf.WriteString(`<a href="` + issue.Package + index + `#` + issue.Anchor + `">` + html.EscapeString(issue.Name) + `</a>: `)
+		} else {
+			buf.WriteString(`<a href="` + issue.Package + index + `#` + issue.Anchor + `">Package</a>: `)
+		}
+		buf.WriteString(html.EscapeString(issue.Message) + `</li>
+`)
+	}
+	if lastPkg != "" {
+		buf.WriteString(`</ul>
+`)
+	}
+
+	buf.WriteString(`<div id="footer">` + siteFooterText("") + `</div>
+</div>
+</div>
+` + searchTags("") + `</body>
+</html>
+`)
+	return writeFile(ctx, buf, "", docReport)
+}
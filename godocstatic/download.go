@@ -0,0 +1,173 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// downloadedPackages lists the package supplied with a version, such as
+// example.com/foo/bar@v1.4.2, by the directory its module was downloaded to,
+// when it is not the module itself. Only the package and the packages below it
+// are documented.
+var downloadedPackages map[string]string
+
+// downloadedModule is the output of go mod download -json.
+type downloadedModule struct {
+	Path    string
+	Version string
+	Dir     string
+	Error   string
+}
+
+// versionedArgument returns the import path and version of a package
+// supplied with a version, or empty strings for other arguments.
+func versionedArgument(arg string) (string, string) {
+	at := strings.LastIndex(arg, "@")
+	if at <= 0 || at == len(arg)-1 {
+		return "", ""
+	} else if _, err := os.Stat(arg); err == nil {
+		return "", ""
+	}
+	return arg[:at], arg[at+1:]
+}
+
+// downloadModule downloads the module containing a package at a version,
+// which may also be a query such as latest, to the module cache. Each parent
+// of the import path is tried in turn until a module is found.
+func downloadModule(ctx context.Context, pkg string, version string) (*downloadedModule, error) {
+	var lastErr string
+	for modulePath := pkg; modulePath != "." && modulePath != "/"; modulePath = path.Dir(modulePath) {
+		var buf bytes.Buffer
+
+		cmd := exec.CommandContext(ctx, "go", "mod", "download", "-json", modulePath+"@"+version)
+		cmd.Env = godocEnv
+		cmd.Dir = getTmpDir()
+		cmd.Stdout = &buf
+		setDeathSignal(cmd)
+
+		// The error is reported in the output as well.
+		cmd.Run()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		m := &downloadedModule{}
+		err := json.Unmarshal(buf.Bytes(), m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s@%s: %s", pkg, version, err)
+		} else if m.Error == "" && m.Dir != "" {
+			return m, nil
+		}
+		if lastErr == "" {
+			lastErr = m.Error
+		}
+	}
+	if hint := privateModuleHint(lastErr); hint != "" {
+		return nil, fmt.Errorf("failed to download %s@%s: %s\n%s", pkg, version, lastErr, hint)
+	}
+	return nil, fmt.Errorf("failed to download %s@%s: %s", pkg, version, lastErr)
+}
+
+// copyModule copies a module from the read-only module cache to dir, so that
+// it may be listed and documented like a supplied path. A go.mod file is
+// written for modules without one.
+func copyModule(m *downloadedModule, dir string) error {
+	err := filepath.Walk(m.Dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(m.Dir, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		} else if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, data, 0644)
+	})
+	if err != nil {
+		return err
+	}
+
+	modFilePath := filepath.Join(dir, "go.mod")
+	if _, err := os.Stat(modFilePath); os.IsNotExist(err) {
+		return ioutil.WriteFile(modFilePath, []byte("module "+m.Path+"\n"), 0644)
+	}
+	return nil
+}
+
+// downloadVersions replaces each package supplied with a version with a copy
+// of its module at that version, downloaded below a temporary directory. The
+// temporary directory is returned when any packages were downloaded and must
+// be removed once documentation is generated.
+func downloadVersions(ctx context.Context, args []string) ([]string, string, error) {
+	downloadedPackages = make(map[string]string)
+
+	var (
+		expanded []string
+		tmpDir   string
+	)
+	for i, arg := range args {
+		pkg, version := versionedArgument(arg)
+		if pkg == "" {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		if tmpDir == "" {
+			var err error
+			tmpDir, err = ioutil.TempDir(getTmpDir(), "godoc-static-download")
+			if err != nil {
+				return nil, "", err
+			}
+		}
+
+		if !quiet {
+			logger.Printf("Downloading %s@%s...", pkg, version)
+		}
+
+		m, err := downloadModule(ctx, pkg, version)
+		if err != nil {
+			return nil, tmpDir, err
+		}
+
+		// The version is read from the name of the directory, as it is for
+		// modules in the module cache.
+		dir := filepath.Join(tmpDir, fmt.Sprint(i), path.Base(m.Path)+"@"+m.Version)
+		err = copyModule(m, dir)
+		if err != nil {
+			return nil, tmpDir, fmt.Errorf("failed to copy %s@%s: %s", m.Path, m.Version, err)
+		}
+
+		if pkg != m.Path {
+			downloadedPackages[dir] = pkg
+		}
+		expanded = append(expanded, dir)
+	}
+	return expanded, tmpDir, nil
+}
+
+// downloadedPackage returns whether a package of a module downloaded to dir
+// was supplied to be documented.
+func downloadedPackage(dir string, pkg string) bool {
+	supplied, ok := downloadedPackages[dir]
+	return !ok || pkg == supplied || strings.HasPrefix(pkg, supplied+"/")
+}
@@ -0,0 +1,256 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"html"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// endpointDirective annotates a handler function with the route it serves,
+// such as "//godoc-static:endpoint GET /users/{id}", for routers which are
+// not detected.
+const endpointDirective = "//godoc-static:endpoint "
+
+var endpoints bool
+
+// routerImports lists the packages whose routes are detected, by import path
+// prefix.
+var routerImports = []string{
+	"net/http",
+	"github.com/go-chi/chi",
+	"github.com/gin-gonic/gin",
+}
+
+// routeMethods maps the methods registering a route for a single HTTP method
+// in chi and gin to the HTTP method.
+var routeMethods = map[string]string{
+	"Get": "GET", "Post": "POST", "Put": "PUT", "Patch": "PATCH", "Delete": "DELETE",
+	"Head": "HEAD", "Options": "OPTIONS", "Connect": "CONNECT", "Trace": "TRACE",
+	"GET": "GET", "POST": "POST", "PUT": "PUT", "PATCH": "PATCH", "DELETE": "DELETE",
+	"HEAD": "HEAD", "OPTIONS": "OPTIONS", "Any": "",
+}
+
+type endpoint struct {
+	Method  string
+	Pattern string
+	Handler string
+	// Anchor is the anchor of the handler on the page of the package, or an
+	// empty string when the handler is not documented.
+	Anchor string
+	File   string
+	Line   int
+}
+
+// importsRouter returns whether a file imports a package of routerImports.
+func importsRouter(f *ast.File) bool {
+	for _, spec := range f.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		for _, prefix := range routerImports {
+			if p == prefix || strings.HasPrefix(p, prefix+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stringArgument returns the value of a string literal argument.
+func stringArgument(arg ast.Expr) (string, bool) {
+	lit, ok := arg.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// routeCall returns the HTTP method, pattern and handler of a call which
+// registers a route with net/http, chi or gin.
+func routeCall(call *ast.CallExpr) (method string, pattern string, handler ast.Expr, ok bool) {
+	sel, isSel := call.Fun.(*ast.SelectorExpr)
+	if !isSel || len(call.Args) < 2 {
+		return "", "", nil, false
+	}
+
+	name := sel.Sel.Name
+	args := call.Args
+	switch name {
+	case "Handle", "HandleFunc":
+		pattern, ok = stringArgument(args[0])
+		// Patterns may begin with a method since Go 1.22.
+		if i := strings.IndexByte(pattern, ' '); i > 0 && !strings.Contains(pattern[:i], "/") {
+			method, pattern = pattern[:i], strings.TrimSpace(pattern[i+1:])
+		}
+	case "Method", "MethodFunc":
+		if len(args) < 3 {
+			return "", "", nil, false
+		}
+		var methodOK bool
+		method, methodOK = stringArgument(args[0])
+		pattern, ok = stringArgument(args[1])
+		ok = ok && methodOK
+		args = args[1:]
+	default:
+		var known bool
+		method, known = routeMethods[name]
+		if !known {
+			return "", "", nil, false
+		}
+		pattern, ok = stringArgument(args[0])
+	}
+	if !ok || !strings.Contains(pattern, "/") {
+		return "", "", nil, false
+	}
+	return strings.ToUpper(method), pattern, args[len(args)-1], true
+}
+
+// packageEndpoints returns the routes registered by the non-test Go files of
+// a package, and the routes of handlers annotated with endpointDirective.
+func packageEndpoints(dir string, files []string) ([]endpoint, error) {
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, file), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, f)
+	}
+
+	// Exported functions are linked to their documentation.
+	documented := make(map[string]bool)
+	for _, f := range parsed {
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.IsExported() {
+				documented[fn.Name.Name] = true
+			}
+		}
+	}
+
+	var found []endpoint
+	for i, f := range parsed {
+		file := files[i]
+
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil {
+				continue
+			}
+			for _, c := range fn.Doc.List {
+				if !strings.HasPrefix(c.Text, endpointDirective) {
+					continue
+				}
+
+				fields := strings.Fields(c.Text[len(endpointDirective):])
+				e := endpoint{
+					Handler: fn.Name.Name,
+					File:    file,
+					Line:    fset.Position(c.Pos()).Line,
+				}
+				if fn.Recv == nil && documented[fn.Name.Name] {
+					e.Anchor = fn.Name.Name
+				}
+				switch len(fields) {
+				case 0:
+					continue
+				case 1:
+					e.Pattern = fields[0]
+				default:
+					e.Method, e.Pattern = strings.ToUpper(fields[0]), fields[1]
+				}
+				found = append(found, e)
+			}
+		}
+
+		if !importsRouter(f) {
+			continue
+		}
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			method, pattern, handler, ok := routeCall(call)
+			if !ok {
+				return true
+			}
+
+			var buf bytes.Buffer
+			printer.Fprint(&buf, fset, handler)
+			e := endpoint{
+				Method:  method,
+				Pattern: pattern,
+				Handler: buf.String(),
+				File:    file,
+				Line:    fset.Position(call.Pos()).Line,
+			}
+			if ident, ok := handler.(*ast.Ident); ok && documented[ident.Name] {
+				e.Anchor = ident.Name
+			}
+			found = append(found, e)
+			return true
+		})
+	}
+	return found, nil
+}
+
+// addEndpointsSection lists the HTTP routes registered by a package below its
+// overview.
+func addEndpointsSection(ctx context.Context, doc *goquery.Document, pkg string, basePath string) error {
+	if !endpoints {
+		return nil
+	}
+
+	p, err := loadNativePackage(ctx, pkg)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	} else if err != nil {
+		return nil // This is expected for packages without source files
+	}
+
+	found, err := packageEndpoints(p.Dir, append(append([]string{}, p.GoFiles...), p.CgoFiles...))
+	if err != nil || len(found) == 0 {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(`<div id="pkg-endpoints">
+<h2>Endpoints</h2>
+<table>
+<tr><th>Method</th><th>Pattern</th><th>Handler</th><th>Registered</th></tr>
+`)
+	for _, e := range found {
+		method := e.Method
+		if method == "" {
+			method = "ANY"
+		}
+
+		handler := `<code>` + html.EscapeString(e.Handler) + `</code>`
+		if e.Anchor != "" {
+			handler = `<a href="#` + e.Anchor + `">` + handler + `</a>`
+		}
+
+		line := strconv.Itoa(e.Line)
+		b.WriteString(`<tr><td>` + html.EscapeString(method) + `</td><td><code>` + html.EscapeString(e.Pattern) + `</code></td><td>` + handler + `</td><td><a href="` + basePath + "src/" + vanityPath(pkg) + "/" + e.File + ".html#L" + line + `">` + e.File + ":" + line + `</a></td></tr>
+`)
+	}
+	b.WriteString(`</table>
+</div>`)
+
+	doc.Find("#pkg-index").First().BeforeHtml(b.String())
+	doc.Find("#short-nav").First().Find("dl").Last().AppendHtml(`<dd><a href="#pkg-endpoints">Endpoints</a></dd>`)
+	return nil
+}
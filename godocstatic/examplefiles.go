@@ -0,0 +1,118 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/doc"
+	"go/format"
+	"go/token"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// exampleFilesDir is the directory of the site examples are written to, in a
+// directory for each package.
+const exampleFilesDir = "examples"
+
+var exampleFiles bool
+
+const exampleFilesCSS = `
+.example-download { margin: 0.625rem 0; }
+.example-download a { display: inline-block; padding: 0.3125rem 0.625rem; color: var(--link); border: 0.0625rem solid var(--border); border-radius: 0.3125rem; text-decoration: none; }
+.example-download a:hover { background: var(--heading-background); }
+`
+
+// exampleFileName returns the name of the file an example is written to.
+func exampleFileName(ex *doc.Example) string {
+	return "Example" + ex.Name + ".go"
+}
+
+// exampleSource returns the source of the file an example is written to: a
+// complete program when the example may be run as one, or its code preceded
+// by a comment explaining where it runs otherwise.
+func exampleSource(fset *token.FileSet, ex *doc.Example, pkg string) ([]byte, error) {
+	var buf bytes.Buffer
+	if ex.Play != nil {
+		err := format.Node(&buf, fset, ex.Play)
+		if err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	err := format.Node(&buf, fset, ex.Code)
+	if err != nil {
+		return nil, err
+	}
+	return []byte("// Example" + ex.Name + " of package " + pkg + " is not a complete program. It runs\n// within the tests of the package.\n\n" + exampleCode(buf.String(), ex) + "\n"), nil
+}
+
+// addExampleFiles writes the code of each example of a package to a file in
+// exampleFilesDir, and links the file beneath the code of the example.
+func addExampleFiles(ctx context.Context, buf *bytes.Buffer, page *goquery.Document, pkg string, basePath string) error {
+	if !exampleFiles {
+		return nil
+	}
+
+	examples := page.Find(`details[id^="example_"]`)
+	if examples.Length() == 0 {
+		return nil
+	}
+
+	p, err := loadNativePackage(ctx, pkg)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	} else if err != nil {
+		return nil // This is expected for packages without source files
+	}
+
+	fset, parsed, err := packageExamples(p)
+	if err != nil {
+		return err
+	}
+
+	outDir := path.Join(exampleFilesDir, vanityPath(pkg))
+	err = os.MkdirAll(filepath.Join(siteDestination, outDir), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to make directory %s: %s", outDir, err)
+	}
+
+	files := make(map[string]string)
+	for _, ex := range parsed {
+		src, err := exampleSource(fset, ex, pkg)
+		if err != nil {
+			return err
+		}
+
+		name := exampleFileName(ex)
+		buf.Reset()
+		buf.Write(src)
+		err = writeFile(ctx, buf, outDir, name)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range exampleIDs(ex) {
+			files[id] = name
+		}
+	}
+
+	examples.Each(func(_ int, example *goquery.Selection) {
+		name, ok := files[example.AttrOr("id", "")]
+		if !ok {
+			return
+		}
+
+		code := example.Find("pre.code").First()
+		if code.Length() == 0 {
+			return
+		}
+		code.AfterHtml(`<p class="example-download"><a href="` + basePath + outDir + "/" + html.EscapeString(name) + `" download>Download ` + html.EscapeString(name) + `</a></p>`)
+	})
+	return nil
+}
@@ -0,0 +1,187 @@
+package godocstatic
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"html"
+	"path/filepath"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	examplesCollapsed = "collapsed"
+	examplesExpanded  = "expanded"
+	examplesBottom    = "bottom"
+)
+
+var examplePlacement string
+
+func validateExamplePlacement() error {
+	switch examplePlacement {
+	case examplesCollapsed, examplesExpanded, examplesBottom:
+		return nil
+	default:
+		return fmt.Errorf("unknown example placement %s: must be one of %s, %s, %s", examplePlacement, examplesCollapsed, examplesExpanded, examplesBottom)
+	}
+}
+
+// placeExamples expands the examples of a package page, or moves them to a
+// section at the bottom of the page labeled with what they belong to, as
+// specified by -examples. Examples are collapsed beneath the function or type
+// they belong to by default.
+func placeExamples(doc *goquery.Document) {
+	if examplePlacement == examplesCollapsed {
+		return
+	}
+
+	examples := doc.Find(`details[id^="example_"]`)
+	if examples.Length() == 0 {
+		return
+	}
+	examples.SetAttr("open", "")
+
+	if examplePlacement != examplesBottom {
+		return
+	}
+
+	examples.Each(func(_ int, example *goquery.Selection) {
+		id := example.AttrOr("id", "")
+		label := doc.Find(`a[href="#` + id + `"]`).First().Text()
+		if label == "" {
+			label = strings.Replace(strings.TrimPrefix(id, "example_"), "_", ".", -1)
+			if label == "" || label == "package" {
+				label = "Package"
+			}
+		}
+		example.Find("summary").First().SetText("Example (" + label + ")")
+	})
+
+	section := doc.Find("#pkg-subdirectories").First()
+	if section.Length() == 0 {
+		section = doc.Find("#footer").Last()
+	}
+	section.BeforeHtml(`<h2 id="pkg-example-code">Examples</h2>
+<div id="pkg-example-list"></div>
+`)
+	doc.Find("#pkg-example-list").AppendSelection(examples)
+}
+
+// packageExamples parses the examples declared in the test files of a
+// package.
+func packageExamples(p *nativePackage) (*token.FileSet, []*doc.Example, error) {
+	fset := token.NewFileSet()
+
+	var files []*ast.File
+	for _, file := range append(append([]string{}, p.TestGoFiles...), p.XTestGoFiles...) {
+		f, err := parser.ParseFile(fset, filepath.Join(p.Dir, file), nil, parser.ParseComments)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, f)
+	}
+	return fset, doc.Examples(files...), nil
+}
+
+// exampleIDs returns the IDs of the elements an example may be displayed in.
+// Examples of packages are identified as example_package by the native
+// renderer.
+func exampleIDs(ex *doc.Example) []string {
+	ids := []string{"example_" + ex.Name}
+	if ex.Name == "" || strings.HasPrefix(ex.Name, "_") {
+		ids = append(ids, "example_package"+ex.Name)
+	}
+	return ids
+}
+
+// exampleCode returns the code of an example printed as source, without the
+// braces and indentation of the body of its function.
+func exampleCode(code string, ex *doc.Example) string {
+	if _, ok := ex.Code.(*ast.BlockStmt); !ok {
+		return code
+	}
+
+	lines := strings.Split(code, "\n")
+	if len(lines) >= 2 {
+		lines = lines[1 : len(lines)-1]
+	}
+	for i := range lines {
+		lines[i] = strings.TrimPrefix(lines[i], "\t")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exampleOutputLabel returns the label of the output of an example.
+func exampleOutputLabel(ex *doc.Example) string {
+	if ex.Unordered {
+		return "Output (in any order):"
+	}
+	return "Output:"
+}
+
+// addExampleOutputs displays the output of each example of a package page
+// beneath its code, labeling output which may be printed in any order. godoc
+// omits the output of examples displayed as whole files, and leaves an empty
+// comment where the output comment was removed from the code.
+func addExampleOutputs(ctx context.Context, page *goquery.Document, pkg string) error {
+	examples := page.Find(`details[id^="example_"]`)
+	if examples.Length() == 0 {
+		return nil
+	}
+
+	examples.Find("pre.code span.comment").Each(func(_ int, comment *goquery.Selection) {
+		if comment.Text() == "" {
+			comment.Remove()
+		}
+	})
+
+	p, err := loadNativePackage(ctx, pkg)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	} else if err != nil {
+		return nil // This is expected for packages without source files
+	}
+
+	_, parsed, err := packageExamples(p)
+	if err != nil {
+		return err
+	}
+
+	outputs := make(map[string]*doc.Example)
+	for _, ex := range parsed {
+		if ex.Output == "" {
+			continue
+		}
+		for _, id := range exampleIDs(ex) {
+			outputs[id] = ex
+		}
+	}
+
+	examples.Each(func(_ int, example *goquery.Selection) {
+		ex, ok := outputs[example.AttrOr("id", "")]
+		if !ok {
+			return
+		}
+
+		output := example.Find("pre.output").First()
+		if output.Length() == 0 {
+			code := example.Find("pre.code").First()
+			if code.Length() == 0 {
+				return
+			}
+			code.AfterHtml("<p>" + exampleOutputLabel(ex) + "</p>\n<pre class=\"output\">" + html.EscapeString(ex.Output) + "</pre>")
+			return
+		}
+
+		label := output.Prev()
+		if goquery.NodeName(label) == "p" && strings.HasPrefix(strings.TrimSpace(label.Text()), "Output") {
+			label.SetText(exampleOutputLabel(ex))
+		}
+	})
+	return nil
+}
@@ -0,0 +1,152 @@
+package godocstatic
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	excludeFile     string
+	includePackages string
+)
+
+var (
+	// excludePatterns matches the packages excluded by -exclude and
+	// -exclude-file.
+	excludePatterns []*regexp.Regexp
+	// includePatterns matches the packages documented with -include. All
+	// packages are documented when it is empty.
+	includePatterns []*regexp.Regexp
+)
+
+// regexpChars are the characters which mark a package pattern as a regular
+// expression rather than a package path or glob.
+const regexpChars = `^$()[]{}+|\`
+
+// filterPattern compiles a pattern of -exclude or -include, which is either:
+//
+//   - a regular expression, when it contains any of ^$()[]{}+|\ or .* and is
+//     matched anywhere in the path of a package, such as .*/mocks$
+//   - a glob, when it contains * or ?, matching any characters or a single
+//     character other than /, or ..., matching any string as with the go
+//     command, such as example.com/*/internal/...
+//   - or else the path of a package, which also matches the packages below it
+//
+// Globs and paths match a package or any of its parents.
+func filterPattern(p string) (*regexp.Regexp, error) {
+	if strings.ContainsAny(p, regexpChars) || strings.Contains(p, ".*") {
+		return regexp.Compile(p)
+	}
+
+	var expr strings.Builder
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "..."):
+			expr.WriteString(`.*`)
+			i += 2
+		case p[i] == '*':
+			expr.WriteString(`[^/]*`)
+		case p[i] == '?':
+			expr.WriteString(`[^/]`)
+		default:
+			expr.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	return regexp.Compile("^" + expr.String() + "(/.*)?$")
+}
+
+// readExcludeFile returns the exclusion patterns listed in a file, one per
+// line. Blank lines and lines starting with # are ignored.
+func readExcludeFile(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+// validateExcludePatterns compiles the space-separated patterns of -exclude
+// and -include, and the patterns listed in -exclude-file.
+func validateExcludePatterns() error {
+	patterns := strings.Fields(excludePackages)
+	if excludeFile != "" {
+		filePatterns, err := readExcludeFile(excludeFile)
+		if err != nil {
+			return fmt.Errorf("failed to read exclude file %s: %s", excludeFile, err)
+		}
+		patterns = append(patterns, filePatterns...)
+	}
+
+	excludePatterns = nil
+	for _, p := range patterns {
+		re, err := filterPattern(p)
+		if err != nil {
+			return fmt.Errorf("invalid exclude pattern %s: %s", p, err)
+		}
+		excludePatterns = append(excludePatterns, re)
+	}
+
+	includePatterns = nil
+	for _, p := range strings.Fields(includePackages) {
+		re, err := filterPattern(p)
+		if err != nil {
+			return fmt.Errorf("invalid include pattern %s: %s", p, err)
+		}
+		includePatterns = append(includePatterns, re)
+	}
+	return nil
+}
+
+// excludedPackage returns whether a package is excluded by -exclude or
+// -exclude-file. Packages are matched by import path or vanity path.
+func excludedPackage(pkg string) bool {
+	for _, re := range excludePatterns {
+		if re.MatchString(pkg) || re.MatchString(vanityPath(pkg)) {
+			return true
+		}
+	}
+	return false
+}
+
+// includedPackage returns whether a package is documented with -include.
+// Packages are matched by import path or vanity path.
+func includedPackage(pkg string) bool {
+	if len(includePatterns) == 0 {
+		return true
+	}
+	for _, re := range includePatterns {
+		if re.MatchString(pkg) || re.MatchString(vanityPath(pkg)) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterPkgsWithIncludes returns the packages documented with -include.
+func filterPkgsWithIncludes(pkgs []string) []string {
+	if len(includePatterns) == 0 {
+		return pkgs
+	}
+
+	var included []string
+	for _, pkg := range pkgs {
+		if includedPackage(pkg) {
+			included = append(included, pkg)
+		}
+	}
+	return included
+}
@@ -0,0 +1,68 @@
+package godocstatic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	externalLinksPkgGoDev = "pkg.go.dev"
+	externalLinksGodocsIO = "godocs.io"
+	externalLinksStrip    = "strip"
+)
+
+var externalLinks string
+
+// documentedPackages lists the packages whose documentation is written to the
+// site. Links to other packages are handled as specified by -external-links.
+var documentedPackages = make(map[string]bool)
+
+func validateExternalLinks() error {
+	switch externalLinks {
+	case externalLinksPkgGoDev, externalLinksGodocsIO, externalLinksStrip:
+		return nil
+	default:
+		return fmt.Errorf("unknown external links policy %s: must be one of %s, %s, %s", externalLinks, externalLinksPkgGoDev, externalLinksGodocsIO, externalLinksStrip)
+	}
+}
+
+// linkedPackage returns the import path of the package a link served by godoc
+// refers to, such as /pkg/net/http/#Request, and the fragment of the link.
+func linkedPackage(href string) (string, string) {
+	p := strings.TrimPrefix(href, "/pkg/")
+
+	var fragment string
+	if i := strings.IndexRune(p, '#'); i >= 0 {
+		p, fragment = p[:i], p[i+1:]
+	}
+	if i := strings.IndexRune(p, '?'); i >= 0 {
+		p = p[:i]
+	}
+	return strings.Trim(p, "/"), fragment
+}
+
+// linkExternalPackage rewrites a link to a package which is not documented by
+// the site, returning whether the link refers to such a package.
+func linkExternalPackage(selection *goquery.Selection, href string) bool {
+	pkg, fragment := linkedPackage(href)
+	if pkg == "" || documentedPackages[pkg] {
+		return false
+	}
+
+	switch externalLinks {
+	case externalLinksStrip:
+		selection.ReplaceWithSelection(selection.Contents())
+		return true
+	case externalLinksGodocsIO:
+		href = "https://godocs.io/" + pkg
+	default:
+		href = "https://pkg.go.dev/" + pkg
+	}
+	if fragment != "" {
+		href += "#" + fragment
+	}
+	selection.SetAttr("href", href)
+	return true
+}
@@ -0,0 +1,112 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	extraCSS stringsFlag
+	extraJS  stringsFlag
+
+	// extraStyleSheets and extraScripts list the files in lib copied from
+	// extraCSS and extraJS.
+	extraStyleSheets []string
+	extraScripts     []string
+)
+
+// readAsset returns the contents of a file, or of a URL when source begins
+// with http:// or https://.
+func readAsset(ctx context.Context, source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return ioutil.ReadFile(source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// writeExtraAssets copies the style sheets and scripts supplied with
+// -extra-css and -extra-js into lib.
+func writeExtraAssets(ctx context.Context, buf *bytes.Buffer) error {
+	extraStyleSheets, extraScripts = nil, nil
+	if len(extraCSS) == 0 && len(extraJS) == 0 {
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Join(siteDestination, "lib"), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to make directory lib: %s", err)
+	}
+
+	copyAssets := func(sources []string, ext string) ([]string, error) {
+		var names []string
+		for i, source := range sources {
+			data, err := readAsset(ctx, source)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %s", source, err)
+			}
+
+			name := "extra" + strconv.Itoa(i+1) + ext
+			buf.Reset()
+			buf.Write(data)
+			err = writeFile(ctx, buf, "lib", name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to write %s: %s", name, err)
+			}
+			names = append(names, name)
+		}
+		return names, nil
+	}
+
+	extraStyleSheets, err = copyAssets(extraCSS, ".css")
+	if err != nil {
+		return err
+	}
+	extraScripts, err = copyAssets(extraJS, ".js")
+	return err
+}
+
+// extraTags returns the elements linking the extra style sheets and scripts
+// from a page which links to the root of the site using basePath.
+func extraTags(basePath string) string {
+	var b strings.Builder
+	for _, name := range extraStyleSheets {
+		b.WriteString(`<link type="text/css" rel="stylesheet" href="` + basePath + "lib/" + name + `">
+`)
+	}
+	for _, name := range extraScripts {
+		b.WriteString(`<script src="` + basePath + "lib/" + name + `" defer></script>
+`)
+	}
+	return b.String()
+}
+
+// addExtraAssets links the extra style sheets and scripts from a page.
+func addExtraAssets(doc *goquery.Document, basePath string) {
+	if tags := extraTags(basePath); tags != "" {
+		doc.Find("head").First().AppendHtml(tags)
+	}
+}
@@ -0,0 +1,33 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// writeFragment writes the documentation of a package without the page
+// head, top bar or footer, for embedding within other sites. Links within the
+// fragment are relative to the package directory.
+func writeFragment(ctx context.Context, buf *bytes.Buffer, doc *goquery.Document, outPkg string) error {
+	content := doc.Find("#page > .container").First().Clone()
+	if content.Length() == 0 {
+		return nil
+	}
+	content.Find("#footer").Remove()
+
+	buf.Reset()
+	buf.WriteString(`<div class="godoc-fragment">`)
+	for node := content.Nodes[0].FirstChild; node != nil; node = node.NextSibling {
+		err := html.Render(buf, node)
+		if err != nil {
+			return fmt.Errorf("failed to render HTML: %s", err)
+		}
+	}
+	buf.WriteString("</div>\n")
+
+	return writeFile(ctx, buf, outPkg, "fragment.html")
+}
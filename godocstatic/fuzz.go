@@ -0,0 +1,222 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"html"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const fuzzTargetsPage = "fuzz.html"
+
+var fuzzTargets bool
+
+// fuzzTarget describes a fuzz target and the seed corpus checked in below
+// testdata/fuzz.
+type fuzzTarget struct {
+	Name string
+	File string
+	Line int
+	// Dir is the directory of the package declaring the target.
+	Dir    string
+	Corpus []string
+}
+
+// corpusDir returns the directory of the seed corpus of a fuzz target,
+// relative to the directory of its package.
+func (t fuzzTarget) corpusDir() string {
+	return path.Join("testdata", "fuzz", t.Name)
+}
+
+// packageFuzzTargets returns the fuzz targets declared in the test files of a
+// package.
+func packageFuzzTargets(p *nativePackage) ([]fuzzTarget, error) {
+	fset := token.NewFileSet()
+
+	var targets []fuzzTarget
+	for _, file := range append(append([]string{}, p.TestGoFiles...), p.XTestGoFiles...) {
+		f, err := parser.ParseFile(fset, filepath.Join(p.Dir, file), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || testFuncPrefix(fn) != "Fuzz" {
+				continue
+			}
+
+			t := fuzzTarget{
+				Name: fn.Name.Name,
+				File: file,
+				Line: fset.Position(fn.Pos()).Line,
+				Dir:  p.Dir,
+			}
+
+			infos, err := ioutil.ReadDir(filepath.Join(t.Dir, filepath.FromSlash(t.corpusDir())))
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+			for _, info := range infos {
+				if info.Mode().IsRegular() {
+					t.Corpus = append(t.Corpus, info.Name())
+				}
+			}
+
+			targets = append(targets, t)
+		}
+	}
+	return targets, nil
+}
+
+// loadFuzzTargets returns the fuzz targets of a package, or nil when fuzz
+// targets are not listed or the package has no source files.
+func loadFuzzTargets(ctx context.Context, pkg string) ([]fuzzTarget, error) {
+	if !fuzzTargets {
+		return nil, nil
+	}
+
+	p, err := loadNativePackage(ctx, pkg)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	} else if err != nil {
+		return nil, nil // This is expected for packages without source files
+	}
+	return packageFuzzTargets(p)
+}
+
+// addFuzzSection lists the fuzz targets of a package and their seed corpus
+// below its overview, copying the files of the corpus to the site.
+func addFuzzSection(ctx context.Context, buf *bytes.Buffer, doc *goquery.Document, pkg string, basePath string) error {
+	targets, err := loadFuzzTargets(ctx, pkg)
+	if err != nil || len(targets) == 0 {
+		return err
+	}
+
+	outSrcPath := path.Join("src", vanityPath(pkg))
+
+	var b strings.Builder
+	b.WriteString(`<div id="pkg-fuzz">
+<h2>Fuzz targets</h2>
+<table>
+<tr><th>Target</th><th>Declared</th><th>Seed corpus</th></tr>
+`)
+	for _, t := range targets {
+		line := strconv.Itoa(t.Line)
+		b.WriteString(`<tr><td><code>` + html.EscapeString(t.Name) + `</code></td><td><a href="` + basePath + outSrcPath + "/" + t.File + ".html#L" + line + `">` + html.EscapeString(t.File) + ":" + line + `</a></td><td>`)
+		if len(t.Corpus) == 0 {
+			b.WriteString(`None`)
+		} else {
+			b.WriteString(`<details><summary>` + strconv.Itoa(len(t.Corpus)) + ` files</summary>
+<ul>
+`)
+			for _, name := range t.Corpus {
+				corpusPath := path.Join(outSrcPath, t.corpusDir())
+				data, err := ioutil.ReadFile(filepath.Join(t.Dir, filepath.FromSlash(t.corpusDir()), name))
+				if err != nil {
+					return err
+				}
+
+				err = os.MkdirAll(filepath.Join(siteDestination, corpusPath), 0755)
+				if err != nil {
+					return err
+				}
+
+				buf.Reset()
+				buf.Write(data)
+				err = writeFile(ctx, buf, corpusPath, name)
+				if err != nil {
+					return err
+				}
+
+				b.WriteString(`<li><a href="` + basePath + corpusPath + "/" + html.EscapeString(name) + `">` + html.EscapeString(name) + `</a></li>
+`)
+			}
+			b.WriteString(`</ul>
+</details>`)
+		}
+		b.WriteString(`</td></tr>
+`)
+	}
+	b.WriteString(`</table>
+</div>`)
+
+	doc.Find("#pkg-index").First().BeforeHtml(b.String())
+	doc.Find("#short-nav").First().Find("dl").Last().AppendHtml(`<dd><a href="#pkg-fuzz">Fuzz targets</a></dd>`)
+	return nil
+}
+
+// writeFuzzTargetsPage writes a page listing the fuzz targets of every
+// documented package and the size of their seed corpus.
+func writeFuzzTargetsPage(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	var index string
+	if linkIndex {
+		index = "/index.html"
+	}
+
+	buf.Reset()
+	buf.WriteString(sitePageHeader("Fuzz targets - " + siteName))
+	buf.WriteString(`
+<h1>
+	Fuzz targets
+</h1>
+<div id="pkg-fuzz-targets">
+`)
+
+	var total, seeded int
+	for _, pkg := range pkgs {
+		if _, ok := listFailures[pkg]; ok {
+			continue
+		}
+
+		targets, err := loadFuzzTargets(ctx, pkg)
+		if err != nil {
+			return err
+		} else if len(targets) == 0 {
+			continue
+		}
+
+		outPkg := vanityPath(pkg)
+		buf.WriteString(`<h2 id="` + html.EscapeString(outPkg) + `"><a href="` + outPkg + index + `#pkg-fuzz">` + html.EscapeString(displayPath(outPkg)) + `</a></h2>
+<table>
+`)
+		for _, t := range targets {
+			line := strconv.Itoa(t.Line)
+			buf.WriteString(`<tr><td><code>` + html.EscapeString(t.Name) + `</code></td><td><a href="src/` + outPkg + "/" + t.File + ".html#L" + line + `">` + html.EscapeString(t.File) + ":" + line + `</a></td><td>` + strconv.Itoa(len(t.Corpus)) + ` corpus files</td></tr>
+`)
+			total++
+			if len(t.Corpus) > 0 {
+				seeded++
+			}
+		}
+		buf.WriteString(`</table>
+`)
+	}
+
+	if total == 0 {
+		buf.WriteString(`<p>No fuzz targets are declared.</p>
+`)
+	} else {
+		buf.WriteString(`<p>` + strconv.Itoa(total) + ` fuzz targets, ` + strconv.Itoa(seeded) + ` with a checked-in seed corpus.</p>
+`)
+	}
+
+	buf.WriteString(`</div>
+<div id="footer">` + siteFooterText("") + `</div>
+</div>
+</div>
+` + searchTags("") + `</body>
+</html>
+`)
+	return writeFile(ctx, buf, "", fuzzTargetsPage)
+}
@@ -0,0 +1,1384 @@
+// Package godocstatic generates static Go documentation.
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+var (
+	siteName            string
+	siteDescription     string
+	siteDescriptionFile string
+	siteFooter          string
+	siteFooterFile      string
+	siteDestination     string
+	siteZip             string
+	zipSplitSize        string
+	disableFilter       bool
+	linkIndex           bool
+	go111Modules        bool
+	excludePackages     string
+	buildTags           string
+	a11yReport          string
+	themeVariant        string
+	configFile          string
+	timeout             time.Duration
+	searchMode          string
+	indexPageSize       int
+	redirectsFile       string
+	fragments           bool
+	workers             int
+	renderer            string
+	workDir             string
+	symbolIndex         bool
+	quiet               bool
+	verbose             bool
+
+	goPath   string
+	pkgPaths map[string]string
+
+	godocEnv []string
+)
+
+func registerGenerateFlags(flags *flag.FlagSet) {
+	flags.StringVar(&renderer, "renderer", rendererGodoc, "render pages using godoc (godoc) or directly from source using go/doc (native)")
+	flags.StringVar(&siteName, "site-name", "Documentation", "site name")
+	flags.StringVar(&siteDescription, "site-description", "", "site description (markdown-enabled)")
+	flags.StringVar(&siteDescriptionFile, "site-description-file", "", "path to markdown file containing site description")
+	flags.StringVar(&siteFooter, "site-footer", "", "site footer (markdown-enabled)")
+	flags.StringVar(&siteFooterFile, "site-footer-file", "", "path to markdown file containing site footer")
+	flags.StringVar(&siteDestination, "destination", "", "path to write site HTML")
+	flags.StringVar(&baseURL, "base-url", "", "URL the site is published at, used to write sitemap.xml (blank to disable)")
+	flags.StringVar(&siteZip, "zip", "docs.zip", "name of site ZIP file (blank to disable)")
+	flags.StringVar(&zipSplitSize, "zip-split-size", "", "split site ZIP file into numbered parts no larger than this size (e.g. 200MB)")
+	flags.BoolVar(&disableFilter, "disable-filter", false, `do not exclude packages named "testdata", "internal" or "vendor"`)
+	flags.StringVar(&examplePlacement, "examples", examplesCollapsed, "place examples collapsed beneath their function or type (collapsed), expanded beneath it (expanded) or expanded at the bottom of the page (bottom)")
+	flags.BoolVar(&fragments, "fragments", false, "also write fragment.html for each package, without page head, top bar or footer")
+	flags.BoolVar(&apiListing, "api-listing", false, "also write api.txt for each package, listing its exported declarations one per line")
+	flags.BoolVar(&endpoints, "endpoints", false, "list the HTTP routes each package registers with net/http, chi or gin, or annotates with //godoc-static:endpoint")
+	flags.BoolVar(&provenance, "provenance", false, "record the module version and commit each page is generated from, and the version of godoc-static, in the page and its footer")
+	flags.Var(&withDeps, "with-deps", "also document the dependencies of each module found in the module cache: direct, when supplied without a value, or all, or those in its vendor directory at the vendored versions (vendor)")
+	flags.BoolVar(&stdlib, "stdlib", false, "also document the standard library of GOROOT, linking the standard library symbols referenced by other packages to its pages")
+	flags.BoolVar(&unexported, "unexported", false, "also write internal.html for each package, documenting its unexported identifiers as well, linked as the internal view of the package")
+	flags.BoolVar(&commandHelp, "command-help", false, "build each command and add the output of running it with -help to its page")
+	flags.BoolVar(&testDocs, "test-docs", false, "also write tests.html for each package with tests, documenting exported test helpers and listing tests, benchmarks, fuzz targets and examples")
+	flags.BoolVar(&fuzzTargets, "fuzz-targets", false, "list the fuzz targets of each package and their checked-in seed corpus, and write fuzz.html listing the fuzz targets of all packages")
+	flags.BoolVar(&deprecations, "deprecations", false, "also write deprecations.html listing the deprecated packages and symbols of all packages by the date they were deprecated, according to git blame")
+	flags.StringVar(&deprecationRemovalsFile, "deprecation-removals", "", "path to file listing the versions deprecated APIs are planned to be removed in, as import path or import path#Symbol and version per line (used by --deprecations)")
+	flags.StringVar(&notes, "notes", "", "comma-separated list of note markers, such as BUG,TODO,SECURITY, whose MARKER(name): notes are listed on notes.html (blank to disable)")
+	flags.BoolVar(&importGraph, "import-graph", false, "also write imports.html drawing the imports between the documented packages, each linking to its documentation")
+	flags.BoolVar(&licenses, "licenses", false, "also write licenses.html listing the license files of each module and their text, and label package pages with the license of their module")
+	flags.BoolVar(&dependencyLicenses, "dependency-licenses", false, "also list the licenses of the dependencies of each module found in the module cache on licenses.html (used by --licenses)")
+	flags.BoolVar(&goModInfo, "module-info", false, "also write go.mod.html for each module, describing its go.mod file")
+	flags.BoolVar(&dependencyGraph, "dependency-graph", false, "also write dependencies.html for each module, listing the modules in its requirement graph reported by go mod graph")
+	flags.BoolVar(&symbolIndex, "symbol-index", false, "also write symbols.html listing the exported symbols of all packages")
+	flags.BoolVar(&implementers, "implementers", false, "list the documented types implementing each interface, and the documented interfaces each type implements, on package pages")
+	flags.BoolVar(&references, "references", false, "add a See also section to package pages, listing the packages their doc comments refer to and are referred to by")
+	flags.StringVar(&docComments, "doc-comments", docCommentsGo119, "render doc comments supporting links to symbols, lists and headings (go1.19) or as godoc did originally (legacy)")
+	flags.StringVar(&externalLinks, "external-links", externalLinksPkgGoDev, "link packages which are not documented to pkg.go.dev (pkg.go.dev) or godocs.io (godocs.io), or remove the links (strip)")
+	flags.BoolVar(&linkIndex, "link-index", false, "set link targets to index.html instead of folder")
+	flags.StringVar(&syntheticModulePath, "module-path", "", "module path of a supplied directory without a go.mod file, which is documented as a module (defaults to its import path in GOPATH, or its name)")
+	flags.BoolVar(&go111Modules, "go111modules", true, "use -go111Modules=false to turn off GO111MODULES=auto env addition")
+	flags.StringVar(&goFlags, "goflags", "", "GOFLAGS of the go commands run while generating documentation (defaults to the environment)")
+	flags.StringVar(&goProxy, "goproxy", "", "GOPROXY of the go commands run while generating documentation (defaults to the environment)")
+	flags.StringVar(&goNoSumDB, "gonosumdb", "", "GONOSUMDB of the go commands run while generating documentation (defaults to the environment)")
+	flags.StringVar(&goPrivate, "goprivate", "", "GOPRIVATE of the go commands run while generating documentation: comma-separated list of module path prefixes fetched directly from their repositories (defaults to the environment)")
+	flags.StringVar(&netrc, "netrc", "", "path to the .netrc file holding the credentials of private module repositories and proxies (defaults to ~/.netrc)")
+	flags.Var(&goEnv, "env", "NAME=value environment variable of the go commands run while generating documentation (may be repeated)")
+	flags.StringVar(&buildTags, "tags", "", "comma-separated list of build tags to satisfy when listing packages and extracting documentation (e.g. integration,embedded)")
+	flags.StringVar(&robots, "robots", robotsAllow, "write robots.txt allowing (allow) or denying (deny) crawlers access to the site, or do not write it (none)")
+	flags.StringVar(&robotsDisallow, "robots-disallow", "", "comma-separated list of paths crawlers are denied access to, relative to the site (e.g. src/)")
+	flags.StringVar(&priority, "priority", "", "comma-separated list of package patterns whose pages are written first, in order of priority (e.g. example.com/mod/api/...)")
+	flags.StringVar(&noindexPattern, "noindex-pattern", "", "comma-separated list of package patterns whose pages search engines are asked not to index (e.g. example.com/mod/experimental/...)")
+	flags.StringVar(&saveStateFile, "save-state", "", "path to file to save the packages resolved from the supplied packages and paths to, with their directories and modules")
+	flags.StringVar(&loadStateFile, "load-state", "", "path to file saved by --save-state to load the packages to document from, instead of resolving the supplied packages and paths")
+	flags.StringVar(&cacheFile, "cache", "", "path to cache file used to skip packages whose source and options are unchanged since it was written (blank to disable)")
+	flags.StringVar(&workDir, "work-dir", "", "directory for temporary files (default system temporary directory)")
+	flags.IntVar(&workers, "workers", 1, "number of package and source pages to scrape concurrently")
+	flags.IntVar(&indexPageSize, "index-page-size", 0, "maximum number of packages listed on each page of the index (0 to disable pagination)")
+	flags.StringVar(&excludePackages, "exclude", "", "space-separated list of packages to exclude, as paths, globs (e.g. example.com/*/mocks) or regular expressions (e.g. .*/mocks$)")
+	flags.StringVar(&includePackages, "include", "", "space-separated list of packages to document, excluding all others, as paths, globs or regular expressions as -exclude (e.g. example.com/mod/api/...)")
+	flags.StringVar(&excludeFile, "exclude-file", "", "path to a file listing patterns of packages to exclude, as -exclude, one per line")
+	flags.StringVar(&a11yReport, "a11y-report", "", "name of accessibility report file (blank to disable)")
+	flags.StringVar(&compatReport, "compat-report", "", "name of report file listing the elements of pages served by godoc which could not be rewritten (blank to disable)")
+	flags.StringVar(&docReport, "doc-report", "", "name of report page listing likely typos and non-idiomatic doc comments (blank to disable)")
+	flags.StringVar(&docDictionary, "doc-dictionary", "", "comma-separated list of word list files used by --doc-report (default "+defaultDictionary+" when present)")
+	flags.StringVar(&assetsDir, "assets-dir", "", "directory of files replacing the shared assets written to lib: style.css (before additions), source.js, theme.js, search.js, search.wasm and wasm_exec.js")
+	flags.BoolVar(&noJS, "no-js", false, "omit all scripts from generated pages, for environments where scripts are not permitted")
+	flags.Var(&extraCSS, "extra-css", "path or URL of a style sheet to copy into lib and link from every page (may be repeated)")
+	flags.Var(&extraJS, "extra-js", "path or URL of a script to copy into lib and link from every page (may be repeated)")
+	flags.StringVar(&themeName, "theme", themeClassic, "presentation of generated pages: godoc's layout (classic) or a layout like pkg.go.dev (modern)")
+	flags.StringVar(&themeSCSS, "theme-scss", "", "path to an SCSS style sheet compiled and appended to style.css, customizing the theme")
+	flags.Var(&themeVars, "theme-var", "variable of the -theme-scss style sheet, such as a brand color, specified as NAME=VALUE (may be repeated)")
+	flags.StringVar(&themeVariant, "theme-variant", "", "alternative color palette (deuteranopia or protanopia)")
+	flags.StringVar(&symbolRedirectsFile, "symbol-redirects", "", "path to file listing renamed or moved symbols, as old import path#anchor and new import path#anchor or URL per line")
+	flags.StringVar(&redirectsFile, "redirects", "", "path to file listing moved packages, as old import path and new import path or URL per line")
+	flags.Var(&vanity, "vanity", "display packages matching old-prefix under new-prefix, as old-prefix=new-prefix (may be repeated)")
+	flags.StringVar(&trimPrefix, "trim-prefix", "", "import path prefix to omit from package names displayed on the index, headings and titles")
+	flags.StringVar(&versions, "versions", "", "comma-separated list of git tags or branches to generate documentation for, each in its own directory")
+	flags.BoolVar(&apiDiff, "api-diff", false, "write changes.html for each version listed by --versions or --version-tags, listing the changes to the exported API since the previous version, linked from the version switcher")
+	flags.Var(&versionTags, "version-tags", "also generate documentation for each semantic version tag of the git repositories of the packages supplied as paths, newest first: all, when supplied without a value, or a range such as >=v1.2.0,<v2.0.0")
+	flags.BoolVar(&importPanel, "import-panel", false, "add instructions for fetching and importing each package of a module, pinned to the documented version")
+	flags.StringVar(&importGOPRIVATE, "import-goprivate", "", "GOPRIVATE pattern to configure in the instructions added by --import-panel")
+	flags.StringVar(&importGOPROXY, "import-goproxy", "", "GOPROXY list to configure in the instructions added by --import-panel")
+	flags.BoolVar(&exampleFiles, "example-files", false, "write the code of each example to a .go file below examples/, linked beneath the example")
+	flags.BoolVar(&playground, "playground", false, "add links running examples in the Go Playground, sharing them with the playground when generating documentation")
+	flags.StringVar(&playgroundURL, "playground-url", defaultPlaygroundURL, "URL of the Go Playground examples are shared with by --playground")
+	flags.StringVar(&templatesDir, "templates", "", "path to directory of Go html/template files overriding the index (index.html), top bar (topbar.html), page layout (page.html) and footer (footer.html) of generated pages (blank to disable)")
+	flags.StringVar(&translationsDir, "translations", "", "path to directory of translated package overviews, named import/path/LANGUAGE.md, written to a tree of pages for each language (blank to disable)")
+	flags.StringVar(&siteLanguage, "language", defaultLanguage, "language of the documentation, such as en, when --translations is supplied")
+	flags.StringVar(&imageWidths, "image-widths", defaultImageWidths, "comma-separated list of widths in pixels of the responsive variants written of images in module landing pages and translations (blank to disable)")
+	flags.BoolVar(&requireAlt, "require-alt", false, "fail when an image in module landing pages and translations has no alternative text, rather than deriving it from the name of its file")
+	flags.StringVar(&ownersFile, "owners", "", "path to CODEOWNERS file, or JSON file mapping package patterns to owners, by which packages are labeled and grouped (blank to disable)")
+	flags.StringVar(&annotationsFile, "annotations", "", "path to SARIF or JSON file of annotations to display on source pages (blank to disable)")
+	flags.StringVar(&sourceStyle, "source-style", defaultSourceStyle, "chroma style highlighting source files in the light theme, or none to leave them to the renderer")
+	flags.StringVar(&sourceStyleDark, "source-style-dark", defaultSourceStyleDark, "chroma style highlighting source files in the dark theme, or none to use -source-style")
+	flags.BoolVar(&sourceLinks, "source-links", false, "link the identifiers in Go source files to the documentation or source of their declarations (source files are rendered natively)")
+	flags.StringVar(&sourceTypes, "source-types", "", "comma-separated list of source file types to write pages for: go, cgo, c, cxx, m, h, f, s, swig, swigcxx and test (blank for all)")
+	flags.StringVar(&searchMode, "search", "", "add symbol search using a JSON index (json) or a WebAssembly search with a sharded binary index (wasm)")
+	flags.Var(&transformExec, "transform-exec", "command to transform the HTML of each page, read from stdin and written to stdout (may be repeated)")
+	flags.BoolVar(&watch, "watch", false, "after generating documentation, regenerate the pages of packages when their .go or .md files change")
+	flags.DurationVar(&timeout, "timeout", 0, "maximum duration of documentation generation (0 to disable)")
+	flags.DurationVar(&deadline, "deadline", 0, "duration after which no more package pages are written, writing the rest of the site and status.html listing the packages skipped (0 to disable)")
+	registerCommonFlags(flags)
+}
+
+// receivedSignal is the signal which interrupted generation.
+var receivedSignal os.Signal
+
+// runGenerate generates documentation until it completes or is interrupted by
+// an interrupt or termination signal, in which case the partial output is
+// removed.
+func runGenerate(ctx context.Context, args []string) error {
+	err := run(ctx, args)
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("generation interrupted by %s signal", receivedSignal)
+	}
+	return err
+}
+
+// removePartialOutput removes the output of generation which was interrupted
+// or timed out: the destination when it was created while generating, or else
+// the site ZIP files, which are incomplete. Pages written to an existing
+// destination are left in place, as they replace the pages of a previous run.
+func removePartialOutput(destinationCreated bool) {
+	if !quiet {
+		logger.Println("Generation cancelled, removing partial output...")
+	}
+
+	closeZip()
+	if destinationCreated {
+		os.RemoveAll(siteDestination)
+		return
+	}
+	for part := 1; part <= outZipPart; part++ {
+		os.Remove(filepath.Join(siteDestination, zipPartName(part)))
+	}
+}
+
+var skipPackages = []string{"internal", "testdata", "vendor"}
+
+func filterPkgsWithExcludes(pkgs []string) []string {
+	var tmpPkgs []string
+PACKAGEINDEX:
+	for _, pkg := range pkgs {
+		if excludedPackage(pkg) {
+			continue
+		}
+
+		if !disableFilter {
+			for _, skipPackage := range skipPackages {
+				if strings.Contains(pkg, "/"+skipPackage+"/") || strings.HasSuffix(pkg, "/"+skipPackage) {
+					continue PACKAGEINDEX
+				}
+			}
+		}
+		tmpPkgs = append(tmpPkgs, pkg)
+	}
+	return tmpPkgs
+}
+
+func getTmpDir() string {
+	tmpDir := workDir
+	if tmpDir == "" {
+		tmpDir = os.TempDir()
+	}
+	if _, err := os.Stat(tmpDir); os.IsNotExist(err) {
+		mkDirErr := os.MkdirAll(tmpDir, 0755)
+		if _, err = os.Stat(tmpDir); os.IsNotExist(err) {
+			logger.Fatalf("failed to create missing temporary directory %s: %s", tmpDir, mkDirErr)
+		}
+	}
+	return tmpDir
+}
+
+func writeFile(ctx context.Context, buf *bytes.Buffer, fileDir string, fileName string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if strings.HasSuffix(fileName, ".html") {
+		err := applyPageTemplate(buf, fileDir)
+		if err != nil {
+			return fmt.Errorf("failed to apply page template to %s: %s", path.Join(fileDir, fileName), err)
+		}
+	}
+
+	if a11yReport != "" && strings.HasSuffix(fileName, ".html") && !(fileDir == "" && fileName == a11yReport) {
+		auditPage(path.Join(fileDir, fileName), buf.Bytes())
+	}
+
+	if outZip != nil {
+		fn := fileDir
+		if fn != "" {
+			fn += "/"
+		}
+		fn += fileName
+
+		err := writeZipFile(fn, buf.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	err := ioutil.WriteFile(filepath.Join(siteDestination, fileDir, fileName), buf.Bytes(), 0755)
+	if err != nil {
+		return err
+	}
+
+	recordCachedFile(ctx, fileDir, fileName, buf.Bytes())
+	addSitePage(path.Join(fileDir, fileName))
+	return nil
+}
+
+// resolvePackages lists the packages to document from the supplied packages
+// and paths, recording their paths, directories and modules. The temporary
+// directory packages supplied with a version were downloaded to is returned
+// as well.
+func resolvePackages(ctx context.Context, pkgs []string) ([]string, string, error) {
+	if len(pkgs) == 0 || (len(pkgs) == 1 && pkgs[0] == "") {
+		var buf bytes.Buffer
+
+		cmd := exec.CommandContext(ctx, "go", "list", "...")
+		cmd.Env = godocEnv
+		cmd.Dir = getTmpDir()
+		cmd.Stdout = &buf
+		setDeathSignal(cmd)
+
+		err := cmd.Run()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list system packages: %s", err)
+		}
+
+		pkgs = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	}
+
+	var argPkgs []string
+	for _, pkg := range pkgs {
+		if strings.TrimSpace(pkg) != "" {
+			argPkgs = append(argPkgs, pkg)
+		}
+	}
+
+	argPkgs, downloadDir, err := downloadVersions(ctx, argPkgs)
+	if err != nil {
+		return nil, downloadDir, err
+	}
+
+	argPkgs, downloadDir, err = synthesizeModules(argPkgs, downloadDir)
+	if err != nil {
+		return nil, downloadDir, err
+	}
+
+	argPkgs, err = expandWorkspaces(argPkgs)
+	if err != nil {
+		return nil, downloadDir, err
+	}
+
+	listed, err := listArguments(ctx, argPkgs)
+	if err != nil {
+		return nil, downloadDir, err
+	}
+
+	var newPkgs []string
+	pkgPaths = make(map[string]string)
+	pkgDirs = make(map[string]string)
+	for _, arg := range argPkgs {
+		a := listed[arg]
+		if a.ModFile != nil {
+			addModule(a.Dir, a.ModFile)
+			if workspaceDirs[arg] {
+				workspaceModules = append(workspaceModules, a.Pkg)
+			}
+		} else if a.Dir != "" {
+			loadModule(a.Dir)
+		}
+
+		if supplied, ok := downloadedPackages[arg]; ok {
+			newPkgs = append(newPkgs, supplied)
+		} else {
+			newPkgs = append(newPkgs, a.Pkg)
+		}
+
+		if a.Failure != "" {
+			pkgPaths[a.Pkg] = a.Dir
+			listFailures[a.Pkg] = a.Failure
+			continue
+		}
+
+		for _, p := range a.Packages {
+			if !downloadedPackage(arg, p.Pkg) {
+				continue
+			}
+
+			newPkgs = append(newPkgs, p.Pkg)
+			pkgDirs[p.Pkg] = p.Dir
+
+			if a.Dir == "" || strings.HasPrefix(filepath.Base(p.Dir), ".") {
+				continue
+			}
+
+			if a.SuppliedPath {
+				pkgPaths[p.Pkg] = a.Dir
+			} else {
+				pkgPaths[p.Pkg] = p.Dir
+			}
+		}
+	}
+
+	if stdlib {
+		std, err := listStdlib(ctx)
+		if err != nil {
+			return nil, downloadDir, err
+		}
+		for _, p := range std {
+			newPkgs = append(newPkgs, p.Pkg)
+			pkgDirs[p.Pkg] = p.Dir
+		}
+	}
+
+	if withDeps != "" {
+		list := listDependencies
+		if withDeps == depsVendor {
+			list = listVendoredDependencies
+		}
+		deps, err := list(ctx)
+		if err != nil {
+			return nil, downloadDir, err
+		}
+		for _, p := range deps {
+			newPkgs = append(newPkgs, p.Pkg)
+			pkgDirs[p.Pkg] = p.Dir
+			pkgPaths[p.Pkg] = p.ModuleDir
+			addDependencyModule(p.DependencyPath, p.DependencyDir)
+		}
+	}
+	return uniqueStrings(newPkgs), downloadDir, nil
+}
+
+func generate(ctx context.Context, pkgs []string) error {
+	var (
+		timeStarted = time.Now()
+
+		buf bytes.Buffer
+		err error
+	)
+
+	if siteDestination == "" {
+		return errors.New("--destination must be set")
+	}
+	_, err = os.Stat(siteDestination)
+	destinationCreated := os.IsNotExist(err)
+
+	err = validateSourceStyle()
+	if err != nil {
+		return err
+	}
+
+	err = validateTheme()
+	if err != nil {
+		return err
+	}
+
+	err = validateThemeVariant()
+	if err != nil {
+		return err
+	}
+
+	err = parseVanity()
+	if err != nil {
+		return err
+	}
+
+	err = validateRenderer()
+	if err != nil {
+		return err
+	}
+
+	err = validateWithDeps()
+	if err != nil {
+		return err
+	}
+
+	err = parseSourceTypes()
+	if err != nil {
+		return err
+	}
+
+	err = parseImageWidths()
+	if err != nil {
+		return err
+	}
+
+	err = parseNoteMarkers()
+	if err != nil {
+		return err
+	}
+
+	err = parsePriority()
+	if err != nil {
+		return err
+	}
+
+	err = validateExamplePlacement()
+	if err != nil {
+		return err
+	}
+
+	err = validateRobots()
+	if err != nil {
+		return err
+	}
+
+	err = validateExternalLinks()
+	if err != nil {
+		return err
+	}
+
+	err = validateDocComments()
+	if err != nil {
+		return err
+	}
+
+	err = compileThemeSCSS()
+	if err != nil {
+		return err
+	}
+
+	err = loadTranslations()
+	if err != nil {
+		return err
+	}
+
+	err = loadTemplates()
+	if err != nil {
+		return err
+	}
+
+	err = validateNoindexPattern()
+	if err != nil {
+		return err
+	}
+
+	err = validateExcludePatterns()
+	if err != nil {
+		return err
+	}
+
+	err = validateNoJS()
+	if err != nil {
+		return err
+	}
+
+	err = validateSearchMode()
+	if err != nil {
+		return err
+	}
+
+	err = addExecTransformers()
+	if err != nil {
+		return err
+	}
+
+	if siteDescriptionFile != "" {
+		siteDescriptionBytes, err := ioutil.ReadFile(siteDescriptionFile)
+		if err != nil {
+			return fmt.Errorf("failed to read site description file %s: %s", siteDescriptionFile, err)
+		}
+		siteDescription = string(siteDescriptionBytes)
+	}
+
+	if siteDescription != "" {
+		buf.Reset()
+		err := markdownRenderer().Convert([]byte(siteDescription), &buf)
+		if err != nil {
+			return fmt.Errorf("failed to render site description markdown: %s", err)
+		}
+		siteDescription = buf.String()
+	}
+
+	if siteFooterFile != "" {
+		siteFooterBytes, err := ioutil.ReadFile(siteFooterFile)
+		if err != nil {
+			return fmt.Errorf("failed to read site footer file %s: %s", siteFooterFile, err)
+		}
+		siteFooter = string(siteFooterBytes)
+	}
+
+	if siteFooter != "" {
+		buf.Reset()
+		err := markdownRenderer().Convert([]byte(siteFooter), &buf)
+		if err != nil {
+			return fmt.Errorf("failed to render site footer markdown: %s", err)
+		}
+		siteFooter = buf.String()
+	}
+
+	zipSplitBytes, err = parseSize(zipSplitSize)
+	if err != nil {
+		return fmt.Errorf("failed to parse zip split size %s: %s", zipSplitSize, err)
+	}
+
+	if siteZip != "" {
+		err = openZip()
+		if err != nil {
+			return err
+		}
+		defer closeZip()
+	}
+
+	goPath = os.Getenv("GOPATH")
+	if goPath == "" {
+		goPath = build.Default.GOPATH
+	}
+
+	err = initGodocEnv()
+	if err != nil {
+		return err
+	}
+
+	if workDir != "" {
+		workDir, err = filepath.Abs(workDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve work directory: %s", err)
+		}
+		setGodocEnv("TMPDIR", getTmpDir())
+		setGodocEnv("GOTMPDIR", getTmpDir())
+	}
+
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	completed := false
+	defer func() {
+		if !completed && ctx.Err() != nil {
+			removePartialOutput(destinationCreated)
+		}
+	}()
+
+	var (
+		args        = pkgs
+		downloadDir string
+	)
+	if loadStateFile != "" {
+		pkgs, err = loadState(pkgs)
+		if err != nil {
+			return fmt.Errorf("failed to load state file %s: %s", loadStateFile, err)
+		}
+	} else {
+		pkgs, downloadDir, err = resolvePackages(ctx, pkgs)
+		if downloadDir != "" {
+			defer os.RemoveAll(downloadDir)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if saveStateFile != "" {
+		if downloadDir != "" {
+			return errors.New("--save-state may not be used with packages supplied with a version or directories without a go.mod file, as they are removed once documentation is generated")
+		}
+
+		err = saveState(args, pkgs)
+		if err != nil {
+			return fmt.Errorf("failed to save state file %s: %s", saveStateFile, err)
+		}
+	}
+
+	if renderer == rendererGodoc {
+		defer discardGodocLog()()
+		defer useGodocBuildTags()()
+
+		err = initGodoc(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(pkgs) == 0 {
+		return errors.New("failed to generate docs: provide the name of at least one package to generate documentation for")
+	}
+
+	// Packages which are excluded, or not included with -include, are
+	// neither documented nor listed in the index.
+	pkgs = filterPkgsWithIncludes(pkgs)
+	if len(pkgs) == 0 {
+		return errors.New("failed to generate docs: no packages match --include")
+	}
+	filterPkgs := filterPkgsWithExcludes(pkgs)
+
+	for _, pkg := range pkgs {
+		subPkgs := strings.Split(vanityPath(pkg), "/")
+		for i := range subPkgs {
+			pkgs = append(pkgs, originalPath(strings.Join(subPkgs[0:i+1], "/")))
+		}
+	}
+	pkgs = filterPkgsWithExcludes(uniqueStrings(pkgs))
+
+	sortByVanityPath(pkgs)
+
+	if !disableFilter {
+		filterPkgs = nil
+		for _, pkg := range pkgs {
+			if !vanityParent(pkg) && includedPackage(pkg) {
+				filterPkgs = append(filterPkgs, pkg)
+			}
+		}
+	}
+
+	documentedPackages = make(map[string]bool)
+	for _, pkg := range filterPkgs {
+		documentedPackages[pkg] = true
+	}
+
+	err = writeExtraAssets(ctx, &buf)
+	if err != nil {
+		return err
+	}
+
+	if annotationsFile != "" {
+		err = loadAnnotations()
+		if err != nil {
+			return fmt.Errorf("failed to read annotations file %s: %s", annotationsFile, err)
+		}
+	}
+
+	if ownersFile != "" {
+		err = loadOwners()
+		if err != nil {
+			return fmt.Errorf("failed to read owners file %s: %s", ownersFile, err)
+		}
+	}
+
+	if symbolRedirectsFile != "" {
+		err = loadSymbolRedirects()
+		if err != nil {
+			return fmt.Errorf("failed to read symbol redirects file %s: %s", symbolRedirectsFile, err)
+		}
+	}
+
+	if references {
+		err = loadReferences(ctx, filterPkgs)
+		if err != nil {
+			return fmt.Errorf("failed to find references between packages: %s", err)
+		}
+	}
+
+	if implementers {
+		err = loadImplementers(ctx, filterPkgs)
+		if err != nil {
+			return fmt.Errorf("failed to find implementations of interfaces: %s", err)
+		}
+	}
+
+	if cacheFile != "" {
+		err = loadCache(ctx, filterPkgs)
+		if err != nil {
+			return fmt.Errorf("failed to load cache file %s: %s", cacheFile, err)
+		}
+	}
+
+	pkgCtx, pkgCancel := packageContext(ctx, timeStarted)
+	defer pkgCancel()
+
+	docs, sources := newCompletedPackages(), newCompletedPackages()
+
+	orderedPkgs := prioritizePackages(filterPkgs)
+
+	err = scrapePackages(pkgCtx, orderedPkgs, docs.track(cachePackages(copyPackageDocs, true)))
+	if err != nil && !deadlineReached(ctx, pkgCtx) {
+		return fmt.Errorf("failed to copy docs: %s", err)
+	}
+
+	// Write source files
+
+	err = os.MkdirAll(filepath.Join(siteDestination, "src"), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to make directory lib: %s", err)
+	}
+
+	err = scrapePackages(pkgCtx, orderedPkgs, sources.track(cachePackages(copyPackageSources, false)))
+	if err != nil && !deadlineReached(ctx, pkgCtx) {
+		return err
+	}
+
+	if deadlineReached(ctx, pkgCtx) {
+		err = skipUnfinishedPackages(ctx, &buf, filterPkgs, docs, sources)
+		if err != nil {
+			return fmt.Errorf("failed to write skipped packages: %s", err)
+		}
+	}
+
+	// Write style.css
+
+	if verbose {
+		logger.Println("Copying style.css...")
+	}
+
+	err = os.MkdirAll(filepath.Join(siteDestination, "lib"), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to make directory lib: %s", err)
+	}
+
+	styleCSS, err := sharedAsset("style.css", func() ([]byte, error) {
+		return styleSheet(ctx)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get style.css: %s", err)
+	}
+
+	buf.Reset()
+	buf.Write(styleCSS)
+	buf.WriteString("\n" + themeStyleSheet() + additionalCSS + sourceCSS + annotationCSS + sourceStyleCSS() + currentTheme().CSS)
+	buf.WriteString(themeVariantCSS())
+	if searchMode != "" {
+		buf.WriteString(searchCSS)
+	}
+	if importPanel {
+		buf.WriteString(importPanelCSS)
+	}
+	if importGraph {
+		buf.WriteString(importGraphCSS)
+	}
+	if sourceLinks {
+		buf.WriteString(sourceLinksCSS)
+	}
+	if playground {
+		buf.WriteString(playgroundCSS)
+	}
+	if exampleFiles {
+		buf.WriteString(exampleFilesCSS)
+	}
+	if len(translationLanguages) > 0 {
+		buf.WriteString(translationsCSS)
+	}
+	buf.WriteString(imagesCSS)
+	buf.WriteString(themeSCSSCSS)
+
+	err = writeFile(ctx, &buf, "lib", "style.css")
+	if err != nil {
+		return fmt.Errorf("failed to write style.css: %s", err)
+	}
+
+	if !noJS {
+		for _, script := range []struct {
+			name    string
+			content string
+		}{
+			{"source.js", sourceJS},
+			{"theme.js", themeJS},
+		} {
+			asset, err := sharedAsset(script.name, constantAsset(script.content))
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %s", script.name, err)
+			}
+
+			buf.Reset()
+			buf.Write(asset)
+			err = writeFile(ctx, &buf, "lib", script.name)
+			if err != nil {
+				return fmt.Errorf("failed to write %s: %s", script.name, err)
+			}
+		}
+	}
+
+	// Write index
+
+	if verbose {
+		logger.Println("Writing module landing pages...")
+	}
+
+	if goModInfo {
+		err = writeModuleInfoPages(ctx, &buf, filterPkgs)
+		if err != nil {
+			return fmt.Errorf("failed to write module information pages: %s", err)
+		}
+	}
+
+	if dependencyGraph {
+		err = writeModuleDependencyPages(ctx, &buf, filterPkgs)
+		if err != nil {
+			return fmt.Errorf("failed to write module dependency pages: %s", err)
+		}
+	}
+
+	if withDeps == depsVendor {
+		err = writeModuleVendorPages(ctx, &buf, filterPkgs)
+		if err != nil {
+			return fmt.Errorf("failed to write vendored module pages: %s", err)
+		}
+	}
+
+	err = writeModuleLandingPages(ctx, &buf, filterPkgs)
+	if err != nil {
+		return fmt.Errorf("failed to write module landing pages: %s", err)
+	}
+
+	if verbose {
+		logger.Println("Writing index.html...")
+	}
+
+	err = writeIndex(ctx, &buf, pkgs, filterPkgs)
+	if err != nil {
+		return fmt.Errorf("failed to write index: %s", err)
+	}
+
+	if symbolIndex {
+		if verbose {
+			logger.Printf("Writing %s...", symbolIndexPage)
+		}
+
+		err = writeSymbolIndex(ctx, &buf, filterPkgs)
+		if err != nil {
+			return fmt.Errorf("failed to write symbol index: %s", err)
+		}
+	}
+
+	if fuzzTargets {
+		if verbose {
+			logger.Printf("Writing %s...", fuzzTargetsPage)
+		}
+
+		err = writeFuzzTargetsPage(ctx, &buf, filterPkgs)
+		if err != nil {
+			return fmt.Errorf("failed to write fuzz targets page: %s", err)
+		}
+	}
+
+	if len(noteMarkers) > 0 {
+		if verbose {
+			logger.Printf("Writing %s...", notesPage)
+		}
+
+		err = writeNotesPage(ctx, &buf, filterPkgs)
+		if err != nil {
+			return fmt.Errorf("failed to write notes page: %s", err)
+		}
+	}
+
+	if apiDiff && currentVersion != "" {
+		if verbose {
+			logger.Printf("Writing %s...", apiChangesPage)
+		}
+
+		err = writeAPIChangesPage(ctx, &buf)
+		if err != nil {
+			return fmt.Errorf("failed to write API changes page: %s", err)
+		}
+	}
+
+	if importGraph {
+		if verbose {
+			logger.Printf("Writing %s...", importGraphPage)
+		}
+
+		err = writeImportGraphPage(ctx, &buf, filterPkgs)
+		if err != nil {
+			return fmt.Errorf("failed to write import graph page: %s", err)
+		}
+	}
+
+	if licenses {
+		if verbose {
+			logger.Printf("Writing %s...", licensesPage)
+		}
+
+		err = writeLicensesPage(ctx, &buf, filterPkgs)
+		if err != nil {
+			return fmt.Errorf("failed to write licenses page: %s", err)
+		}
+	}
+
+	if deprecations {
+		if verbose {
+			logger.Printf("Writing %s...", deprecationsPage)
+		}
+
+		err = writeDeprecationsPage(ctx, &buf, filterPkgs)
+		if err != nil {
+			return fmt.Errorf("failed to write deprecations page: %s", err)
+		}
+	}
+
+	if ownersFile != "" {
+		if verbose {
+			logger.Printf("Writing %s...", ownersPage)
+		}
+
+		err = writeOwnersPage(ctx, &buf, filterPkgs)
+		if err != nil {
+			return fmt.Errorf("failed to write owners page: %s", err)
+		}
+	}
+
+	if deadline > 0 {
+		if verbose {
+			logger.Printf("Writing %s...", statusPage)
+		}
+
+		err = writeStatusPage(ctx, &buf, filterPkgs)
+		if err != nil {
+			return fmt.Errorf("failed to write status page: %s", err)
+		}
+	}
+
+	if verbose {
+		logger.Printf("Writing %s...", siteMapPage)
+	}
+
+	err = writeSiteMap(ctx, &buf, filterPkgs)
+	if err != nil {
+		return fmt.Errorf("failed to write site map: %s", err)
+	}
+
+	if robots != robotsNone {
+		if verbose {
+			logger.Println("Writing robots.txt...")
+		}
+
+		err = writeRobots(ctx, &buf)
+		if err != nil {
+			return fmt.Errorf("failed to write robots.txt: %s", err)
+		}
+	}
+
+	if verbose {
+		logger.Println("Writing 404.html...")
+	}
+
+	err = writeNotFoundPage(ctx, &buf)
+	if err != nil {
+		return fmt.Errorf("failed to write 404.html: %s", err)
+	}
+
+	if redirectsFile != "" {
+		if verbose {
+			logger.Println("Writing redirects...")
+		}
+
+		err = writeRedirects(ctx, &buf)
+		if err != nil {
+			return fmt.Errorf("failed to write redirects: %s", err)
+		}
+	}
+
+	if symbolRedirectsFile != "" {
+		if verbose {
+			logger.Println("Writing symbol redirects...")
+		}
+
+		err = writeSymbolRedirectPages(ctx, &buf)
+		if err != nil {
+			return fmt.Errorf("failed to write symbol redirects: %s", err)
+		}
+	}
+
+	if searchMode != "" {
+		if verbose {
+			logger.Println("Writing search index...")
+		}
+
+		err = writeSearchIndex(ctx, &buf)
+		if err != nil {
+			return fmt.Errorf("failed to write search index: %s", err)
+		}
+
+		if ownersFile != "" {
+			err = writeSearchOwners(ctx, &buf, filterPkgs)
+			if err != nil {
+				return fmt.Errorf("failed to write search owners: %s", err)
+			}
+		}
+	}
+
+	if a11yReport != "" {
+		if verbose {
+			logger.Printf("Writing %s...", a11yReport)
+		}
+
+		err = writeA11yReport(ctx, &buf)
+		if err != nil {
+			return fmt.Errorf("failed to write accessibility report: %s", err)
+		}
+	}
+
+	warnRewriteMisses()
+
+	if compatReport != "" {
+		if verbose {
+			logger.Printf("Writing %s...", compatReport)
+		}
+
+		err = writeCompatReport(ctx, &buf)
+		if err != nil {
+			return fmt.Errorf("failed to write compatibility report: %s", err)
+		}
+	}
+
+	if docReport != "" {
+		if verbose {
+			logger.Printf("Writing %s...", docReport)
+		}
+
+		err = writeDocReport(ctx, &buf, filterPkgs)
+		if err != nil {
+			return fmt.Errorf("failed to write documentation report: %s", err)
+		}
+	}
+
+	if baseURL != "" {
+		if verbose {
+			logger.Printf("Writing %s...", siteMapXML)
+		}
+
+		err = writeSiteMapXML(ctx, &buf)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %s", siteMapXML, err)
+		}
+	}
+
+	if cacheFile != "" {
+		err = writeCache()
+		if err != nil {
+			return fmt.Errorf("failed to write cache file %s: %s", cacheFile, err)
+		}
+	}
+
+	completed = true
+
+	logListFailures()
+
+	if verbose {
+		logger.Printf("Generated documentation in %s.", time.Since(timeStarted).Round(time.Second))
+	}
+
+	if siteZip != "" {
+		err = closeZip()
+		if err != nil {
+			return fmt.Errorf("failed to close zip file: %s", err)
+		}
+		removeStaleZipParts()
+	}
+
+	if watch {
+		return watchPackages(ctx, &buf, filterPkgs)
+	}
+	return nil
+}
+
+// copyPackageDocs writes the documentation page of a package.
+func copyPackageDocs(ctx context.Context, buf *bytes.Buffer, pkg string) error {
+	if verbose {
+		logger.Printf("Copying %s documentation...", pkg)
+	}
+
+	if _, ok := listFailures[pkg]; ok {
+		return writeListFailurePage(ctx, buf, pkg)
+	}
+
+	doc, err := packageDocument(ctx, pkg, 0)
+	if err != nil {
+		return err
+	}
+
+	doc.Find("title").First().SetHtml(fmt.Sprintf("%s - %s", pageTitle(pkg), siteName))
+
+	outPkg := vanityPath(pkg)
+
+	updatePage(doc, path.Join(outPkg, "index.html"), relativeBasePath(outPkg), siteName)
+
+	addNoindex(doc, pkg, path.Join(outPkg, "index.html"))
+
+	err = addExampleOutputs(ctx, doc, pkg)
+	if err != nil {
+		return fmt.Errorf("failed to add example output to %s: %s", pkg, err)
+	}
+
+	placeExamples(doc)
+
+	err = addExampleFiles(ctx, buf, doc, pkg, relativeBasePath(outPkg))
+	if err != nil {
+		return fmt.Errorf("failed to write examples of %s: %s", pkg, err)
+	}
+
+	err = addPlaygroundLinks(ctx, doc, pkg)
+	if err != nil {
+		return fmt.Errorf("failed to add playground links to %s: %s", pkg, err)
+	}
+
+	addProvenance(ctx, doc, pkg)
+
+	addSearchEntries(ctx, pkg, outPkg, doc)
+
+	addModuleWarnings(doc, pkg)
+
+	addLicenseBadge(doc, pkg, relativeBasePath(outPkg))
+
+	addImportPanel(ctx, doc, pkg)
+
+	err = addCommandDocs(ctx, doc, pkg, relativeBasePath(outPkg))
+	if err != nil {
+		return fmt.Errorf("failed to document command %s: %s", pkg, err)
+	}
+
+	err = addStructuredData(doc, pkg, path.Join(outPkg, "index.html"))
+	if err != nil {
+		return fmt.Errorf("failed to add structured data to %s: %s", pkg, err)
+	}
+
+	err = addEndpointsSection(ctx, doc, pkg, relativeBasePath(outPkg))
+	if err != nil {
+		return fmt.Errorf("failed to list endpoints of %s: %s", pkg, err)
+	}
+
+	err = addGoGenerateSection(ctx, doc, pkg, relativeBasePath(outPkg))
+	if err != nil {
+		return fmt.Errorf("failed to list go:generate directives of %s: %s", pkg, err)
+	}
+
+	err = addFuzzSection(ctx, buf, doc, pkg, relativeBasePath(outPkg))
+	if err != nil {
+		return fmt.Errorf("failed to list fuzz targets of %s: %s", pkg, err)
+	}
+
+	tests, err := addTestDocs(ctx, doc, pkg)
+	if err != nil {
+		return fmt.Errorf("failed to parse tests of %s: %s", pkg, err)
+	}
+
+	internalView := addUnexportedLink(doc)
+
+	addReferences(doc, pkg, relativeBasePath(outPkg))
+
+	addImplementers(doc, pkg, relativeBasePath(outPkg))
+
+	addSymbolRedirects(doc, pkg, relativeBasePath(outPkg))
+
+	err = transformPage(ctx, path.Join(outPkg, "index.html"), doc)
+	if err != nil {
+		return err
+	}
+
+	var untranslated *goquery.Document
+	if len(translationLanguages) > 0 {
+		untranslated = goquery.CloneDocument(doc)
+		err = translatePage(ctx, doc, pkg, outPkg, siteLanguage)
+		if err != nil {
+			return err
+		}
+	}
+
+	localPkgPath := filepath.Join(siteDestination, outPkg)
+
+	err = os.MkdirAll(localPkgPath, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to make directory %s: %s", localPkgPath, err)
+	}
+
+	buf.Reset()
+	err = html.Render(buf, doc.Nodes[0])
+	if err != nil {
+		return fmt.Errorf("failed to render HTML: %s", err)
+	}
+	err = writeFile(ctx, buf, outPkg, "index.html")
+	if err != nil {
+		return fmt.Errorf("failed to write docs for %s: %s", pkg, err)
+	}
+
+	if fragments {
+		err = writeFragment(ctx, buf, doc, outPkg)
+		if err != nil {
+			return fmt.Errorf("failed to write fragment for %s: %s", pkg, err)
+		}
+	}
+
+	if apiListing {
+		err = writeAPIListing(ctx, buf, pkg, outPkg)
+		if err != nil {
+			return fmt.Errorf("failed to write API listing for %s: %s", pkg, err)
+		}
+	}
+
+	if tests != nil {
+		err = writeTestDocsPage(ctx, buf, pkg, outPkg, tests)
+		if err != nil {
+			return err
+		}
+	}
+
+	if internalView {
+		err = writeUnexportedPage(ctx, buf, pkg, outPkg)
+		if err != nil {
+			return err
+		}
+	}
+
+	if untranslated != nil {
+		err = writeTranslations(ctx, buf, pkg, outPkg, untranslated)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyPackageSources writes the source file pages of a package.
+func copyPackageSources(ctx context.Context, buf *bytes.Buffer, pkg string) error {
+	if verbose {
+		logger.Printf("Copying %s sources...", pkg)
+	}
+
+	buf.Reset()
+	defer releaseSourceLinks(pkg)
+
+	dir := pkgPaths[pkg]
+	if dir == "" {
+		dir = getTmpDir()
+	}
+
+	cmd := exec.CommandContext(ctx, "go", "list", "-find", "-f", sourceFilesTemplate(), pkg)
+	cmd.Env = godocEnv
+	cmd.Dir = dir
+	cmd.Stdout = buf
+	setDeathSignal(cmd)
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	} else if err != nil {
+		//return fmt.Errorf("failed to list source files of package %s: %s", pkg, err)
+		return nil // This is expected for packages without source files
+	}
+
+	sourceFiles := append(strings.Split(buf.String(), "\n"), "index.html")
+	for _, sourceFile := range sourceFiles {
+		sourceFile = strings.TrimSpace(sourceFile)
+		if sourceFile == "" {
+			continue
+		}
+
+		doc, err := sourceDocument(ctx, pkg, sourceFile)
+		if err != nil {
+			return err
+		}
+
+		doc.Find("title").First().SetHtml(fmt.Sprintf("%s - %s", pageTitle(pkg), siteName))
+
+		outSrcPath := path.Join("src", vanityPath(pkg))
+
+		outFileName := sourceFile
+		if !strings.HasSuffix(outFileName, ".html") {
+			outFileName += ".html"
+		}
+
+		updatePage(doc, path.Join(outSrcPath, outFileName), relativeBasePath(outSrcPath), siteName)
+
+		addNoindex(doc, pkg, path.Join(outSrcPath, outFileName))
+
+		linkLineNumbers(doc)
+
+		addSourceControls(doc, relativeBasePath(outSrcPath))
+
+		if annotationsFile != "" {
+			addAnnotations(doc, filepath.Join(pkgDirs[pkg], sourceFile))
+		}
+
+		addProvenance(ctx, doc, pkg)
+
+		doc.Find(".layout").First().Find("a").Each(func(_ int, selection *goquery.Selection) {
+			href := selection.AttrOr("href", "")
+			if !strings.HasSuffix(href, ".") && !strings.HasSuffix(href, "/") && !strings.HasSuffix(href, ".html") {
+				selection.SetAttr("href", href+".html")
+			}
+		})
+
+		err = transformPage(ctx, path.Join(outSrcPath, outFileName), doc)
+		if err != nil {
+			return err
+		}
+
+		pkgSrcPath := filepath.Join(siteDestination, outSrcPath)
+
+		err = os.MkdirAll(pkgSrcPath, 0755)
+		if err != nil {
+			return fmt.Errorf("failed to make directory %s: %s", pkgSrcPath, err)
+		}
+
+		buf.Reset()
+		err = html.Render(buf, doc.Nodes[0])
+		if err != nil {
+			return fmt.Errorf("failed to render HTML: %s", err)
+		}
+
+		err = writeFile(ctx, buf, outSrcPath, outFileName)
+		if err != nil {
+			return fmt.Errorf("failed to write docs for %s: %s", pkg, err)
+		}
+	}
+	return nil
+}
+
+func relativeBasePath(p string) string {
+	var r string
+	if p != "" {
+		r += "../"
+	}
+	p = filepath.ToSlash(p)
+	for i := strings.Count(p, "/"); i > 0; i-- {
+		r += "../"
+	}
+	return r
+}
+
+func uniqueStrings(strSlice []string) []string {
+	keys := make(map[string]bool)
+	var unique []string
+	for _, entry := range strSlice {
+		if _, value := keys[entry]; !value {
+			keys[entry] = true
+			unique = append(unique, entry)
+		}
+	}
+	return unique
+}
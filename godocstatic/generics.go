@@ -0,0 +1,254 @@
+package godocstatic
+
+import (
+	"context"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"html"
+	"path/filepath"
+	"strings"
+)
+
+// declaresTypeParams reports whether a package declares generic functions or
+// types. godoc predates type parameters and fails to render such packages.
+func declaresTypeParams(ctx context.Context, pkg string) bool {
+	p, err := loadNativePackage(ctx, pkg)
+	if err != nil {
+		return false
+	}
+
+	fset := token.NewFileSet()
+	for _, file := range append(append([]string{}, p.GoFiles...), p.CgoFiles...) {
+		f, err := parser.ParseFile(fset, filepath.Join(p.Dir, file), nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Type.TypeParams != nil {
+					return true
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if spec, ok := spec.(*ast.TypeSpec); ok && spec.TypeParams != nil {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// typeSpec returns the specification of a type documented by go/doc, which
+// declares each type separately.
+func typeSpec(t *doc.Type) *ast.TypeSpec {
+	if t.Decl == nil || len(t.Decl.Specs) == 0 {
+		return nil
+	}
+	spec, _ := t.Decl.Specs[0].(*ast.TypeSpec)
+	return spec
+}
+
+// typeParams returns the type parameter list of a generic type, such as
+// [K comparable, V any], or an empty string when the type is not generic.
+// Constraints declared by the package are linked to their documentation when
+// link is set.
+func (r *nativeRenderer) typeParams(t *doc.Type, link bool) string {
+	spec := typeSpec(t)
+	if spec == nil || spec.TypeParams == nil {
+		return ""
+	}
+
+	params := make([]string, len(spec.TypeParams.List))
+	for i, field := range spec.TypeParams.List {
+		names := make([]string, len(field.Names))
+		for j, name := range field.Names {
+			names[j] = name.Name
+		}
+
+		constraint := html.EscapeString(r.node(field.Type))
+		if ident, ok := field.Type.(*ast.Ident); ok && link && r.documentedType(ident.Name) != nil {
+			constraint = `<a href="#` + ident.Name + `">` + constraint + `</a>`
+		}
+		params[i] = html.EscapeString(strings.Join(names, ", ")) + " " + constraint
+	}
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
+// documentedType returns the type of the package with a name, or nil when the
+// package does not document it.
+func (r *nativeRenderer) documentedType(name string) *doc.Type {
+	for _, t := range r.doc.Types {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// instantiatedMethod is a method of a generic type promoted to a type which
+// instantiates it, either as an alias or an embedded field.
+type instantiatedMethod struct {
+	// Method is the method of the generic type.
+	Method *doc.Func
+	// Generic is the generic type declaring the method.
+	Generic *doc.Type
+	// Recv is the receiver of the method, such as *Ints.
+	Recv string
+	// Decl is the declaration of the method with the type arguments of the
+	// instantiation substituted for its type parameters.
+	Decl string
+}
+
+// instantiation returns the generic type documented by the package which a
+// type expression such as List[int] instantiates, and its type arguments.
+func (r *nativeRenderer) instantiation(expr ast.Expr) (*doc.Type, []ast.Expr) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+
+	var x ast.Expr
+	var args []ast.Expr
+	switch expr := expr.(type) {
+	case *ast.IndexExpr:
+		x, args = expr.X, []ast.Expr{expr.Index}
+	case *ast.IndexListExpr:
+		x, args = expr.X, expr.Indices
+	default:
+		return nil, nil
+	}
+
+	ident, ok := x.(*ast.Ident)
+	if !ok {
+		return nil, nil
+	}
+	return r.documentedType(ident.Name), args
+}
+
+// instantiatedMethods returns the methods a type gains by instantiating
+// generic types of the package, excluding methods the type declares itself.
+func (r *nativeRenderer) instantiatedMethods(t *doc.Type) []*instantiatedMethod {
+	spec := typeSpec(t)
+	if spec == nil {
+		return nil
+	}
+
+	var instances []ast.Expr
+	if spec.Assign.IsValid() {
+		instances = append(instances, spec.Type)
+	} else if s, ok := spec.Type.(*ast.StructType); ok {
+		for _, field := range s.Fields.List {
+			if len(field.Names) == 0 {
+				instances = append(instances, field.Type)
+			}
+		}
+	}
+
+	declared := make(map[string]bool)
+	for _, m := range t.Methods {
+		declared[m.Name] = true
+	}
+
+	var methods []*instantiatedMethod
+	for _, instance := range instances {
+		generic, args := r.instantiation(instance)
+		if generic == nil {
+			continue
+		}
+
+		for _, m := range generic.Methods {
+			if declared[m.Name] {
+				continue
+			}
+			declared[m.Name] = true
+
+			recv, decl := r.instantiate(m, t.Name, args)
+			methods = append(methods, &instantiatedMethod{Method: m, Generic: generic, Recv: recv, Decl: decl})
+		}
+	}
+	return methods
+}
+
+// instantiate returns the receiver and declaration of a method of a generic
+// type promoted to the named type, substituting the type arguments of the
+// instantiation for the type parameters of the method's receiver.
+func (r *nativeRenderer) instantiate(m *doc.Func, name string, args []ast.Expr) (string, string) {
+	recv := name
+	var recvName string
+	substitutes := make(map[string]string)
+	if m.Decl.Recv != nil && len(m.Decl.Recv.List) > 0 {
+		field := m.Decl.Recv.List[0]
+		if len(field.Names) > 0 {
+			recvName = field.Names[0].Name + " "
+		}
+
+		expr := field.Type
+		if star, ok := expr.(*ast.StarExpr); ok {
+			recv = "*" + recv
+			expr = star.X
+		}
+
+		var params []ast.Expr
+		switch expr := expr.(type) {
+		case *ast.IndexExpr:
+			params = []ast.Expr{expr.Index}
+		case *ast.IndexListExpr:
+			params = expr.Indices
+		}
+		for i, param := range params {
+			if ident, ok := param.(*ast.Ident); ok && ident.Name != "_" && i < len(args) {
+				substitutes[ident.Name] = r.node(args[i])
+			}
+		}
+	}
+
+	signature := strings.TrimPrefix(r.node(m.Decl.Type), "func")
+	return recv, "func (" + recvName + recv + ") " + m.Name + substituteIdents(signature, substitutes)
+}
+
+// substituteIdents replaces the identifiers of Go source which are not
+// qualified by a package or value.
+func substituteIdents(src string, substitutes map[string]string) string {
+	if len(substitutes) == 0 {
+		return src
+	}
+
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	s.Init(file, []byte(src), nil, 0)
+
+	var b strings.Builder
+	var last int
+	var prev token.Token
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		if substitute, ok := substitutes[lit]; ok && tok == token.IDENT && prev != token.PERIOD {
+			offset := file.Offset(pos)
+			b.WriteString(src[last:offset] + substitute)
+			last = offset + len(lit)
+		}
+		prev = tok
+	}
+	b.WriteString(src[last:])
+	return b.String()
+}
+
+// instantiatedFunction renders a method promoted from a generic type, linking
+// to the documentation of the generic method.
+func (r *nativeRenderer) instantiatedFunction(b *strings.Builder, t *doc.Type, m *instantiatedMethod) {
+	id := t.Name + "." + m.Method.Name
+	b.WriteString(`<h3 id="` + id + `">func (` + html.EscapeString(m.Recv) + `) <a href="#` + m.Generic.Name + `.` + m.Method.Name + `">` + html.EscapeString(m.Method.Name) + `</a> <a class="permalink" href="#` + id + `">&#xb6;</a></h3>
+<pre>` + html.EscapeString(m.Decl) + `</pre>
+` + r.comment(m.Method.Doc))
+}
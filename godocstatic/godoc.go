@@ -0,0 +1,225 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+
+	"golang.org/x/tools/godoc"
+	"golang.org/x/tools/godoc/static"
+	"golang.org/x/tools/godoc/vfs"
+	"golang.org/x/tools/godoc/vfs/mapfs"
+)
+
+var (
+	// logger writes the messages of godoc-static. The godoc library writes
+	// its own messages, such as errors serving directories, to the standard
+	// logger, which is discarded while generating unless -verbose is supplied.
+	logger = log.Default()
+
+	godocPresentation *godoc.Presentation
+
+	// godocModules maps module paths to the presentations serving their
+	// packages when multiple modules are supplied.
+	godocModules map[string]*godoc.Presentation
+)
+
+// moduleFS treats packages whose import path begins with a domain name as
+// third party packages, as godoc does in module mode.
+type moduleFS struct{ vfs.FileSystem }
+
+func (moduleFS) RootType(p string) vfs.RootType {
+	if !strings.HasPrefix(p, "/src/") {
+		return ""
+	}
+	domain := p[len("/src/"):]
+	if i := strings.Index(domain, "/"); i >= 0 {
+		domain = domain[:i]
+	}
+	if !strings.Contains(domain, ".") {
+		return vfs.RootTypeGoRoot
+	}
+	return vfs.RootTypeGoPath
+}
+
+func (fs moduleFS) String() string { return "module(" + fs.FileSystem.String() + ")" }
+
+// newPresentation initializes a corpus of the packages in fs and parses the
+// godoc templates.
+func newPresentation(fs vfs.NameSpace, versionInfo bool) (*godoc.Presentation, error) {
+	corpus := godoc.NewCorpus(moduleFS{fs})
+	err := corpus.Init()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize godoc: %s", err)
+	}
+	if versionInfo {
+		corpus.InitVersionInfo()
+	}
+
+	pres := godoc.NewPresentation(corpus)
+	funcs := pres.FuncMap()
+	funcs["doc_comment_html"] = docCommentHTML
+
+	for _, t := range []struct {
+		name     string
+		template **template.Template
+	}{
+		{"dirlist.html", &pres.DirlistHTML},
+		{"error.html", &pres.ErrorHTML},
+		{"example.html", &pres.ExampleHTML},
+		{"godoc.html", &pres.GodocHTML},
+		{"methodset.html", &pres.MethodSetHTML},
+		{"package.html", &pres.PackageHTML},
+		{"packageroot.html", &pres.PackageRootHTML},
+	} {
+		data, err := vfs.ReadFile(fs, "/lib/godoc/"+t.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read godoc template %s: %s", t.name, err)
+		}
+
+		*t.template, err = template.New(t.name).Funcs(funcs).Parse(docCommentTemplate(t.name, string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse godoc template %s: %s", t.name, err)
+		}
+	}
+	return pres, nil
+}
+
+// initGodoc prepares godoc to serve the documentation of GOROOT, GOPATH and
+// each module supplied, in-process. When multiple modules are supplied, the
+// packages of each module are served by a corpus of their own, initialized
+// concurrently using up to -workers goroutines, so that modules do not
+// shadow one another.
+func initGodoc(ctx context.Context) error {
+	vfs.GOROOT = build.Default.GOROOT
+
+	fs := vfs.NameSpace{}
+	fs.Bind("/", vfs.OS(vfs.GOROOT), "/", vfs.BindReplace)
+	fs.Bind("/lib/godoc", mapfs.New(static.Files), "/", vfs.BindReplace)
+	for _, p := range filepath.SplitList(goPath) {
+		fs.Bind("/src", vfs.OS(p), "/src", vfs.BindAfter)
+	}
+
+	// The directories containing modules only exist as the parents of the
+	// directories modules are bound to, which godoc fails to serve. They are
+	// added as directories without packages, so that godoc lists them.
+	parents := make(map[string]string)
+	for _, name := range moduleNames() {
+		fs.Bind(path.Join("/src", name), vfs.OS(modules[name].Dir), "/", vfs.BindBefore)
+		for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			parents[dir+"/.keep"] = ""
+		}
+	}
+	if len(parents) > 0 {
+		fs.Bind("/src", mapfs.New(parents), "/", vfs.BindAfter)
+	}
+
+	pres, err := newPresentation(fs, true)
+	if err != nil {
+		return err
+	}
+	godocPresentation = pres
+
+	godocModules = make(map[string]*godoc.Presentation)
+	if len(modules) < 2 {
+		return nil
+	}
+
+	var lock sync.Mutex
+	return scrapePackages(ctx, moduleNames(), func(ctx context.Context, buf *bytes.Buffer, name string) error {
+		fs := vfs.NameSpace{}
+		// The corpus requires a root directory.
+		fs.Bind("/", mapfs.New(map[string]string{"src/.keep": ""}), "/", vfs.BindReplace)
+		fs.Bind("/lib/godoc", mapfs.New(static.Files), "/", vfs.BindReplace)
+		fs.Bind(path.Join("/src", name), vfs.OS(modules[name].Dir), "/", vfs.BindReplace)
+
+		pres, err := newPresentation(fs, false)
+		if err != nil {
+			return fmt.Errorf("failed to initialize godoc for module %s: %s", name, err)
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		godocModules[name] = pres
+		return nil
+	})
+}
+
+// discardGodocLog discards the messages written to the standard logger by
+// the godoc library unless -verbose is supplied, returning a function which
+// restores the standard logger.
+func discardGodocLog() func() {
+	if verbose {
+		return func() {}
+	}
+
+	out := log.Writer()
+	logger = log.New(out, log.Prefix(), log.Flags())
+	log.SetOutput(ioutil.Discard)
+	return func() {
+		log.SetOutput(out)
+		logger = log.Default()
+	}
+}
+
+// useGodocBuildTags sets the build tags supplied with -tags on the default
+// build context, which the godoc library copies to select the files of
+// packages, returning a function which restores the previous build tags.
+func useGodocBuildTags() func() {
+	tags := build.Default.BuildTags
+	build.Default.BuildTags = godocBuildTags
+	return func() {
+		build.Default.BuildTags = tags
+	}
+}
+
+// presentation returns the godoc presentation serving a page.
+func presentation(urlPath string) *godoc.Presentation {
+	for _, prefix := range []string{"/pkg/", "/src/"} {
+		if !strings.HasPrefix(urlPath, prefix) {
+			continue
+		}
+
+		pkg := strings.TrimSuffix(urlPath[len(prefix):], "/")
+		if i := strings.IndexAny(pkg, "?#"); i >= 0 {
+			pkg = pkg[:i]
+		}
+		for pkg != "." && pkg != "" {
+			if pres, ok := godocModules[pkg]; ok {
+				return pres
+			}
+			pkg = path.Dir(pkg)
+		}
+	}
+	return godocPresentation
+}
+
+// fetchPage returns the body of a page served by godoc. Panics of godoc,
+// which does not support every language feature, are returned as errors.
+func fetchPage(ctx context.Context, urlPath string) (body []byte, err error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			body, err = nil, fmt.Errorf("godoc failed to serve %s: %v", urlPath, r)
+		}
+	}()
+
+	req := httptest.NewRequest(http.MethodGet, urlPath, nil).WithContext(ctx)
+	res := httptest.NewRecorder()
+	presentation(urlPath).ServeHTTP(res, req)
+	return res.Body.Bytes(), nil
+}
@@ -0,0 +1,282 @@
+package godocstatic
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Config specifies the documentation generated by Generate. Each field
+// corresponds to the generate option of the same name.
+type Config struct {
+	// Packages lists the import paths and/or paths of the packages to
+	// document. When empty, packages listed by go list ... are documented.
+	Packages []string
+
+	SiteName            string
+	SiteDescription     string
+	SiteDescriptionFile string
+	SiteFooter          string
+	SiteFooterFile      string
+
+	// Destination is the path to write the site to. It must exist.
+	Destination string
+
+	// BaseURL is the URL the site is published at. When set, sitemap.xml is
+	// written.
+	BaseURL string
+
+	ExternalLinks string
+
+	Robots         string
+	RobotsDisallow []string
+	NoindexPattern []string
+	Priority       []string
+
+	Zip          string
+	ZipSplitSize string
+
+	Renderer            string
+	DocComments         string
+	DisableFilter       bool
+	LinkIndex           bool
+	GO111Modules        bool
+	ModulePath          string
+	Tags                []string
+	GOFLAGS             string
+	GOPROXY             string
+	GONOSUMDB           string
+	GOPRIVATE           string
+	Netrc               string
+	Env                 []string
+	Exclude             []string
+	ExcludeFile         string
+	Include             []string
+	IndexPageSize       int
+	Examples            string
+	Fragments           bool
+	APIListing          bool
+	CommandHelp         bool
+	TestDocs            bool
+	Unexported          bool
+	Stdlib              bool
+	WithDeps            string
+	FuzzTargets         bool
+	Notes               []string
+	Deprecations        bool
+	DeprecationRemovals string
+	Endpoints           bool
+	Provenance          bool
+
+	ImportPanel     bool
+	ImportGOPRIVATE string
+	ImportGOPROXY   string
+
+	ExampleFiles  bool
+	Playground    bool
+	PlaygroundURL string
+
+	Translations string
+	Language     string
+
+	Templates string
+
+	ImageWidths []string
+	RequireAlt  bool
+
+	SourceStyle     string
+	SourceStyleDark string
+
+	SymbolIndex        bool
+	DependencyGraph    bool
+	ModuleInfo         bool
+	ImportGraph        bool
+	Licenses           bool
+	DependencyLicenses bool
+	References         bool
+	Implementers       bool
+	SourceLinks        bool
+	SourceTypes        []string
+	Annotations        string
+	Owners             string
+	Search             string
+	Redirects          string
+	SymbolRedirects    string
+	Vanity             []string
+	TrimPrefix         string
+	Versions           []string
+	VersionTags        string
+	APIDiff            bool
+	Theme              string
+	ThemeVariant       string
+	ThemeSCSS          string
+	ThemeVars          []string
+	NoJS               bool
+	AssetsDir          string
+	ExtraCSS           []string
+	ExtraJS            []string
+	A11yReport         string
+	CompatReport       string
+	DocReport          string
+	DocDictionary      []string
+	Cache              string
+	SaveState          string
+	LoadState          string
+	WorkDir            string
+	Workers            int
+	Timeout            time.Duration
+	Deadline           time.Duration
+	Watch              bool
+
+	// TransformExec lists commands which transform the HTML of each page.
+	TransformExec []string
+
+	// Transformers are applied to each package and source page, before the
+	// commands listed in TransformExec.
+	Transformers []PageTransformer
+
+	Quiet   bool
+	Verbose bool
+}
+
+// DefaultConfig returns the configuration used by the generate command when
+// no options are supplied.
+func DefaultConfig() Config {
+	return Config{
+		SiteName:        "Documentation",
+		Zip:             "docs.zip",
+		Renderer:        rendererGodoc,
+		DocComments:     docCommentsGo119,
+		Examples:        examplesCollapsed,
+		Robots:          robotsAllow,
+		ExternalLinks:   externalLinksPkgGoDev,
+		Theme:           themeClassic,
+		PlaygroundURL:   defaultPlaygroundURL,
+		Language:        defaultLanguage,
+		ImageWidths:     strings.Split(defaultImageWidths, ","),
+		SourceStyle:     defaultSourceStyle,
+		SourceStyleDark: defaultSourceStyleDark,
+		GO111Modules:    true,
+		Workers:         1,
+	}
+}
+
+// Generate generates documentation as specified by c. The state of previous
+// calls is cleared. Generate must not be called concurrently.
+func Generate(ctx context.Context, c Config) error {
+	siteName = c.SiteName
+	siteDescription = c.SiteDescription
+	siteDescriptionFile = c.SiteDescriptionFile
+	siteFooter = c.SiteFooter
+	siteFooterFile = c.SiteFooterFile
+	siteDestination = c.Destination
+	baseURL = c.BaseURL
+	externalLinks = c.ExternalLinks
+	robots = c.Robots
+	robotsDisallow = strings.Join(c.RobotsDisallow, ",")
+	noindexPattern = strings.Join(c.NoindexPattern, ",")
+	priority = strings.Join(c.Priority, ",")
+	siteZip = c.Zip
+	zipSplitSize = c.ZipSplitSize
+	renderer = c.Renderer
+	docComments = c.DocComments
+	disableFilter = c.DisableFilter
+	linkIndex = c.LinkIndex
+	go111Modules = c.GO111Modules
+	syntheticModulePath = c.ModulePath
+	buildTags = strings.Join(c.Tags, ",")
+	goFlags = c.GOFLAGS
+	goProxy = c.GOPROXY
+	goNoSumDB = c.GONOSUMDB
+	goPrivate = c.GOPRIVATE
+	netrc = c.Netrc
+	goEnv = append(stringsFlag(nil), c.Env...)
+	excludePackages = strings.Join(c.Exclude, " ")
+	excludeFile = c.ExcludeFile
+	includePackages = strings.Join(c.Include, " ")
+	indexPageSize = c.IndexPageSize
+	examplePlacement = c.Examples
+	fragments = c.Fragments
+	apiListing = c.APIListing
+	provenance = c.Provenance
+	endpoints = c.Endpoints
+	commandHelp = c.CommandHelp
+	testDocs = c.TestDocs
+	unexported = c.Unexported
+	stdlib = c.Stdlib
+	withDeps = depsFlag(c.WithDeps)
+	fuzzTargets = c.FuzzTargets
+	notes = strings.Join(c.Notes, ",")
+	deprecations = c.Deprecations
+	deprecationRemovalsFile = c.DeprecationRemovals
+	importPanel = c.ImportPanel
+	importGOPRIVATE = c.ImportGOPRIVATE
+	importGOPROXY = c.ImportGOPROXY
+	exampleFiles = c.ExampleFiles
+	playground = c.Playground
+	playgroundURL = c.PlaygroundURL
+	translationsDir = c.Translations
+	templatesDir = c.Templates
+	siteLanguage = c.Language
+	imageWidths = strings.Join(c.ImageWidths, ",")
+	requireAlt = c.RequireAlt
+	symbolIndex = c.SymbolIndex
+	dependencyGraph = c.DependencyGraph
+	goModInfo = c.ModuleInfo
+	importGraph = c.ImportGraph
+	licenses = c.Licenses
+	dependencyLicenses = c.DependencyLicenses
+	references = c.References
+	implementers = c.Implementers
+	sourceStyle = c.SourceStyle
+	sourceStyleDark = c.SourceStyleDark
+	sourceLinks = c.SourceLinks
+	sourceTypes = strings.Join(c.SourceTypes, ",")
+	annotationsFile = c.Annotations
+	ownersFile = c.Owners
+	searchMode = c.Search
+	redirectsFile = c.Redirects
+	symbolRedirectsFile = c.SymbolRedirects
+	vanity = append(stringsFlag(nil), c.Vanity...)
+	trimPrefix = c.TrimPrefix
+	versions = strings.Join(c.Versions, ",")
+	versionTags = tagsFlag(c.VersionTags)
+	apiDiff = c.APIDiff
+	themeName = c.Theme
+	themeVariant = c.ThemeVariant
+	themeSCSS = c.ThemeSCSS
+	themeVars = append(stringsFlag(nil), c.ThemeVars...)
+	noJS = c.NoJS
+	assetsDir = c.AssetsDir
+	extraCSS = append(stringsFlag(nil), c.ExtraCSS...)
+	extraJS = append(stringsFlag(nil), c.ExtraJS...)
+	a11yReport = c.A11yReport
+	compatReport = c.CompatReport
+	docReport = c.DocReport
+	docDictionary = strings.Join(c.DocDictionary, ",")
+	cacheFile = c.Cache
+	saveStateFile = c.SaveState
+	loadStateFile = c.LoadState
+	workDir = c.WorkDir
+	workers = c.Workers
+	timeout = c.Timeout
+	deadline = c.Deadline
+	watch = c.Watch
+	transformExec = append(stringsFlag(nil), c.TransformExec...)
+	pageTransformers = append([]PageTransformer(nil), c.Transformers...)
+	quiet = c.Quiet
+	verbose = c.Verbose
+
+	resetGenerationState()
+	versionList, versionAPIs = nil, nil
+	sharedAssets = make(map[string][]byte)
+	playgroundShares = make(map[string]string)
+
+	return run(ctx, c.Packages)
+}
+
+// Main runs the godoc-static command line interface with the supplied
+// arguments, excluding the program name.
+func Main(args []string) error {
+	return runCommand(args)
+}
@@ -0,0 +1,128 @@
+package godocstatic
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	goFlags   string
+	goProxy   string
+	goNoSumDB string
+	goPrivate string
+	netrc     string
+	goEnv     stringsFlag
+
+	// godocBuildTags lists the build tags supplied with -tags.
+	godocBuildTags []string
+)
+
+// privateModuleErrors are the messages of go commands which fail to fetch a
+// module because it is private and access to it was denied.
+var privateModuleErrors = []string{
+	"terminal prompts disabled",
+	"could not read Username",
+	"could not read Password",
+	"Authentication failed",
+	"Permission denied (publickey)",
+	"Repository not found",
+	"401 Unauthorized",
+	"403 Forbidden",
+	"404 Not Found",
+	"410 Gone",
+	"verifying module",
+	"SECURITY ERROR",
+}
+
+// setGodocEnv sets an environment variable of the go commands run while
+// generating documentation, replacing any previous value.
+func setGodocEnv(key string, value string) {
+	for i, e := range godocEnv {
+		if strings.HasPrefix(e, key+"=") {
+			godocEnv[i] = ""
+		}
+	}
+	godocEnv = append(godocEnv, key+"="+value)
+}
+
+// godocEnvValue returns the value of an environment variable of the go
+// commands run while generating documentation.
+func godocEnvValue(key string) string {
+	var value string
+	for _, e := range godocEnv {
+		if strings.HasPrefix(e, key+"=") {
+			value = e[len(key)+1:]
+		}
+	}
+	return value
+}
+
+// initGodocEnv initializes the environment of the go commands run while
+// generating documentation from the environment of godoc-static, overridden
+// by -go111modules, -goflags, -goproxy, -gonosumdb, -goprivate, -netrc, -env
+// and -tags.
+func initGodocEnv() error {
+	godocEnv = make([]string, len(os.Environ()))
+	copy(godocEnv, os.Environ())
+
+	if go111Modules {
+		setGodocEnv("GO111MODULE", "auto")
+	}
+
+	if goFlags != "" {
+		setGodocEnv("GOFLAGS", goFlags)
+	}
+	if goProxy != "" {
+		setGodocEnv("GOPROXY", goProxy)
+	}
+	if goNoSumDB != "" {
+		setGodocEnv("GONOSUMDB", goNoSumDB)
+	}
+	if goPrivate != "" {
+		setGodocEnv("GOPRIVATE", goPrivate)
+	}
+	if netrc != "" {
+		setGodocEnv("NETRC", netrc)
+	}
+	for _, e := range goEnv {
+		equals := strings.IndexRune(e, '=')
+		if equals <= 0 {
+			return fmt.Errorf("invalid environment variable %s: expected NAME=value", e)
+		}
+		setGodocEnv(e[:equals], e[equals+1:])
+	}
+
+	// Build tags are passed to go commands, which list and type-check the
+	// packages documented.
+	godocBuildTags = nil
+	for _, tag := range strings.Split(buildTags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			godocBuildTags = append(godocBuildTags, tag)
+		}
+	}
+	if len(godocBuildTags) > 0 {
+		setGodocEnv("GOFLAGS", strings.TrimSpace(godocEnvValue("GOFLAGS")+" -tags="+strings.Join(godocBuildTags, ",")))
+	}
+	return nil
+}
+
+// privateModuleHint returns advice on fetching private modules when the output
+// of a go command shows that it was denied access to a module, or an empty
+// string otherwise. Private modules are fetched directly from their
+// repositories using the credentials in .netrc or of git, and must not be
+// verified using the public checksum database.
+func privateModuleHint(output string) string {
+	for _, message := range privateModuleErrors {
+		if !strings.Contains(output, message) {
+			continue
+		}
+
+		hint := "the module may be private: "
+		if godocEnvValue("GOPRIVATE") == "" {
+			hint += "set -goprivate (or GOPRIVATE) to the path prefixes of private modules, and "
+		}
+		return hint + "make sure the credentials to fetch it are configured in .netrc (see -netrc) or a git credential helper"
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package godocstatic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"html"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const goGenerateDirective = "//go:generate "
+
+// maxSourceLine is the length of the longest line of a source file which is
+// scanned.
+const maxSourceLine = 64 * 1024 * 1024
+
+type generateDirective struct {
+	File    string
+	Line    int
+	Command string
+}
+
+// packageFiles returns the directory and Go source files of a package.
+func packageFiles(ctx context.Context, pkg string) (string, []string, error) {
+	var buf bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "go", "list", "-find", "-f",
+		`{{ .Dir }}`+"\n"+
+			`{{ join .GoFiles "\n" }}`+"\n"+
+			`{{ join .CgoFiles "\n" }}`+"\n"+
+			`{{ join .TestGoFiles "\n" }}`+"\n"+
+			`{{ join .XTestGoFiles "\n" }}`,
+		pkg)
+	cmd.Env = godocEnv
+	cmd.Dir = pkgPaths[pkg]
+	if cmd.Dir == "" {
+		cmd.Dir = getTmpDir()
+	}
+	cmd.Stdout = &buf
+	setDeathSignal(cmd)
+
+	err := cmd.Run()
+	if err != nil {
+		return "", nil, err
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+
+	var files []string
+	for _, file := range lines[1:] {
+		file = strings.TrimSpace(file)
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return strings.TrimSpace(lines[0]), files, nil
+}
+
+func goGenerateDirectives(dir string, files []string) ([]generateDirective, error) {
+	var directives []generateDirective
+	for _, file := range files {
+		f, err := os.Open(filepath.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(f)
+		// Generated files may contain long lines, such as embedded data.
+		scanner.Buffer(nil, maxSourceLine)
+		var line int
+		for scanner.Scan() {
+			line++
+
+			text := scanner.Text()
+			if !strings.HasPrefix(text, goGenerateDirective) {
+				continue
+			}
+
+			directives = append(directives, generateDirective{
+				File:    file,
+				Line:    line,
+				Command: strings.TrimSpace(text[len(goGenerateDirective):]),
+			})
+		}
+		f.Close()
+
+		err = scanner.Err()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return directives, nil
+}
+
+// addGoGenerateSection lists the go:generate directives of a package below its
+// overview.
+func addGoGenerateSection(ctx context.Context, doc *goquery.Document, pkg string, basePath string) error {
+	dir, files, err := packageFiles(ctx, pkg)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	} else if err != nil {
+		return nil // This is expected for packages without source files
+	}
+
+	directives, err := goGenerateDirectives(dir, files)
+	if err != nil || len(directives) == 0 {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(`<div id="pkg-generate">
+<h2>Code generation</h2>
+<table>
+`)
+	for _, d := range directives {
+		line := strconv.Itoa(d.Line)
+		file := html.EscapeString(d.File)
+		b.WriteString(`<tr><td><a href="` + basePath + "src/" + vanityPath(pkg) + "/" + file + ".html#L" + line + `">` + file + ":" + line + `</a></td><td><code>` + html.EscapeString(d.Command) + `</code></td></tr>
+`)
+	}
+	b.WriteString(`</table>
+</div>`)
+
+	doc.Find("#pkg-index").First().BeforeHtml(b.String())
+	doc.Find("#short-nav").First().Find("dl").Last().AppendHtml(`<dd><a href="#pkg-generate">Code generation</a></dd>`)
+	return nil
+}
@@ -0,0 +1,145 @@
+package godocstatic
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+)
+
+// sourceStyleNone disables syntax highlighting of source files, leaving them
+// to be rendered by the renderer selected with -renderer.
+const sourceStyleNone = "none"
+
+const (
+	defaultSourceStyle     = "github"
+	defaultSourceStyleDark = "monokai"
+)
+
+var (
+	sourceStyle     string
+	sourceStyleDark string
+)
+
+func validateSourceStyle() error {
+	for _, name := range []string{sourceStyle, sourceStyleDark} {
+		if _, ok := styles.Registry[name]; !ok && name != sourceStyleNone {
+			return fmt.Errorf("unknown source style %s: must be %s or one of %s", name, sourceStyleNone, strings.Join(styles.Names(), ", "))
+		}
+	}
+	return nil
+}
+
+// sourceHighlighting returns whether source files are highlighted with chroma.
+func sourceHighlighting() bool {
+	return sourceStyle != sourceStyleNone
+}
+
+// tokenClass returns the class of the elements containing tokens of a type.
+func tokenClass(t chroma.TokenType) string {
+	for ; t != 0; t = t.Parent() {
+		if class, ok := chroma.StandardTypes[t]; ok {
+			return class
+		}
+	}
+	return ""
+}
+
+// highlightSource returns the lines of a source file highlighted with chroma,
+// without line endings, linking the identifiers it contains.
+func highlightSource(name string, src []byte, links map[int]sourceLink) ([]string, error) {
+	lexer := lexers.Match(name)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+
+	tokens, err := chroma.Coalesce(lexer).Tokenise(nil, string(src))
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	var offset int
+	for _, line := range chroma.SplitTokensIntoLines(tokens.Tokens()) {
+		var b strings.Builder
+		for _, token := range line {
+			// Identifiers are only linked while the tokens match the source.
+			start := offset
+			offset += len(token.Value)
+			if offset > len(src) || string(src[start:offset]) != token.Value {
+				links = nil
+			}
+
+			var value string
+			if trimmed := strings.TrimRight(token.Value, "\r\n"); links != nil {
+				value = linkIdentifiers(src, start, start+len(trimmed), links)
+			} else {
+				value = html.EscapeString(trimmed)
+			}
+			if value == "" {
+				continue
+			}
+
+			if class := tokenClass(token.Type); class != "" && class != "w" {
+				value = `<span class="` + class + `">` + value + `</span>`
+			}
+			b.WriteString(value)
+		}
+		lines = append(lines, b.String())
+	}
+	return lines, nil
+}
+
+// styleRules returns CSS rules coloring the tokens of highlighted source files
+// using a chroma style. Each rule begins with prefix.
+func styleRules(prefix string, name string) string {
+	style := styles.Get(name)
+
+	var rules []string
+	for t, class := range chroma.StandardTypes {
+		if class == "" || (t < 0 && t != chroma.Error) {
+			continue
+		}
+
+		entry := style.Get(t)
+		var declarations []string
+		if entry.Colour.IsSet() {
+			declarations = append(declarations, "color: "+entry.Colour.String()+";")
+		}
+		if entry.Bold == chroma.Yes {
+			declarations = append(declarations, "font-weight: bold;")
+		}
+		if entry.Italic == chroma.Yes {
+			declarations = append(declarations, "font-style: italic;")
+		}
+		if entry.Underline == chroma.Yes {
+			declarations = append(declarations, "text-decoration: underline;")
+		}
+		if len(declarations) > 0 {
+			rules = append(rules, prefix+"pre.chroma ."+class+" { "+strings.Join(declarations, " ")+" }\n")
+		}
+	}
+	sort.Strings(rules)
+	return strings.Join(rules, "")
+}
+
+// sourceStyleCSS returns the rules coloring highlighted source files in the
+// light and dark themes.
+func sourceStyleCSS() string {
+	if !sourceHighlighting() {
+		return ""
+	}
+
+	css := "\n" + styleRules("", sourceStyle)
+	if sourceStyleDark != sourceStyleNone {
+		css += styleRules(`:root[data-theme="dark"] `, sourceStyleDark) +
+			"@media (prefers-color-scheme: dark) {\n" +
+			styleRules(`:root:not([data-theme="light"]) `, sourceStyleDark) +
+			"}\n"
+	}
+	return css
+}
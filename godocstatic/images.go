@@ -0,0 +1,295 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const defaultImageWidths = "480,960"
+
+var (
+	imageWidths string
+	requireAlt  bool
+
+	// imageWidthValues lists the widths of the responsive variants of
+	// images, in ascending order.
+	imageWidthValues []int
+)
+
+const imagesCSS = `
+.doc-figure { margin: 1.25rem; }
+.doc-figure img { max-width: 100%; height: auto; }
+.doc-figure figcaption { margin-top: 0.3125rem; font-size: 0.875rem; color: var(--text-muted); }
+`
+
+// parseImageWidths parses the widths of the responsive variants of images
+// written by -image-widths.
+func parseImageWidths() error {
+	imageWidthValues = nil
+	for _, value := range strings.Split(imageWidths, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		width, err := strconv.Atoi(value)
+		if err != nil || width <= 0 {
+			return fmt.Errorf("invalid image width %s: must be a positive number of pixels", value)
+		}
+		imageWidthValues = append(imageWidthValues, width)
+	}
+	sort.Ints(imageWidthValues)
+	return nil
+}
+
+// deriveAltText returns alternative text describing an image by the name of
+// its file, such as "Architecture diagram" for architecture-diagram.png.
+func deriveAltText(src string) string {
+	name := path.Base(src)
+	name = strings.TrimSuffix(name, path.Ext(name))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' {
+			return ' '
+		}
+		return r
+	}, name))
+	if name == "" {
+		return "Image"
+	}
+
+	runes := []rune(strings.Join(strings.Fields(name), " "))
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// scaleImage scales an image to a width, averaging the pixels of the source
+// which each pixel of the result covers.
+func scaleImage(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	in := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(in, in.Bounds(), src, bounds.Min, draw.Src)
+
+	height := (bounds.Dy()*width + bounds.Dx()/2) / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0, y1 := y*bounds.Dy()/height, (y+1)*bounds.Dy()/height
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*bounds.Dx()/width, (x+1)*bounds.Dx()/width
+			if x1 == x0 {
+				x1++
+			}
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					i := in.PixOffset(sx, sy)
+					r += int(in.Pix[i])
+					g += int(in.Pix[i+1])
+					b += int(in.Pix[i+2])
+					a += int(in.Pix[i+3])
+					n++
+				}
+			}
+
+			i := out.PixOffset(x, y)
+			out.Pix[i] = uint8(r / n)
+			out.Pix[i+1] = uint8(g / n)
+			out.Pix[i+2] = uint8(b / n)
+			out.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return out
+}
+
+// writeImageVariants writes the image in a file to the path relative to
+// outDir, along with a variant scaled to each width of -image-widths narrower
+// than the image. The srcset listing the variants relative to outDir and the
+// dimensions of the image are returned. Only PNG and JPEG images are scaled.
+func writeImageVariants(ctx context.Context, file string, outDir string, rel string) (string, image.Point, error) {
+	relDir, name := path.Split(rel)
+	dir := path.Join(outDir, relDir)
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", image.Point{}, err
+	}
+
+	err = os.MkdirAll(filepath.Join(siteDestination, dir), 0755)
+	if err != nil {
+		return "", image.Point{}, err
+	}
+	err = writeFile(ctx, bytes.NewBuffer(data), dir, name)
+	if err != nil {
+		return "", image.Point{}, err
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "png" && format != "jpeg") {
+		return "", image.Point{}, nil
+	}
+	size := image.Pt(config.Width, config.Height)
+
+	var variants []string
+	var decoded image.Image
+	for _, width := range imageWidthValues {
+		if width >= size.X {
+			break
+		}
+
+		if decoded == nil {
+			decoded, _, err = image.Decode(bytes.NewReader(data))
+			if err != nil {
+				return "", size, nil
+			}
+		}
+
+		var buf bytes.Buffer
+		scaled := scaleImage(decoded, width)
+		if format == "png" {
+			err = png.Encode(&buf, scaled)
+		} else {
+			err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: 85})
+		}
+		if err != nil {
+			return "", size, err
+		}
+
+		ext := path.Ext(name)
+		variant := strings.TrimSuffix(name, ext) + "-" + strconv.Itoa(width) + "w" + ext
+		err = writeFile(ctx, &buf, dir, variant)
+		if err != nil {
+			return "", size, err
+		}
+		variants = append(variants, relDir+variant+" "+strconv.Itoa(width)+"w")
+	}
+	if len(variants) == 0 {
+		return "", size, nil
+	}
+	return strings.Join(append(variants, rel+" "+strconv.Itoa(size.X)+"w"), ", "), size, nil
+}
+
+// processDocImages prepares the images of documentation rendered from a
+// Markdown file for the page written to outDir. Images without alternative
+// text are described by the name of their file, or rejected when -require-alt
+// is supplied, and images standing alone in a paragraph are placed in a figure
+// captioned by their title or alternative text. Images relative to the
+// Markdown file are copied to the site along with responsive variants.
+func processDocImages(ctx context.Context, content *goquery.Selection, sourceFile string, outDir string) error {
+	sourceDir := filepath.Dir(sourceFile)
+
+	var err error
+	content.Find("img").EachWithBreak(func(_ int, img *goquery.Selection) bool {
+		src := img.AttrOr("src", "")
+
+		alt := strings.TrimSpace(img.AttrOr("alt", ""))
+		if alt == "" && img.AttrOr("role", "") != "presentation" {
+			if requireAlt {
+				err = fmt.Errorf("%s: image %s has no alternative text", sourceFile, src)
+				return false
+			}
+			alt = deriveAltText(src)
+			img.SetAttr("alt", alt)
+			if verbose {
+				logger.Printf("Warning: %s: image %s has no alternative text, using %q", sourceFile, src, alt)
+			}
+		}
+
+		u, parseErr := url.Parse(src)
+		if parseErr == nil && src != "" && u.Scheme == "" && u.Host == "" && !strings.HasPrefix(u.Path, "/") {
+			rel := path.Clean(u.Path)
+			if rel == ".." || strings.HasPrefix(rel, "../") {
+				logger.Printf("Warning: %s: image %s is outside of %s and is not copied", sourceFile, src, sourceDir)
+			} else {
+				var srcset string
+				var size image.Point
+				srcset, size, err = writeImageVariants(ctx, filepath.Join(sourceDir, filepath.FromSlash(rel)), outDir, rel)
+				if err != nil {
+					err = fmt.Errorf("%s: failed to copy image %s: %s", sourceFile, src, err)
+					return false
+				}
+
+				if size.X > 0 {
+					img.SetAttr("width", strconv.Itoa(size.X))
+					img.SetAttr("height", strconv.Itoa(size.Y))
+				}
+				if srcset != "" {
+					img.SetAttr("srcset", srcset)
+					img.SetAttr("sizes", "(max-width: "+strconv.Itoa(size.X)+"px) 100vw, "+strconv.Itoa(size.X)+"px")
+				}
+			}
+		}
+		img.SetAttr("loading", "lazy")
+		img.SetAttr("decoding", "async")
+
+		figure := img
+		if parent := img.Parent(); goquery.NodeName(parent) == "a" && parent.Children().Length() == 1 {
+			figure = parent
+		}
+		paragraph := figure.Parent()
+		if goquery.NodeName(paragraph) != "p" || paragraph.Children().Length() != 1 || strings.TrimSpace(paragraph.Text()) != strings.TrimSpace(figure.Text()) {
+			return true
+		}
+
+		caption := strings.TrimSpace(img.AttrOr("title", ""))
+		if caption == "" {
+			caption = alt
+		}
+		var figureHTML string
+		figureHTML, err = goquery.OuterHtml(figure)
+		if err != nil {
+			return false
+		}
+		paragraph.ReplaceWithHtml(`<figure class="doc-figure">` + figureHTML + `<figcaption>` + html.EscapeString(caption) + `</figcaption></figure>`)
+		return true
+	})
+	return err
+}
+
+// docImagesHTML processes the images of HTML rendered from a Markdown file
+// for the page written to outDir.
+func docImagesHTML(ctx context.Context, content []byte, sourceFile string, outDir string) ([]byte, error) {
+	if !bytes.Contains(content, []byte("<img")) {
+		return content, nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	body := doc.Find("body").First()
+	err = processDocImages(ctx, body, sourceFile, outDir)
+	if err != nil {
+		return nil, err
+	}
+
+	processed, err := body.Html()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(processed), nil
+}
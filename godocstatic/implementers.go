@@ -0,0 +1,235 @@
+package godocstatic
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var implementers bool
+
+// typeName identifies an exported type declared in a documented package.
+type typeName struct {
+	Pkg  string
+	Name string
+}
+
+// implementation is a type implementing an interface, or an interface
+// implemented by a type. Pointer is set when only pointers to the type
+// implement the interface.
+type implementation struct {
+	typeName
+	PkgName string
+	Pointer bool
+}
+
+var (
+	// interfaceImplementers lists the types implementing each interface, and
+	// typeInterfaces the interfaces each type implements.
+	interfaceImplementers map[typeName][]implementation
+	typeInterfaces        map[typeName][]implementation
+)
+
+// loadImplementers type-checks the documented packages and finds the exported
+// concrete types implementing each exported interface among them.
+func loadImplementers(ctx context.Context, pkgs []string) error {
+	interfaceImplementers = make(map[typeName][]implementation)
+	typeInterfaces = make(map[typeName][]implementation)
+
+	// Packages are type-checked within the module they were supplied from.
+	// Dependencies are type-checked once for all packages, so that the types
+	// they share are identical.
+	var (
+		dirs    []string
+		dirPkgs = make(map[string][]string)
+	)
+	for _, pkg := range pkgs {
+		if _, ok := listFailures[pkg]; ok || pkgDirs[pkg] == "" {
+			continue
+		}
+
+		dir := pkgPaths[pkg]
+		if dir == "" {
+			dir = getTmpDir()
+		}
+		if dirPkgs[dir] == nil {
+			dirs = append(dirs, dir)
+		}
+		dirPkgs[dir] = append(dirPkgs[dir], pkg)
+	}
+
+	var (
+		fset    = token.NewFileSet()
+		checked = make(map[string]*types.Package)
+		typed   []*types.Package
+	)
+	for _, dir := range dirs {
+		checkedPkgs, pkgErrs, err := typeCheckPackages(ctx, dir, fset, checked, dirPkgs[dir]...)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		} else if err != nil {
+			return fmt.Errorf("failed to type-check packages in %s: %s", dir, err)
+		}
+
+		for _, pkg := range dirPkgs[dir] {
+			if err := pkgErrs[pkg]; err != nil {
+				return fmt.Errorf("failed to type-check %s: %s", pkg, err)
+			} else if checkedPkgs[pkg] == nil {
+				return fmt.Errorf("failed to type-check %s: package was not listed", pkg)
+			}
+			typed = append(typed, checkedPkgs[pkg])
+		}
+	}
+
+	var interfaces, concrete []*types.TypeName
+	for _, p := range typed {
+		if p.Name() == "main" {
+			continue
+		}
+
+		scope := p.Scope()
+		for _, name := range scope.Names() {
+			t, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || !t.Exported() || t.IsAlias() {
+				continue
+			}
+			named, ok := t.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 {
+				continue // Generic types are instantiated to implement interfaces
+			}
+
+			if iface, ok := named.Underlying().(*types.Interface); ok {
+				if iface.NumMethods() > 0 && iface.IsMethodSet() {
+					interfaces = append(interfaces, t)
+				}
+			} else {
+				concrete = append(concrete, t)
+			}
+		}
+	}
+
+	for _, i := range interfaces {
+		iface := i.Type().Underlying().(*types.Interface)
+		for _, t := range concrete {
+			pointer := !types.Implements(t.Type(), iface)
+			if pointer && !types.Implements(types.NewPointer(t.Type()), iface) {
+				continue
+			}
+
+			ifaceName := typeName{i.Pkg().Path(), i.Name()}
+			concreteName := typeName{t.Pkg().Path(), t.Name()}
+			interfaceImplementers[ifaceName] = append(interfaceImplementers[ifaceName], implementation{concreteName, t.Pkg().Name(), pointer})
+			typeInterfaces[concreteName] = append(typeInterfaces[concreteName], implementation{ifaceName, i.Pkg().Name(), pointer})
+		}
+	}
+
+	for _, list := range []map[typeName][]implementation{interfaceImplementers, typeInterfaces} {
+		for _, impls := range list {
+			sort.Slice(impls, func(i, j int) bool {
+				if impls[i].Pkg != impls[j].Pkg {
+					return vanityPath(impls[i].Pkg) < vanityPath(impls[j].Pkg)
+				}
+				return impls[i].Name < impls[j].Name
+			})
+		}
+	}
+	return nil
+}
+
+// implementersKey describes the implementers of the interfaces of a package
+// and the interfaces implemented by its types, which are listed on its page,
+// so that its cached pages are not reused when other packages change them.
+func implementersKey(pkg string) string {
+	var lines []string
+	for _, list := range []map[typeName][]implementation{interfaceImplementers, typeInterfaces} {
+		for name, impls := range list {
+			if name.Pkg != pkg {
+				continue
+			}
+			for _, impl := range impls {
+				lines = append(lines, fmt.Sprintf("%s %s %s %t", name.Name, impl.Pkg, impl.Name, impl.Pointer))
+			}
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// implementationList returns a list of types, each linked to its
+// documentation. Types declared in other packages are qualified by the name
+// of their package. Interfaces implemented by a type are listed when recv is
+// set to the name of the type, noting those implemented by pointers to it.
+func implementationList(impls []implementation, pkg string, basePath string, recv string) string {
+	var index string
+	if linkIndex {
+		index = "index.html"
+	}
+
+	var b strings.Builder
+	b.WriteString(`<ul>
+`)
+	for _, impl := range impls {
+		var href, name string
+		if impl.Pkg != pkg {
+			href = basePath + vanityPath(impl.Pkg) + "/" + index
+			name = impl.PkgName + "."
+		}
+		name += impl.Name
+
+		var note string
+		if impl.Pointer && recv != "" {
+			note = ` (as *` + html.EscapeString(recv) + `)`
+		} else if impl.Pointer {
+			name = "*" + name
+		}
+		b.WriteString(`<li><a href="` + href + `#` + html.EscapeString(impl.Name) + `" title="` + html.EscapeString(impl.Pkg+"."+impl.Name) + `">` + html.EscapeString(name) + `</a>` + note + `</li>
+`)
+	}
+	b.WriteString(`</ul>
+`)
+	return b.String()
+}
+
+// addImplementers adds an Implementers section to the declaration of each
+// interface of a package, listing the documented types implementing it, and
+// an Implements section to the declaration of each type, listing the
+// documented interfaces it implements.
+func addImplementers(doc *goquery.Document, pkg string, basePath string) {
+	if !implementers {
+		return
+	}
+
+	doc.Find("h2[id]").Each(func(_ int, heading *goquery.Selection) {
+		name := typeName{pkg, heading.AttrOr("id", "")}
+
+		var b strings.Builder
+		if impls := interfaceImplementers[name]; len(impls) > 0 {
+			b.WriteString(`<div class="pkg-implementers">
+<h4 id="` + html.EscapeString(name.Name) + `-implementers">Implementers</h4>
+` + implementationList(impls, pkg, basePath, "") + `</div>
+`)
+		}
+		if impls := typeInterfaces[name]; len(impls) > 0 {
+			b.WriteString(`<div class="pkg-implementers">
+<h4 id="` + html.EscapeString(name.Name) + `-implements">Implements</h4>
+` + implementationList(impls, pkg, basePath, name.Name) + `</div>
+`)
+		}
+		if b.Len() == 0 {
+			return
+		}
+
+		// The sections follow the declaration and doc comment of the type.
+		if decl := heading.NextUntil("h2, h3, div, details"); decl.Length() > 0 {
+			decl.Last().AfterHtml(b.String())
+		} else {
+			heading.AfterHtml(b.String())
+		}
+	})
+}
@@ -0,0 +1,287 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const importGraphPage = "imports.html"
+
+const importGraphCSS = `
+.import-graph { overflow: auto; margin: 1.25rem 0; }
+.import-graph rect { fill: var(--heading-background); stroke: var(--border); }
+.import-graph text { fill: var(--text); font-family: monospace; font-size: 12px; }
+.import-graph line { stroke: var(--text-muted); }
+.import-graph marker path { fill: var(--text-muted); }
+.import-graph a:hover rect, .import-graph a:focus rect { fill: var(--link); }
+.import-graph a:hover text, .import-graph a:focus text { fill: var(--background); }
+`
+
+// Dimensions of the import graph, in pixels.
+const (
+	importGraphCharWidth  = 7
+	importGraphPadding    = 8
+	importGraphNodeHeight = 24
+	importGraphNodeGap    = 16
+	importGraphLayerGap   = 64
+	importGraphMargin     = 8
+)
+
+var importGraph bool
+
+// importGraphNode is a package drawn on the import graph.
+type importGraphNode struct {
+	Pkg     string
+	Label   string
+	Imports []string
+	Layer   int
+	X, Y    int
+	Width   int
+}
+
+// loadPackageImports returns the documented packages each documented package
+// imports, by import path. Packages without source files are omitted.
+func loadPackageImports(ctx context.Context, pkgs []string) (map[string][]string, error) {
+	documented := make(map[string]bool)
+	for _, pkg := range pkgs {
+		documented[pkg] = true
+	}
+
+	imports := make(map[string][]string)
+	for _, pkg := range pkgs {
+		if _, ok := listFailures[pkg]; ok {
+			continue
+		}
+
+		dir := pkgPaths[pkg]
+		if dir == "" {
+			dir = getTmpDir()
+		}
+		lines, err := goListLines(ctx, dir, "-f", `{{ range .Imports }}{{ . }}
+{{ end }}`, pkg)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		} else if err != nil {
+			continue // This is expected for packages without source files
+		}
+
+		pkgImports := []string{}
+		for _, imported := range lines {
+			if imported != pkg && documented[imported] {
+				pkgImports = append(pkgImports, imported)
+			}
+		}
+		sort.Strings(pkgImports)
+		imports[pkg] = pkgImports
+	}
+	return imports, nil
+}
+
+// layoutImportGraph places each package in a layer above all packages it
+// imports, so that imports point down the graph. Packages in a layer are
+// ordered by the position of the packages they import to reduce crossings.
+func layoutImportGraph(pkgs []string, imports map[string][]string) ([]*importGraphNode, int, int) {
+	nodes := make(map[string]*importGraphNode)
+	for _, pkg := range pkgs {
+		nodes[pkg] = &importGraphNode{
+			Pkg:     pkg,
+			Label:   displayPath(vanityPath(pkg)),
+			Imports: imports[pkg],
+			Layer:   -1,
+		}
+	}
+
+	// Import cycles are not permitted, so the layer of each package is the
+	// length of the longest chain of imports starting from it.
+	var layerOf func(n *importGraphNode) int
+	layerOf = func(n *importGraphNode) int {
+		if n.Layer >= 0 {
+			return n.Layer
+		}
+		n.Layer = 0
+		for _, imported := range n.Imports {
+			if l := layerOf(nodes[imported]) + 1; l > n.Layer {
+				n.Layer = l
+			}
+		}
+		return n.Layer
+	}
+
+	var layers [][]*importGraphNode
+	for _, pkg := range pkgs {
+		n := nodes[pkg]
+		l := layerOf(n)
+		for len(layers) <= l {
+			layers = append(layers, nil)
+		}
+		layers[l] = append(layers[l], n)
+		n.Width = len(n.Label)*importGraphCharWidth + importGraphPadding*2
+	}
+
+	place := func(layer []*importGraphNode) int {
+		x := importGraphMargin
+		for _, n := range layer {
+			n.X = x
+			x += n.Width + importGraphNodeGap
+		}
+		return x - importGraphNodeGap + importGraphMargin
+	}
+
+	var width int
+	for i, layer := range layers {
+		if i > 0 {
+			center := func(n *importGraphNode) float64 {
+				if len(n.Imports) == 0 {
+					return float64(n.X)
+				}
+				var sum float64
+				for _, imported := range n.Imports {
+					m := nodes[imported]
+					sum += float64(m.X + m.Width/2)
+				}
+				return sum / float64(len(n.Imports))
+			}
+			for _, n := range layer {
+				n.X = int(center(n))
+			}
+			sort.SliceStable(layer, func(i, j int) bool {
+				return layer[i].X < layer[j].X
+			})
+		}
+		if w := place(layer); w > width {
+			width = w
+		}
+	}
+
+	// Layers are centered, with the packages importing the most at the top.
+	var all []*importGraphNode
+	for i, layer := range layers {
+		offset := (width - place(layer)) / 2
+		for _, n := range layer {
+			n.X += offset
+			n.Y = importGraphMargin + (len(layers)-1-i)*(importGraphNodeHeight+importGraphLayerGap)
+		}
+		all = append(all, layer...)
+	}
+	height := importGraphMargin*2 + len(layers)*importGraphNodeHeight + (len(layers)-1)*importGraphLayerGap
+	if len(layers) == 0 {
+		height = 0
+	}
+	return all, width, height
+}
+
+// writeImportGraph writes an SVG drawing of the imports between packages,
+// each package linking to its documentation.
+func writeImportGraph(buf *bytes.Buffer, nodes []*importGraphNode, width int, height int) {
+	var index string
+	if linkIndex {
+		index = "/index.html"
+	}
+
+	byPkg := make(map[string]*importGraphNode)
+	for _, n := range nodes {
+		byPkg[n.Pkg] = n
+	}
+
+	itoa := strconv.Itoa
+	buf.WriteString(`<div class="import-graph">
+<svg xmlns="http://www.w3.org/2000/svg" width="` + itoa(width) + `" height="` + itoa(height) + `" viewBox="0 0 ` + itoa(width) + ` ` + itoa(height) + `" role="img" aria-label="Import graph">
+<defs><marker id="import-arrow" viewBox="0 0 8 8" refX="8" refY="4" markerWidth="8" markerHeight="8" orient="auto"><path d="M0,0 L8,4 L0,8 z"/></marker></defs>
+`)
+	for _, n := range nodes {
+		for _, imported := range n.Imports {
+			m := byPkg[imported]
+			buf.WriteString(`<line x1="` + itoa(n.X+n.Width/2) + `" y1="` + itoa(n.Y+importGraphNodeHeight) + `" x2="` + itoa(m.X+m.Width/2) + `" y2="` + itoa(m.Y) + `" marker-end="url(#import-arrow)"/>
+`)
+		}
+	}
+	for _, n := range nodes {
+		title := n.Label
+		if len(n.Imports) > 0 {
+			labels := make([]string, len(n.Imports))
+			for i, imported := range n.Imports {
+				labels[i] = byPkg[imported].Label
+			}
+			title += " imports " + strings.Join(labels, ", ")
+		}
+		buf.WriteString(`<a href="` + html.EscapeString(vanityPath(n.Pkg)+index) + `"><title>` + html.EscapeString(title) + `</title><rect x="` + itoa(n.X) + `" y="` + itoa(n.Y) + `" width="` + itoa(n.Width) + `" height="` + itoa(importGraphNodeHeight) + `" rx="4"/><text x="` + itoa(n.X+importGraphPadding) + `" y="` + itoa(n.Y+importGraphNodeHeight/2+4) + `">` + html.EscapeString(n.Label) + `</text></a>
+`)
+	}
+	buf.WriteString(`</svg>
+</div>
+`)
+}
+
+// writeImportGraphPage writes a page drawing the imports between the
+// documented packages, followed by a list of the packages each package
+// imports.
+func writeImportGraphPage(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	imports, err := loadPackageImports(ctx, pkgs)
+	if err != nil {
+		return err
+	}
+
+	var graphPkgs []string
+	for _, pkg := range pkgs {
+		if _, ok := imports[pkg]; ok {
+			graphPkgs = append(graphPkgs, pkg)
+		}
+	}
+	sortByVanityPath(graphPkgs)
+
+	// Imports of packages which failed to load are not drawn.
+	for pkg, pkgImports := range imports {
+		filtered := pkgImports[:0]
+		for _, imported := range pkgImports {
+			if _, ok := imports[imported]; ok {
+				filtered = append(filtered, imported)
+			}
+		}
+		imports[pkg] = filtered
+	}
+	nodes, width, height := layoutImportGraph(graphPkgs, imports)
+
+	var index string
+	if linkIndex {
+		index = "/index.html"
+	}
+
+	buf.Reset()
+	buf.WriteString(sitePageHeader("Import graph - " + siteName))
+	buf.WriteString(`
+<h1>
+	Import graph
+</h1>
+<p>Imports between the documented packages. Each package is drawn above the packages it imports.</p>
+`)
+	writeImportGraph(buf, nodes, width, height)
+
+	buf.WriteString(`<h2 id="imports-list">Imports</h2>
+<table id="pkg-imports">
+<tr><th>Package</th><th>Imports</th></tr>
+`)
+	for _, pkg := range graphPkgs {
+		links := make([]string, len(imports[pkg]))
+		for i, imported := range imports[pkg] {
+			links[i] = `<a href="` + html.EscapeString(vanityPath(imported)+index) + `">` + html.EscapeString(displayPath(vanityPath(imported))) + `</a>`
+		}
+		importLinks := strings.Join(links, "<br>")
+		if importLinks == "" {
+			importLinks = "-"
+		}
+		buf.WriteString(`<tr><td><a href="` + html.EscapeString(vanityPath(pkg)+index) + `">` + html.EscapeString(displayPath(vanityPath(pkg))) + `</a></td><td>` + importLinks + `</td></tr>
+`)
+	}
+	buf.WriteString(`</table>
+<div id="footer">` + siteFooterText("") + `</div>
+</div>
+</div>
+` + searchTags("") + `</body>
+</html>
+`)
+	return writeFile(ctx, buf, "", importGraphPage)
+}
@@ -0,0 +1,128 @@
+package godocstatic
+
+import (
+	"context"
+	"html"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/mod/semver"
+)
+
+const importPanelCSS = `
+.import-panel { margin: 1.25rem 0; }
+.import-panel summary { margin-left: 0; }
+.import-panel pre { padding: 0.625rem; }
+`
+
+var (
+	importPanel     bool
+	importGOPRIVATE string
+	importGOPROXY   string
+)
+
+var (
+	importVersions     = make(map[string]string)
+	importVersionsLock sync.Mutex
+)
+
+// importVersion returns the version of the module containing a package to
+// pin in go get commands: the version of a vendored module or of a module in
+// the module cache, the highest semantic version tag of the checked out commit
+// of a module in a git repository, or else the commit itself. An empty string
+// is returned when the version is unknown.
+func importVersion(ctx context.Context, pkg string) string {
+	m := packageModule(pkg)
+	if m == nil {
+		return ""
+	}
+
+	importVersionsLock.Lock()
+	defer importVersionsLock.Unlock()
+
+	if v, ok := importVersions[m.Dir]; ok {
+		return v
+	}
+
+	var version string
+	if v, ok := vendoredVersions[m.Dir]; ok {
+		version = v
+	} else if at := strings.LastIndex(filepath.Base(m.Dir), "@"); at >= 0 {
+		version = filepath.Base(m.Dir)[at+1:]
+	} else if top := commandOutput(ctx, m.Dir, "git", "rev-parse", "--show-toplevel"); top != "" {
+		// Tags of modules in subdirectories are prefixed with the directory.
+		var prefix string
+		if rel, err := filepath.Rel(top, m.Dir); err == nil && rel != "." {
+			prefix = filepath.ToSlash(rel) + "/"
+		}
+
+		for _, tag := range strings.Fields(commandOutput(ctx, m.Dir, "git", "tag", "--points-at", "HEAD")) {
+			if !strings.HasPrefix(tag, prefix) {
+				continue
+			}
+			v := tag[len(prefix):]
+			if semver.IsValid(v) && (version == "" || semver.Compare(v, version) > 0) {
+				version = v
+			}
+		}
+		if version == "" {
+			version = commandOutput(ctx, m.Dir, "git", "rev-parse", "--short=12", "HEAD")
+		}
+	}
+	importVersions[m.Dir] = version
+	return version
+}
+
+// addImportPanel adds instructions for fetching and importing a package, or
+// installing a command, to its page.
+func addImportPanel(ctx context.Context, doc *goquery.Document, pkg string) {
+	if !importPanel || packageModule(pkg) == nil {
+		return
+	}
+
+	outPkg := vanityPath(pkg)
+	version := importVersion(ctx, pkg)
+	if version == "" {
+		version = "latest"
+	}
+
+	heading := doc.Find("#page h1").First()
+	command := strings.HasPrefix(strings.TrimSpace(heading.Text()), "Command ")
+
+	var b strings.Builder
+	b.WriteString(`<details class="import-panel" id="pkg-import">
+<summary>How to import</summary>
+`)
+	if importGOPRIVATE != "" || importGOPROXY != "" {
+		var setup []string
+		if importGOPRIVATE != "" {
+			setup = append(setup, "go env -w GOPRIVATE="+html.EscapeString(importGOPRIVATE))
+		}
+		if importGOPROXY != "" {
+			setup = append(setup, "go env -w GOPROXY="+html.EscapeString(importGOPROXY))
+		}
+		b.WriteString(`<p>Configure the go command to fetch this module:</p>
+<pre>` + strings.Join(setup, "\n") + `</pre>
+`)
+	}
+	if command {
+		b.WriteString(`<p>Install the command:</p>
+<pre>go install ` + html.EscapeString(outPkg+"@"+version) + `</pre>
+`)
+	} else {
+		b.WriteString(`<p>Add the module to your go.mod:</p>
+<pre>go get ` + html.EscapeString(outPkg+"@"+version) + `</pre>
+<p>Import the package:</p>
+<pre>import "` + html.EscapeString(outPkg) + `"</pre>
+`)
+	}
+	b.WriteString(`</details>`)
+
+	if overview := doc.Find("#pkg-overview").First(); overview.Length() > 0 {
+		overview.BeforeHtml(b.String())
+	} else {
+		heading.AfterHtml(b.String())
+	}
+}
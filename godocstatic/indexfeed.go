@@ -0,0 +1,96 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"time"
+)
+
+const indexFeedFile = "index.json"
+
+// indexFeedEntry describes a package listed on the package index.
+type indexFeedEntry struct {
+	Package  string `json:"package"`
+	Synopsis string `json:"synopsis"`
+	Command  bool   `json:"command,omitempty"`
+	Version  string `json:"version,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Updated  string `json:"updated,omitempty"`
+}
+
+// packageUpdated returns the time the source of a package was last changed,
+// according to git, or to the files in its directory when it is not checked
+// out.
+func packageUpdated(ctx context.Context, pkg string) string {
+	dir := pkgDirs[pkg]
+	if dir == "" {
+		return ""
+	}
+
+	if committed := commandOutput(ctx, dir, "git", "log", "-1", "--format=%cI", "--", "."); committed != "" {
+		t, err := time.Parse(time.RFC3339, committed)
+		if err == nil {
+			return t.UTC().Format(time.RFC3339)
+		}
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	var modified time.Time
+	for _, info := range files {
+		if info.Mode().IsRegular() && info.ModTime().After(modified) {
+			modified = info.ModTime()
+		}
+	}
+	if modified.IsZero() {
+		return ""
+	}
+	return modified.UTC().Format(time.RFC3339)
+}
+
+// writeIndexFeed writes index.json, listing the packages of the package index
+// with their synopsis, version, URL and the time they were last updated, so
+// that the index may be embedded elsewhere without parsing its pages. URLs are
+// relative to the root of the site unless -base-url is supplied.
+func writeIndexFeed(ctx context.Context, buf *bytes.Buffer, rows []indexRow) error {
+	var index string
+	if linkIndex {
+		index = "/index.html"
+	}
+
+	entries := []*indexFeedEntry{}
+	for _, row := range rows {
+		if !row.Package && !row.Link {
+			continue
+		}
+
+		entry := &indexFeedEntry{
+			Package:  row.Pkg,
+			Synopsis: row.Synopsis,
+			Command:  row.Command,
+			Version:  packageProvenance(ctx, row.Pkg).Version,
+			Updated:  packageUpdated(ctx, row.Pkg),
+		}
+		if row.Link {
+			entry.URL = pageURL(row.OutPkg + "/index.html")
+			if entry.URL == "" {
+				entry.URL = row.OutPkg + index
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	buf.Reset()
+	buf.Write(data)
+	buf.WriteString("\n")
+	return writeFile(ctx, buf, "", indexFeedFile)
+}
@@ -0,0 +1,160 @@
+package godocstatic
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+const (
+	initConfigFile      = "godoc-static.conf"
+	initDescriptionFile = "godoc-static-description.md"
+	initFooterFile      = "godoc-static-footer.md"
+	initScriptFile      = "godoc-static.sh"
+)
+
+var (
+	initDir         string
+	initSiteName    string
+	initDestination string
+	initYes         bool
+	initForce       bool
+)
+
+func registerInitFlags(flags *flag.FlagSet) {
+	flags.StringVar(&initDir, "dir", ".", "path to module to write starter files to")
+	flags.StringVar(&initSiteName, "site-name", "", "site name (default module path)")
+	flags.StringVar(&initDestination, "destination", "docs", "path to write site HTML, relative to the module")
+	flags.BoolVar(&initYes, "yes", false, "do not prompt for the site name and destination")
+	flags.BoolVar(&initForce, "force", false, "overwrite existing files")
+	registerCommonFlags(flags)
+}
+
+// isTerminal returns whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// prompt asks for a value, returning def when no value is entered.
+func prompt(r *bufio.Reader, label string, def string) (string, error) {
+	fmt.Printf("%s [%s]: ", label, def)
+
+	line, err := r.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return def, nil
+	}
+	return line, nil
+}
+
+// initFiles returns the starter files written by the init command.
+func initFiles(modulePath string, siteName string, destination string) map[string]string {
+	return map[string]string{
+		initConfigFile: `# godoc-static configuration
+#
+# Each line sets one option. Run godoc-static help generate to list options.
+
+site-name = ` + siteName + `
+site-description-file = ` + initDescriptionFile + `
+site-footer-file = ` + initFooterFile + `
+destination = ` + destination + `
+`,
+		initDescriptionFile: `Documentation of [` + modulePath + `](https://` + modulePath + `).
+`,
+		initFooterFile: `Documentation of ` + modulePath + `.
+`,
+		initScriptFile: `#!/bin/sh
+# Generate the documentation of ` + modulePath + ` in ` + destination + `.
+# Options supplied to this script are passed to godoc-static.
+set -e
+
+cd "$(dirname "$0")"
+
+if ! command -v godoc-static >/dev/null 2>&1; then
+	go install code.rocketnine.space/tslocum/godoc-static@latest
+	PATH="$(go env GOPATH)/bin:$PATH"
+fi
+
+mkdir -p "` + destination + `"
+godoc-static generate -config ` + initConfigFile + ` "$@" "$(pwd)"
+`,
+	}
+}
+
+func runInit(ctx context.Context, args []string) error {
+	dir, err := filepath.Abs(initDir)
+	if err != nil {
+		return err
+	}
+
+	modFilePath := filepath.Join(dir, "go.mod")
+	modFileData, err := ioutil.ReadFile(modFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %s", modFilePath, err)
+	}
+	modulePath := modfile.ModulePath(modFileData)
+	if modulePath == "" {
+		return fmt.Errorf("failed to read module path from %s", modFilePath)
+	}
+
+	siteName := initSiteName
+	if siteName == "" {
+		siteName = modulePath
+	}
+	destination := initDestination
+
+	if !initYes && isTerminal(os.Stdin) {
+		r := bufio.NewReader(os.Stdin)
+		siteName, err = prompt(r, "Site name", siteName)
+		if err != nil {
+			return err
+		}
+		destination, err = prompt(r, "Destination", destination)
+		if err != nil {
+			return err
+		}
+	}
+	if destination == "" {
+		return errors.New("--destination must be set")
+	}
+
+	files := initFiles(modulePath, siteName, destination)
+	if !initForce {
+		for name := range files {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return fmt.Errorf("failed to write %s: file exists (use --force to overwrite)", name)
+			}
+		}
+	}
+
+	for _, name := range []string{initConfigFile, initDescriptionFile, initFooterFile, initScriptFile} {
+		p := filepath.Join(dir, name)
+		err = ioutil.WriteFile(p, []byte(files[name]), 0644)
+		if err == nil && name == initScriptFile {
+			err = os.Chmod(p, 0755)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %s", p, err)
+		}
+		if !quiet {
+			logger.Printf("Wrote %s", p)
+		}
+	}
+
+	if !quiet {
+		logger.Printf("Run ./%s to generate documentation.", initScriptFile)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// moduleLandingSource is the path of the markdown file introducing a module,
+// relative to the directory of the module.
+const moduleLandingSource = "docs/index.md"
+
+// moduleLandingPage is the name of the landing page written to the directory
+// of each module which supplies moduleLandingSource.
+const moduleLandingPage = "module.html"
+
+// moduleLandingPages lists the path of the landing page of each module which
+// has one, by module path.
+var moduleLandingPages = make(map[string]string)
+
+// markdownRenderer returns the renderer of site descriptions, footers and
+// module landing pages.
+func markdownRenderer() goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithRendererOptions(
+			gmhtml.WithUnsafe(),
+		),
+		goldmark.WithExtensions(
+			extension.NewLinkify(),
+		),
+	)
+}
+
+// writeModuleLandingPages writes a landing page for each documented module
+// which supplies moduleLandingSource, introducing the module and listing its
+// packages.
+func writeModuleLandingPages(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	moduleLandingPages = make(map[string]string)
+
+	modulePkgs := make(map[string][]string)
+	for _, pkg := range pkgs {
+		if m := packageModule(pkg); m != nil {
+			modulePkgs[m.Path] = append(modulePkgs[m.Path], pkg)
+		}
+	}
+
+	var index string
+	if linkIndex {
+		index = "/index.html"
+	}
+
+	for _, name := range moduleNames() {
+		if len(modulePkgs[name]) == 0 {
+			continue
+		}
+
+		sourcePath := filepath.Join(modules[name].Dir, filepath.FromSlash(moduleLandingSource))
+		source, err := ioutil.ReadFile(sourcePath)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to read %s: %s", sourcePath, err)
+		}
+
+		var content bytes.Buffer
+		err = markdownRenderer().Convert(source, &content)
+		if err != nil {
+			return fmt.Errorf("failed to render %s: %s", sourcePath, err)
+		}
+
+		outDir := vanityPath(name)
+		basePath := relativeBasePath(outDir)
+
+		body, err := docImagesHTML(ctx, content.Bytes(), sourcePath, outDir)
+		if err != nil {
+			return err
+		}
+
+		buf.Reset()
+		buf.WriteString(pageHeader("Module "+html.EscapeString(displayPath(outDir))+" - "+siteName, basePath))
+		buf.WriteString(`<div class="module-landing">
+`)
+		buf.Write(body)
+		buf.WriteString(`</div>
+<h2 id="module-packages">Packages</h2>
+<ul>
+`)
+		for _, pkg := range modulePkgs[name] {
+			outPkg := vanityPath(pkg)
+			buf.WriteString(`<li><a href="` + basePath + outPkg + index + `">` + html.EscapeString(displayPath(outPkg)) + `</a></li>
+`)
+		}
+		buf.WriteString(`</ul>
+`)
+		if _, ok := moduleInfoPages[name]; ok {
+			buf.WriteString(`<p><a href="` + moduleInfoPage + `">go.mod</a></p>
+`)
+		}
+		if _, ok := moduleDependencyPages[name]; ok {
+			buf.WriteString(`<p><a href="` + moduleDependenciesPage + `">Dependencies</a></p>
+`)
+		}
+		if _, ok := moduleVendorPages[name]; ok {
+			buf.WriteString(`<p><a href="` + moduleVendorPage + `">Vendored modules</a></p>
+`)
+		}
+		buf.WriteString(`<div id="footer">` + siteFooterText(basePath) + `</div>
+</div>
+</div>
+` + searchTags(basePath) + `</body>
+</html>
+`)
+
+		err = os.MkdirAll(filepath.Join(siteDestination, outDir), 0755)
+		if err != nil {
+			return fmt.Errorf("failed to make directory %s: %s", outDir, err)
+		}
+
+		err = writeFile(ctx, buf, outDir, moduleLandingPage)
+		if err != nil {
+			return err
+		}
+		moduleLandingPages[name] = path.Join(outDir, moduleLandingPage)
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const licensesPage = "licenses.html"
+
+var (
+	licenses           bool
+	dependencyLicenses bool
+)
+
+// licenseFileName matches the names of files containing the license of a
+// module, such as LICENSE, LICENSE.md and COPYING.
+var licenseFileName = regexp.MustCompile(`(?i)^(licen[cs]e|copying)(\.(md|txt|markdown))?$`)
+
+// licenseTypes lists phrases identifying common licenses, most specific
+// first. All phrases of a license must be present.
+var licenseTypes = []struct {
+	ID      string
+	Phrases []string
+}{
+	{"AGPL-3.0", []string{"GNU AFFERO GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"LGPL-3.0", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"LGPL-2.1", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 2.1"}},
+	{"GPL-3.0", []string{"GNU GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"GPL-2.0", []string{"GNU GENERAL PUBLIC LICENSE", "Version 2"}},
+	{"MPL-2.0", []string{"Mozilla Public License", "2.0"}},
+	{"Apache-2.0", []string{"Apache License", "Version 2.0"}},
+	{"BSD-3-Clause", []string{"Redistribution and use in source and binary forms", "endorse or promote products derived"}},
+	{"BSD-2-Clause", []string{"Redistribution and use in source and binary forms"}},
+	{"MIT", []string{"Permission is hereby granted, free of charge"}},
+	{"ISC", []string{"Permission to use, copy, modify, and", "distribute this software for any purpose"}},
+	{"Unlicense", []string{"This is free and unencumbered software released into the public domain"}},
+	{"CC0-1.0", []string{"CC0 1.0 Universal"}},
+}
+
+// classifyLicense returns the SPDX identifier of a license, or Unknown when
+// it is not recognized.
+func classifyLicense(text string) string {
+	normalized := strings.Join(strings.Fields(text), " ")
+	for _, t := range licenseTypes {
+		matched := true
+		for _, phrase := range t.Phrases {
+			if !strings.Contains(normalized, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return t.ID
+		}
+	}
+	return "Unknown"
+}
+
+// moduleLicense is a license file in the directory of a module.
+type moduleLicense struct {
+	File string
+	Type string
+	Text string
+}
+
+var (
+	licenseCache     = make(map[string][]moduleLicense)
+	licenseCacheLock sync.Mutex
+)
+
+// findLicenses returns the license files in a directory.
+func findLicenses(dir string) []moduleLicense {
+	licenseCacheLock.Lock()
+	defer licenseCacheLock.Unlock()
+
+	if found, ok := licenseCache[dir]; ok {
+		return found
+	}
+
+	var found []moduleLicense
+	entries, err := ioutil.ReadDir(dir)
+	if err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() || !licenseFileName.MatchString(entry.Name()) {
+				continue
+			}
+
+			data, err := ioutil.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				continue
+			}
+			text := string(data)
+			found = append(found, moduleLicense{File: entry.Name(), Type: classifyLicense(text), Text: text})
+		}
+	}
+	licenseCache[dir] = found
+	return found
+}
+
+// licenseAnchor returns the anchor of a module on the licenses page.
+func licenseAnchor(modulePath string) string {
+	return "license-" + strings.NewReplacer("/", "-", "@", "-").Replace(modulePath)
+}
+
+// licenseNames returns the types of the licenses of a module, separated by
+// commas, or Unknown when it has none.
+func licenseNames(found []moduleLicense) string {
+	var types []string
+	for _, l := range found {
+		types = append(types, l.Type)
+	}
+	types = uniqueStrings(types)
+	if len(types) == 0 {
+		return "Unknown"
+	}
+	return strings.Join(types, ", ")
+}
+
+// addLicenseBadge labels the heading of the page of a package with the
+// licenses of its module, linked to their text on the licenses page.
+func addLicenseBadge(doc *goquery.Document, pkg string, basePath string) {
+	if !licenses {
+		return
+	}
+
+	m := packageModule(pkg)
+	if m == nil {
+		return
+	}
+
+	found := findLicenses(m.Dir)
+	doc.Find("#page h1").First().AppendHtml(` <a class="pkg-badge pkg-badge-license" href="` + basePath + licensesPage + `#` + html.EscapeString(licenseAnchor(m.Path)) + `" title="License">` + html.EscapeString(licenseNames(found)) + `</a>`)
+}
+
+// licensedModule is a module listed on the licenses page.
+type licensedModule struct {
+	Path     string
+	Version  string
+	Dir      string
+	Licenses []moduleLicense
+}
+
+// dependencyModules lists the dependencies of the documented modules which
+// are in the module cache and are not documented themselves.
+func dependencyModules(ctx context.Context, documented map[string]bool) ([]licensedModule, error) {
+	found := make(map[string]licensedModule)
+	for _, name := range moduleNames() {
+		if !documented[name] {
+			continue
+		}
+
+		lines, err := goListLines(ctx, modules[name].Dir, "-m", "-f", `{{ if not .Main }}{{ .Path }} {{ .Version }} {{ .Dir }}{{ end }}`, "all")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list dependencies of %s: %s", name, err)
+		}
+		for _, line := range lines {
+			fields := strings.SplitN(line, " ", 3)
+			if len(fields) != 3 || documented[fields[0]] {
+				continue
+			}
+			found[fields[0]+"@"+fields[1]] = licensedModule{Path: fields[0], Version: fields[1], Dir: fields[2]}
+		}
+	}
+
+	var deps []licensedModule
+	for _, d := range found {
+		if d.Dir != "" {
+			d.Licenses = findLicenses(d.Dir)
+		}
+		deps = append(deps, d)
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Path != deps[j].Path {
+			return deps[i].Path < deps[j].Path
+		}
+		return deps[i].Version < deps[j].Version
+	})
+	return deps, nil
+}
+
+// writeLicenseTable writes a table summarizing the licenses of modules
+// followed by the text of each license. Modules are identified by version as
+// well when several versions may be listed.
+func writeLicenseTable(buf *bytes.Buffer, mods []licensedModule, versioned bool) {
+	anchor := func(m licensedModule) string {
+		if versioned {
+			return licenseAnchor(m.Path + "@" + m.Version)
+		}
+		return licenseAnchor(m.Path)
+	}
+
+	buf.WriteString(`<table>
+<tr><th>Module</th><th>Version</th><th>License</th></tr>
+`)
+	for _, m := range mods {
+		license := html.EscapeString(licenseNames(m.Licenses))
+		if m.Dir == "" {
+			license = "Not in module cache"
+		}
+		buf.WriteString(`<tr><td><a href="#` + html.EscapeString(anchor(m)) + `">` + html.EscapeString(m.Path) + `</a></td><td>` + html.EscapeString(m.Version) + `</td><td>` + license + `</td></tr>
+`)
+	}
+	buf.WriteString(`</table>
+`)
+
+	for _, m := range mods {
+		name := m.Path
+		if versioned {
+			name += " " + m.Version
+		}
+		buf.WriteString(`<h3 id="` + html.EscapeString(anchor(m)) + `">` + html.EscapeString(name) + `</h3>
+`)
+		if len(m.Licenses) == 0 {
+			buf.WriteString(`<p>No license file was found.</p>
+`)
+		}
+		for _, l := range m.Licenses {
+			buf.WriteString(`<p>` + html.EscapeString(l.File) + ` (` + html.EscapeString(l.Type) + `)</p>
+<pre>` + html.EscapeString(l.Text) + `</pre>
+`)
+		}
+	}
+}
+
+// writeLicensesPage writes a page listing the licenses of the documented
+// modules, and with -dependency-licenses the licenses of their dependencies,
+// including the text of each license.
+func writeLicensesPage(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	documented := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if m := packageModule(pkg); m != nil {
+			documented[m.Path] = true
+		}
+	}
+
+	var mods []licensedModule
+	for _, name := range moduleNames() {
+		if documented[name] {
+			m := modules[name]
+			mods = append(mods, licensedModule{Path: m.Path, Version: importVersion(ctx, m.Path), Dir: m.Dir, Licenses: findLicenses(m.Dir)})
+		}
+	}
+
+	var deps []licensedModule
+	if dependencyLicenses {
+		var err error
+		deps, err = dependencyModules(ctx, documented)
+		if err != nil {
+			return err
+		}
+	}
+
+	buf.Reset()
+	buf.WriteString(sitePageHeader("Licenses - " + siteName))
+	buf.WriteString(`
+<h1>
+	Licenses
+</h1>
+<div id="pkg-licenses">
+<h2 id="licenses-modules">Modules</h2>
+`)
+	writeLicenseTable(buf, mods, false)
+	if dependencyLicenses {
+		buf.WriteString(`<h2 id="licenses-dependencies">Dependencies</h2>
+`)
+		if len(deps) == 0 {
+			buf.WriteString(`<p>The modules have no dependencies.</p>
+`)
+		} else {
+			writeLicenseTable(buf, deps, true)
+		}
+	}
+
+	buf.WriteString(`</div>
+<div id="footer">` + siteFooterText("") + `</div>
+</div>
+</div>
+` + searchTags("") + `</body>
+</html>
+`)
+	return writeFile(ctx, buf, "", licensesPage)
+}
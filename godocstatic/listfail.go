@@ -0,0 +1,98 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	gohtml "golang.org/x/net/html"
+)
+
+// listFailures maps packages which could not be listed by go list to the
+// error output of go list.
+var listFailures = make(map[string]string)
+
+// writeListFailurePage writes a stub page explaining why the documentation
+// of a package could not be generated.
+func writeListFailurePage(ctx context.Context, buf *bytes.Buffer, pkg string) error {
+	outPkg := vanityPath(pkg)
+
+	page := nativePage(path.Base(pkg), `<h1>Package `+html.EscapeString(path.Base(outPkg))+`</h1>
+<div class="module-warning">
+<p><strong>Documentation unavailable:</strong> package `+html.EscapeString(outPkg)+` could not be listed by <code>go list</code>.</p>
+<pre>`+html.EscapeString(listFailures[pkg])+`</pre>
+</div>`)
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return fmt.Errorf("failed to parse page of %s: %s", pkg, err)
+	}
+
+	doc.Find("title").First().SetHtml(fmt.Sprintf("%s - %s", pageTitle(pkg), siteName))
+
+	updatePage(doc, path.Join(outPkg, "index.html"), relativeBasePath(outPkg), siteName)
+
+	err = transformPage(ctx, path.Join(outPkg, "index.html"), doc)
+	if err != nil {
+		return err
+	}
+
+	localPkgPath := filepath.Join(siteDestination, outPkg)
+
+	err = os.MkdirAll(localPkgPath, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to make directory %s: %s", localPkgPath, err)
+	}
+
+	buf.Reset()
+	err = gohtml.Render(buf, doc.Nodes[0])
+	if err != nil {
+		return fmt.Errorf("failed to render HTML: %s", err)
+	}
+	return writeFile(ctx, buf, outPkg, "index.html")
+}
+
+// logListFailures reports the packages which could not be listed.
+func logListFailures() {
+	if len(listFailures) == 0 {
+		return
+	}
+
+	var pkgs []string
+	for pkg := range listFailures {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+
+	logger.Printf("Failed to list %d packages, documentation is unavailable for:", len(pkgs))
+	var hint string
+	for _, pkg := range pkgs {
+		message := strings.TrimSpace(listFailures[pkg])
+		if hint == "" {
+			hint = privateModuleHint(message)
+		}
+		if newline := strings.IndexRune(message, '\n'); newline >= 0 {
+			message = message[:newline]
+		}
+		logger.Printf("  %s: %s", vanityPath(pkg), message)
+	}
+	if hint != "" {
+		logger.Printf("Note: %s.", hint)
+	}
+}
+
+// listFailureBadge returns a label for the index row of a package which could
+// not be listed.
+func listFailureBadge(pkg string) string {
+	if _, ok := listFailures[pkg]; !ok {
+		return ""
+	}
+	return ` <span class="pkg-badge pkg-badge-unavailable" title="Documentation unavailable: go list failed">unavailable</span>`
+}
@@ -0,0 +1,122 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/modfile"
+)
+
+// listedPackage is a package found by listing a supplied package or path.
+type listedPackage struct {
+	Pkg string
+	Dir string
+}
+
+// listedArgument is the result of listing a supplied package or path.
+type listedArgument struct {
+	Pkg          string
+	Dir          string
+	SuppliedPath bool
+	ModFile      *modfile.File
+	Packages     []listedPackage
+	Failure      string
+}
+
+// listArgument lists the packages of a supplied package or path without
+// modifying any shared state, so that arguments may be listed concurrently.
+func listArgument(ctx context.Context, buf *bytes.Buffer, pkg string) (*listedArgument, error) {
+	a := &listedArgument{Pkg: pkg}
+
+	if _, err := os.Stat(pkg); !os.IsNotExist(err) {
+		a.Dir = pkg
+
+		modFileData, err := ioutil.ReadFile(filepath.Join(a.Dir, "go.mod"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mod file for %s: %s", pkg, err)
+		}
+
+		a.ModFile, err = modfile.Parse(filepath.Join(a.Dir, "go.mod"), modFileData, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse mod file for %s: %s", pkg, err)
+		}
+
+		a.Pkg = a.ModFile.Module.Mod.Path
+		a.SuppliedPath = true
+	} else {
+		srcDir := filepath.Join(goPath, "src", filepath.FromSlash(pkg))
+		if _, err := os.Stat(srcDir); !os.IsNotExist(err) {
+			a.Dir = srcDir
+		}
+	}
+
+	buf.Reset()
+
+	search := "./..."
+	if a.Dir == "" {
+		search = a.Pkg
+	}
+
+	cmd := exec.CommandContext(ctx, "go", "list", "-find", "-f", `{{ .ImportPath }} {{ .Dir }}`, search)
+	cmd.Env = godocEnv
+	if a.Dir == "" {
+		cmd.Dir = getTmpDir()
+	} else {
+		cmd.Dir = a.Dir
+	}
+	cmd.Stdout = buf
+	cmd.Stderr = buf
+	setDeathSignal(cmd)
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	} else if err != nil {
+		a.Failure = strings.TrimSpace(buf.String())
+		if a.Failure == "" {
+			a.Failure = err.Error()
+		}
+		if hint := privateModuleHint(a.Failure); hint != "" {
+			a.Failure += "\n\nNote: " + hint + "."
+		}
+		return a, nil
+	}
+
+	for _, line := range strings.Split(buf.String(), "\n") {
+		firstSpace := strings.Index(line, " ")
+		if firstSpace <= 0 {
+			continue
+		}
+		a.Packages = append(a.Packages, listedPackage{Pkg: line[:firstSpace], Dir: line[firstSpace+1:]})
+	}
+	return a, nil
+}
+
+// listArguments lists the packages of each supplied package or path using
+// up to -workers goroutines.
+func listArguments(ctx context.Context, pkgs []string) (map[string]*listedArgument, error) {
+	var (
+		listed     = make(map[string]*listedArgument)
+		listedLock sync.Mutex
+	)
+	err := scrapePackages(ctx, uniqueStrings(pkgs), func(ctx context.Context, buf *bytes.Buffer, pkg string) error {
+		a, err := listArgument(ctx, buf, pkg)
+		if err != nil {
+			return err
+		}
+
+		listedLock.Lock()
+		defer listedLock.Unlock()
+
+		listed[pkg] = a
+		return nil
+	})
+	return listed, err
+}
@@ -0,0 +1,144 @@
+package godocstatic
+
+import (
+	"encoding/json"
+	"go/doc"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// pageURL returns the absolute URL of a page written to the site, or an
+// empty string when the URL the site is published at is unknown.
+func pageURL(page string) string {
+	if baseURL == "" {
+		return ""
+	}
+
+	if !linkIndex && (page == "index.html" || strings.HasSuffix(page, "/index.html")) {
+		page = strings.TrimSuffix(page, "index.html")
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/" + page
+}
+
+// metaTag returns a meta element with the supplied attributes.
+func metaTag(key string, name string, content string) *html.Node {
+	return &html.Node{
+		Type:     html.ElementNode,
+		DataAtom: atom.Meta,
+		Data:     "meta",
+		Attr: []html.Attribute{
+			{Key: key, Val: name},
+			{Key: "content", Val: content},
+		},
+	}
+}
+
+// addSocialMeta adds OpenGraph and Twitter card meta tags to a page, so that
+// links to it are previewed with its title and the synopsis of its package.
+func addSocialMeta(d *goquery.Document, page string) {
+	title := strings.TrimSuffix(strings.TrimSpace(d.Find("title").First().Text()), " - "+siteName)
+	synopsis := doc.Synopsis(d.Find("#pkg-overview p").First().Text())
+
+	head := d.Find("head").First()
+	if synopsis != "" {
+		head.AppendNodes(metaTag("name", "description", synopsis))
+	}
+	head.AppendNodes(
+		metaTag("property", "og:type", "website"),
+		metaTag("property", "og:site_name", siteName),
+		metaTag("property", "og:title", title),
+	)
+	if synopsis != "" {
+		head.AppendNodes(metaTag("property", "og:description", synopsis))
+	}
+	if u := pageURL(page); u != "" {
+		head.AppendNodes(metaTag("property", "og:url", u))
+	}
+	head.AppendNodes(
+		metaTag("name", "twitter:card", "summary"),
+		metaTag("name", "twitter:title", title),
+	)
+	if synopsis != "" {
+		head.AppendNodes(metaTag("name", "twitter:description", synopsis))
+	}
+}
+
+// repositoryHosts lists hosts whose repositories are named by the first two
+// elements of the path of the packages they contain.
+var repositoryHosts = []string{"bitbucket.org", "code.rocketnine.space", "git.sr.ht", "github.com", "gitlab.com"}
+
+// repositoryURL returns the URL of the repository containing a package.
+func repositoryURL(pkg string) string {
+	root := pkg
+	if m := packageModule(pkg); m != nil {
+		root = m.Path
+	}
+
+	elements := strings.Split(root, "/")
+	if !strings.ContainsRune(elements[0], '.') {
+		return "https://go.googlesource.com/go"
+	}
+	for _, host := range repositoryHosts {
+		if elements[0] == host && len(elements) > 3 {
+			root = strings.Join(elements[:3], "/")
+			break
+		}
+	}
+	return "https://" + root
+}
+
+// addStructuredData adds a Schema.org SoftwareSourceCode description of a
+// package to its page as JSON-LD, for presentation in search results. It is
+// omitted along with scripts when --no-js is set.
+func addStructuredData(d *goquery.Document, pkg string, page string) error {
+	if noJS {
+		return nil
+	}
+
+	type language struct {
+		Type string `json:"@type"`
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+	type sourceCode struct {
+		Context             string   `json:"@context"`
+		Type                string   `json:"@type"`
+		Name                string   `json:"name"`
+		Description         string   `json:"description,omitempty"`
+		URL                 string   `json:"url,omitempty"`
+		CodeRepository      string   `json:"codeRepository"`
+		ProgrammingLanguage language `json:"programmingLanguage"`
+	}
+
+	data, err := json.Marshal(sourceCode{
+		Context:        "https://schema.org",
+		Type:           "SoftwareSourceCode",
+		Name:           displayPath(vanityPath(pkg)),
+		Description:    doc.Synopsis(d.Find("#pkg-overview p").First().Text()),
+		URL:            pageURL(page),
+		CodeRepository: repositoryURL(pkg),
+		ProgrammingLanguage: language{
+			Type: "ComputerLanguage",
+			Name: "Go",
+			URL:  "https://go.dev",
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	d.Find("head").First().AppendNodes(&html.Node{
+		Type:     html.ElementNode,
+		DataAtom: atom.Script,
+		Data:     "script",
+		Attr:     []html.Attribute{{Key: "type", Val: "application/ld+json"}},
+		FirstChild: &html.Node{
+			Type: html.TextNode,
+			Data: string(data),
+		},
+	})
+	return nil
+}
@@ -0,0 +1,136 @@
+package godocstatic
+
+import (
+	"html"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/mod/modfile"
+)
+
+type moduleInfo struct {
+	Path string
+	Dir  string
+	File *modfile.File
+}
+
+var modules = make(map[string]*moduleInfo)
+
+func addModule(dir string, modFile *modfile.File) {
+	if modFile.Module == nil {
+		return
+	}
+	modules[modFile.Module.Mod.Path] = &moduleInfo{
+		Path: modFile.Module.Mod.Path,
+		Dir:  dir,
+		File: modFile,
+	}
+}
+
+// loadModule parses the go.mod file in dir, when present.
+func loadModule(dir string) {
+	modFilePath := filepath.Join(dir, "go.mod")
+	modFileData, err := ioutil.ReadFile(modFilePath)
+	if err != nil {
+		return
+	}
+
+	modFile, err := modfile.Parse(modFilePath, modFileData, nil)
+	if err != nil {
+		return
+	}
+	addModule(dir, modFile)
+}
+
+// packageModule returns the module containing a package, or nil when the
+// package is not part of a known module.
+func packageModule(pkg string) *moduleInfo {
+	var m *moduleInfo
+	for modulePath, info := range modules {
+		if (pkg == modulePath || strings.HasPrefix(pkg, modulePath+"/")) && (m == nil || len(modulePath) > len(m.Path)) {
+			m = info
+		}
+	}
+	return m
+}
+
+func moduleNames() []string {
+	var names []string
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func formatRetraction(r *modfile.Retract) string {
+	if r.Low == r.High {
+		return r.Low
+	}
+	return "[" + r.Low + ", " + r.High + "]"
+}
+
+// moduleWarnings returns the deprecation message and retracted versions of
+// the module containing a package, formatted as HTML.
+func moduleWarnings(pkg string) string {
+	m := packageModule(pkg)
+	if m == nil {
+		return ""
+	}
+
+	var warnings string
+	if m.File.Module.Deprecated != "" {
+		warnings += `<p><strong>Deprecated:</strong> module ` + html.EscapeString(m.Path) + ` is deprecated. ` + html.EscapeString(m.File.Module.Deprecated) + `</p>`
+	}
+
+	if len(m.File.Retract) > 0 {
+		warnings += `<p><strong>Retracted versions:</strong> the following versions of module ` + html.EscapeString(m.Path) + ` have been retracted and should not be used.</p><ul>`
+		for _, r := range m.File.Retract {
+			warnings += `<li><code>` + html.EscapeString(formatRetraction(r)) + `</code>`
+			if r.Rationale != "" {
+				warnings += ` - ` + html.EscapeString(r.Rationale)
+			}
+			warnings += `</li>`
+		}
+		warnings += `</ul>`
+	}
+
+	if warnings == "" {
+		return ""
+	}
+	return `<div class="module-warning">` + warnings + `</div>`
+}
+
+// addModuleWarnings displays the deprecation and retraction warnings of the
+// module containing a package below the page heading.
+func addModuleWarnings(doc *goquery.Document, pkg string) {
+	warnings := moduleWarnings(pkg)
+	if warnings != "" {
+		doc.Find("#page h1").First().AfterHtml(warnings)
+	}
+}
+
+// moduleBadges returns labels for the index row of a package which is part
+// of a deprecated module or a module with retracted versions.
+func moduleBadges(pkg string) string {
+	m := packageModule(pkg)
+	if m == nil || m.Path != pkg {
+		return ""
+	}
+
+	var badges string
+	if m.File.Module.Deprecated != "" {
+		badges += ` <span class="pkg-badge pkg-badge-deprecated" title="` + html.EscapeString(m.File.Module.Deprecated) + `">deprecated</span>`
+	}
+	if len(m.File.Retract) > 0 {
+		var retracted []string
+		for _, r := range m.File.Retract {
+			retracted = append(retracted, formatRetraction(r))
+		}
+		badges += ` <span class="pkg-badge pkg-badge-retracted" title="Retracted: ` + html.EscapeString(strings.Join(retracted, ", ")) + `">retractions</span>`
+	}
+	return badges
+}
@@ -0,0 +1,183 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// moduleInfoPage is the name of the page describing the go.mod file of a
+// module, written to the directory of each documented module.
+const moduleInfoPage = "go.mod.html"
+
+var goModInfo bool
+
+// moduleInfoPages lists the path of the module information page of each
+// module which has one, by module path.
+var moduleInfoPages = make(map[string]string)
+
+// moduleToolchain returns the toolchain directive of a go.mod file, or an
+// empty string when it has none.
+func moduleToolchain(f *modfile.File) string {
+	if f.Syntax == nil {
+		return ""
+	}
+	for _, stmt := range f.Syntax.Stmt {
+		if line, ok := stmt.(*modfile.Line); ok && len(line.Token) == 2 && line.Token[0] == "toolchain" {
+			return line.Token[1]
+		}
+	}
+	return ""
+}
+
+// formatModuleVersion returns a module identified as path@version, or by its
+// path when the version is unset, as for replacements by directories.
+func formatModuleVersion(modulePath string, version string) string {
+	if version == "" {
+		return modulePath
+	}
+	return modulePath + "@" + version
+}
+
+// writeModuleInfoPages writes a page for each documented module describing its
+// go.mod file: its path, Go version and toolchain, and its requirements,
+// replacements, exclusions and retractions, followed by the file itself.
+func writeModuleInfoPages(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	moduleInfoPages = make(map[string]string)
+
+	documented := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if m := packageModule(pkg); m != nil {
+			documented[m.Path] = true
+		}
+	}
+
+	for _, name := range moduleNames() {
+		m := modules[name]
+		if !documented[name] {
+			continue
+		}
+
+		// Modules without a go.mod file are not described.
+		source, err := ioutil.ReadFile(filepath.Join(m.Dir, "go.mod"))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to read go.mod file of %s: %s", name, err)
+		}
+
+		outDir := vanityPath(name)
+		basePath := relativeBasePath(outDir)
+
+		goVersion := "-"
+		if m.File.Go != nil {
+			goVersion = m.File.Go.Version
+		}
+		toolchain := moduleToolchain(m.File)
+		if toolchain == "" {
+			toolchain = "-"
+		}
+
+		buf.Reset()
+		buf.WriteString(pageHeader("Module "+html.EscapeString(displayPath(outDir))+" go.mod - "+siteName, basePath))
+		buf.WriteString(`
+<h1>
+	Module ` + html.EscapeString(displayPath(outDir)) + `
+</h1>
+` + moduleWarnings(name) + `
+<table id="module-info">
+<tr><th>Module path</th><td><code>` + html.EscapeString(name) + `</code></td></tr>
+<tr><th>Go version</th><td>` + html.EscapeString(goVersion) + `</td></tr>
+<tr><th>Toolchain</th><td>` + html.EscapeString(toolchain) + `</td></tr>
+</table>
+`)
+
+		if len(m.File.Require) > 0 {
+			buf.WriteString(`<h2 id="module-require">Requirements</h2>
+<table>
+<tr><th>Module</th><th>Version</th><th>Indirect</th></tr>
+`)
+			for _, r := range m.File.Require {
+				indirect := ""
+				if r.Indirect {
+					indirect = "Yes"
+				}
+				buf.WriteString(`<tr><td><a href="` + html.EscapeString(dependencyURL(r.Mod.Path, r.Mod.Version, basePath)) + `">` + html.EscapeString(r.Mod.Path) + `</a></td><td>` + html.EscapeString(r.Mod.Version) + `</td><td>` + indirect + `</td></tr>
+`)
+			}
+			buf.WriteString(`</table>
+`)
+		}
+
+		if len(m.File.Replace) > 0 {
+			buf.WriteString(`<h2 id="module-replace">Replacements</h2>
+<table>
+<tr><th>Module</th><th>Replaced by</th></tr>
+`)
+			for _, r := range m.File.Replace {
+				buf.WriteString(`<tr><td><code>` + html.EscapeString(formatModuleVersion(r.Old.Path, r.Old.Version)) + `</code></td><td><code>` + html.EscapeString(formatModuleVersion(r.New.Path, r.New.Version)) + `</code></td></tr>
+`)
+			}
+			buf.WriteString(`</table>
+`)
+		}
+
+		if len(m.File.Exclude) > 0 {
+			buf.WriteString(`<h2 id="module-exclude">Exclusions</h2>
+<ul>
+`)
+			for _, e := range m.File.Exclude {
+				buf.WriteString(`<li><code>` + html.EscapeString(formatModuleVersion(e.Mod.Path, e.Mod.Version)) + `</code></li>
+`)
+			}
+			buf.WriteString(`</ul>
+`)
+		}
+
+		if len(m.File.Retract) > 0 {
+			buf.WriteString(`<h2 id="module-retract">Retractions</h2>
+<table>
+<tr><th>Versions</th><th>Rationale</th></tr>
+`)
+			for _, r := range m.File.Retract {
+				versions := r.Low
+				if r.High != r.Low {
+					versions = "[" + r.Low + ", " + r.High + "]"
+				}
+				buf.WriteString(`<tr><td>` + html.EscapeString(versions) + `</td><td>` + html.EscapeString(r.Rationale) + `</td></tr>
+`)
+			}
+			buf.WriteString(`</table>
+`)
+		}
+
+		buf.WriteString(`<h2 id="module-go-mod">go.mod</h2>
+<pre>` + html.EscapeString(strings.TrimSpace(string(source))) + `</pre>
+<div id="footer">` + siteFooterText(basePath) + `</div>
+</div>
+</div>
+` + searchTags(basePath) + `</body>
+</html>
+`)
+
+		err = os.MkdirAll(filepath.Join(siteDestination, outDir), 0755)
+		if err != nil {
+			return fmt.Errorf("failed to make directory %s: %s", outDir, err)
+		}
+
+		err = writeFile(ctx, buf, outDir, moduleInfoPage)
+		if err != nil {
+			return err
+		}
+		moduleInfoPages[name] = path.Join(outDir, moduleInfoPage)
+	}
+	return nil
+}
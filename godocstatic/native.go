@@ -0,0 +1,774 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"html"
+	"io/ioutil"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	rendererGodoc  = "godoc"
+	rendererNative = "native"
+)
+
+func validateRenderer() error {
+	switch renderer {
+	case rendererGodoc, rendererNative:
+		return nil
+	default:
+		return fmt.Errorf("unknown renderer %s: must be one of %s, %s", renderer, rendererGodoc, rendererNative)
+	}
+}
+
+// packageDocument returns the documentation page of a package, either
+// served by godoc or rendered natively. Packages declaring type parameters
+// are always rendered natively. Unexported identifiers are documented when
+// mode includes doc.AllDecls.
+func packageDocument(ctx context.Context, pkg string, mode doc.Mode) (*goquery.Document, error) {
+	var body []byte
+	var err error
+	if renderer == rendererNative {
+		body, err = renderPackagePage(ctx, pkg, mode)
+	} else if declaresTypeParams(ctx, pkg) {
+		if verbose {
+			logger.Printf("Rendering %s natively: it declares type parameters", pkg)
+		}
+		body, err = renderPackagePage(ctx, pkg, mode)
+	} else {
+		pagePath := "/pkg/" + pkg + "/"
+		if mode&doc.AllDecls != 0 {
+			pagePath += "?m=all"
+		}
+		body, err = fetchPage(ctx, pagePath)
+		if err != nil && ctx.Err() == nil {
+			if verbose {
+				logger.Printf("Rendering %s natively: %s", pkg, err)
+			}
+			body, err = renderPackagePage(ctx, pkg, mode)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page of %s: %s", pkg, err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse page of %s: %s", pkg, err)
+	}
+	return doc, nil
+}
+
+// sourceDocument returns the page of a source file of a package, or the
+// listing of its source files when sourceFile is index.html. Source files are
+// rendered natively when they are highlighted.
+func sourceDocument(ctx context.Context, pkg string, sourceFile string) (*goquery.Document, error) {
+	var body []byte
+	var err error
+	if renderer == rendererNative || sourceHighlighting() || sourceLinks {
+		body, err = renderSourcePage(ctx, pkg, sourceFile)
+	} else {
+		body, err = fetchPage(ctx, "/src/"+pkg+"/"+sourceFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source file page %s of %s: %s", sourceFile, pkg, err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load document from page for package %s: %s", pkg, err)
+	}
+	return doc, nil
+}
+
+// styleSheet returns the base stylesheet of the site.
+func styleSheet(ctx context.Context) ([]byte, error) {
+	if renderer == rendererNative {
+		return []byte(nativeCSS), nil
+	}
+	return fetchPage(ctx, "/lib/godoc/style.css")
+}
+
+type nativePackage struct {
+	Dir          string
+	ImportPath   string
+	Name         string
+	GoFiles      []string
+	CgoFiles     []string
+	CFiles       []string
+	CXXFiles     []string
+	MFiles       []string
+	HFiles       []string
+	FFiles       []string
+	SFiles       []string
+	SwigFiles    []string
+	SwigCXXFiles []string
+	TestGoFiles  []string
+	XTestGoFiles []string
+}
+
+func loadNativePackage(ctx context.Context, pkg string) (*nativePackage, error) {
+	var buf, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "go", "list", "-find", "-json", pkg)
+	cmd.Env = godocEnv
+	cmd.Dir = pkgPaths[pkg]
+	if cmd.Dir == "" {
+		cmd.Dir = getTmpDir()
+	}
+	cmd.Stdout = &buf
+	cmd.Stderr = &stderr
+	setDeathSignal(cmd)
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	p := &nativePackage{}
+	err = json.Unmarshal(buf.Bytes(), p)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// sourceFiles returns the names of the source files of the package selected
+// by -source-types.
+func (p *nativePackage) sourceFiles() []string {
+	lists := map[string][]string{
+		"GoFiles":      p.GoFiles,
+		"CgoFiles":     p.CgoFiles,
+		"CFiles":       p.CFiles,
+		"CXXFiles":     p.CXXFiles,
+		"MFiles":       p.MFiles,
+		"HFiles":       p.HFiles,
+		"FFiles":       p.FFiles,
+		"SFiles":       p.SFiles,
+		"SwigFiles":    p.SwigFiles,
+		"SwigCXXFiles": p.SwigCXXFiles,
+		"TestGoFiles":  p.TestGoFiles,
+		"XTestGoFiles": p.XTestGoFiles,
+	}
+
+	var files []string
+	for _, field := range sourceFields {
+		files = append(files, lists[field]...)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// nativePage returns a page with the same layout as the pages served by
+// godoc, so that it is processed by updatePage in the same way.
+func nativePage(title string, content string) []byte {
+	return []byte(`<!DOCTYPE html>
+<html>
+<head>
+<meta http-equiv="Content-Type" content="text/html; charset=utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="theme-color" content="#375EAB">
+<title>` + html.EscapeString(title) + `</title>
+</head>
+<body>
+<div id="topbar" class="wide"></div>
+<div id="page" class="wide">
+<div class="container">
+` + content + `
+<div id="footer"></div>
+</div>
+</div>
+</body>
+</html>
+`)
+}
+
+type nativeRenderer struct {
+	fset *token.FileSet
+	pkg  string
+	// doc is the documentation of the package, by which links to its
+	// symbols in doc comments are resolved.
+	doc *doc.Package
+}
+
+func (r *nativeRenderer) node(n interface{}) string {
+	var buf bytes.Buffer
+	err := (&printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}).Fprint(&buf, r.fset, n)
+	if err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+func (r *nativeRenderer) comment(text string) string {
+	return docCommentHTML(r.doc, text)
+}
+
+// sourceLink returns the link to the line of a source file a node is
+// declared on.
+func (r *nativeRenderer) sourceLink(pos token.Pos, name string) string {
+	p := r.fset.Position(pos)
+	return `<a href="/src/` + r.pkg + `/` + filepath.Base(p.Filename) + `#L` + strconv.Itoa(p.Line) + `">` + html.EscapeString(name) + `</a>`
+}
+
+func (r *nativeRenderer) example(b *strings.Builder, ex *doc.Example, id string) {
+	name := "Example"
+	if ex.Suffix != "" {
+		name += " (" + strings.Replace(ex.Suffix, "_", " ", -1) + ")"
+	}
+
+	code := exampleCode(r.node(ex.Code), ex)
+
+	b.WriteString(`<div id="example_` + id + `" class="toggle">
+<div class="collapsed"><p class="exampleHeading toggleButton">&#9657; <span class="text">` + name + `</span></p></div>
+<div class="expanded"><p class="exampleHeading toggleButton">&#9662; <span class="text">` + name + `</span></p>
+` + r.comment(ex.Doc) + `<p>Code:</p>
+<pre class="code">` + html.EscapeString(code) + `</pre>
+`)
+	if ex.Output != "" {
+		b.WriteString(`<p>` + exampleOutputLabel(ex) + `</p>
+<pre class="output">` + html.EscapeString(ex.Output) + `</pre>
+`)
+	}
+	b.WriteString(`</div>
+</div>
+`)
+}
+
+func (r *nativeRenderer) examples(b *strings.Builder, examples []*doc.Example, id string) {
+	for _, ex := range examples {
+		exampleID := id
+		if ex.Suffix != "" {
+			exampleID += "_" + ex.Suffix
+		}
+		r.example(b, ex, exampleID)
+	}
+}
+
+func (r *nativeRenderer) values(b *strings.Builder, values []*doc.Value) {
+	for _, v := range values {
+		b.WriteString(`<pre>` + html.EscapeString(r.node(v.Decl)) + `</pre>
+` + r.comment(v.Doc))
+	}
+}
+
+func (r *nativeRenderer) function(b *strings.Builder, f *doc.Func, heading string, id string) {
+	b.WriteString(`<` + heading + ` id="` + id + `">func `)
+	if f.Recv != "" {
+		b.WriteString(`(` + html.EscapeString(f.Recv) + `) `)
+	}
+	b.WriteString(r.sourceLink(f.Decl.Pos(), f.Name) + ` <a class="permalink" href="#` + id + `">&#xb6;</a></` + heading + `>
+<pre>` + html.EscapeString(r.node(f.Decl)) + `</pre>
+` + r.comment(f.Doc))
+	r.examples(b, f.Examples, strings.Replace(id, ".", "_", -1))
+}
+
+// parsePackageDoc parses the source files of a package and extracts its
+// documentation with mode. The documentation is nil when the package has no Go
+// files.
+func parsePackageDoc(p *nativePackage, mode doc.Mode) (*token.FileSet, *doc.Package, error) {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, list := range [][]string{p.GoFiles, p.CgoFiles, p.TestGoFiles, p.XTestGoFiles} {
+		for _, file := range list {
+			f, err := parser.ParseFile(fset, filepath.Join(p.Dir, file), nil, parser.ParseComments)
+			if err != nil {
+				return nil, nil, err
+			}
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return fset, nil, nil
+	}
+
+	d, err := doc.NewFromFiles(fset, files, p.ImportPath, mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fset, d, nil
+}
+
+// renderPackagePage renders the documentation page of a package using
+// go/parser, go/doc and go/printer.
+func renderPackagePage(ctx context.Context, pkg string, mode doc.Mode) ([]byte, error) {
+	p, err := loadNativePackage(ctx, pkg)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	} else if err != nil {
+		// This is expected for directories without source files
+		return nativePage(path.Base(pkg), `<h1>Directory `+html.EscapeString(pkg)+`</h1>`), nil
+	}
+
+	fset, d, err := parsePackageDoc(p, mode)
+	if err != nil {
+		return nil, err
+	} else if d == nil {
+		return nativePage(path.Base(pkg), `<h1>Directory `+html.EscapeString(pkg)+`</h1>`), nil
+	}
+
+	r := &nativeRenderer{fset: fset, pkg: p.ImportPath, doc: d}
+
+	var b strings.Builder
+	if d.Name == "main" {
+		b.WriteString(`<h1>Command ` + html.EscapeString(path.Base(p.ImportPath)) + `</h1>
+<div id="pkg-overview">
+` + r.comment(d.Doc) + `</div>
+`)
+		return nativePage(path.Base(pkg), b.String()), nil
+	}
+
+	b.WriteString(`<h1>Package ` + html.EscapeString(d.Name) + `</h1>
+<div id="short-nav">
+<dl>
+<dd><code>import "` + html.EscapeString(p.ImportPath) + `"</code></dd>
+</dl>
+<dl>
+<dd><a href="#pkg-overview" class="overviewLink">Overview</a></dd>
+<dd><a href="#pkg-index" class="indexLink">Index</a></dd>
+</dl>
+</div>
+<div id="pkg-overview">
+<h2>Overview</h2>
+` + r.comment(d.Doc))
+	r.examples(&b, d.Examples, "package")
+	b.WriteString(`</div>
+<div id="pkg-index">
+<h2>Index</h2>
+<div id="manual-nav">
+<dl>
+`)
+	if len(d.Consts) > 0 {
+		b.WriteString(`<dd><a href="#pkg-constants">Constants</a></dd>
+`)
+	}
+	if len(d.Vars) > 0 {
+		b.WriteString(`<dd><a href="#pkg-variables">Variables</a></dd>
+`)
+	}
+	indexFunc := func(f *doc.Func, id string, indent bool) {
+		b.WriteString(`<dd>`)
+		if indent {
+			b.WriteString(`&nbsp; &nbsp; `)
+		}
+		b.WriteString(`<a href="#` + id + `">` + html.EscapeString(r.node(f.Decl)) + `</a></dd>
+`)
+	}
+	for _, f := range d.Funcs {
+		indexFunc(f, f.Name, false)
+	}
+	instantiated := make(map[*doc.Type][]*instantiatedMethod)
+	for _, t := range d.Types {
+		instantiated[t] = r.instantiatedMethods(t)
+
+		b.WriteString(`<dd><a href="#` + t.Name + `">type ` + html.EscapeString(t.Name) + r.typeParams(t, false) + `</a></dd>
+`)
+		for _, f := range t.Funcs {
+			indexFunc(f, f.Name, true)
+		}
+		for _, m := range t.Methods {
+			indexFunc(m, t.Name+"."+m.Name, true)
+		}
+		for _, m := range instantiated[t] {
+			b.WriteString(`<dd>&nbsp; &nbsp; <a href="#` + t.Name + `.` + m.Method.Name + `">` + html.EscapeString(m.Decl) + `</a></dd>
+`)
+		}
+	}
+	b.WriteString(`</dl>
+</div>
+<h3>Package files</h3>
+<p><span style="font-size:90%">
+`)
+	for _, file := range p.GoFiles {
+		b.WriteString(`<a href="/src/` + p.ImportPath + `/` + file + `">` + html.EscapeString(file) + `</a>
+`)
+	}
+	b.WriteString(`</span></p>
+</div>
+`)
+
+	if len(d.Consts) > 0 {
+		b.WriteString(`<h2 id="pkg-constants">Constants</h2>
+`)
+		r.values(&b, d.Consts)
+	}
+	if len(d.Vars) > 0 {
+		b.WriteString(`<h2 id="pkg-variables">Variables</h2>
+`)
+		r.values(&b, d.Vars)
+	}
+	for _, f := range d.Funcs {
+		r.function(&b, f, "h2", f.Name)
+	}
+	for _, t := range d.Types {
+		b.WriteString(`<h2 id="` + t.Name + `">type ` + r.sourceLink(t.Decl.Pos(), t.Name) + r.typeParams(t, true) + ` <a class="permalink" href="#` + t.Name + `">&#xb6;</a></h2>
+<pre>` + html.EscapeString(r.node(t.Decl)) + `</pre>
+` + r.comment(t.Doc))
+		r.examples(&b, t.Examples, t.Name)
+		r.values(&b, t.Consts)
+		r.values(&b, t.Vars)
+		for _, f := range t.Funcs {
+			r.function(&b, f, "h3", f.Name)
+		}
+		for _, m := range t.Methods {
+			r.function(&b, m, "h3", t.Name+"."+m.Name)
+		}
+		for _, m := range instantiated[t] {
+			r.instantiatedFunction(&b, t, m)
+		}
+	}
+
+	return nativePage(path.Base(pkg), b.String()), nil
+}
+
+// highlightComments returns the byte offsets of the comments in Go source.
+func highlightComments(src []byte) [][2]int {
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	s.Init(file, src, nil, scanner.ScanComments)
+
+	var comments [][2]int
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		} else if tok == token.COMMENT {
+			offset := file.Offset(pos)
+			comments = append(comments, [2]int{offset, offset + len(lit)})
+		}
+	}
+	return comments
+}
+
+// renderSourcePage renders a source file with numbered lines, or the listing
+// of the source files of a package when sourceFile is index.html.
+func renderSourcePage(ctx context.Context, pkg string, sourceFile string) ([]byte, error) {
+	p, err := loadNativePackage(ctx, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	if sourceFile == "index.html" {
+		var b strings.Builder
+		b.WriteString(`<h1>Directory src/` + html.EscapeString(pkg) + `</h1>
+<table class="layout">
+<tr><th class="pkg-name">Name</th></tr>
+<tr><td><a href="..">..</a></td></tr>
+`)
+		for _, file := range p.sourceFiles() {
+			b.WriteString(`<tr><td class="name"><a href="` + file + `">` + html.EscapeString(file) + `</a></td></tr>
+`)
+		}
+		b.WriteString(`</table>`)
+		return nativePage(path.Base(pkg), b.String()), nil
+	}
+
+	src, err := ioutil.ReadFile(filepath.Join(p.Dir, sourceFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var links map[int]sourceLink
+	if sourceLinks && strings.HasSuffix(sourceFile, ".go") {
+		links = fileSourceLinks(ctx, pkg, sourceFile)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<h1>Source file src/` + html.EscapeString(pkg+"/"+sourceFile) + `</h1>
+`)
+	lineNumber := func(i int) string {
+		n := strconv.Itoa(i + 1)
+		return `<span id="L` + n + `" class="ln">` + fmt.Sprintf("%6s", n) + `&nbsp;&nbsp;</span>`
+	}
+
+	if sourceHighlighting() {
+		lines, err := highlightSource(sourceFile, src, links)
+		if err != nil {
+			return nil, fmt.Errorf("failed to highlight %s: %s", sourceFile, err)
+		}
+
+		b.WriteString(`<pre class="chroma">`)
+		for i, line := range lines {
+			b.WriteString(lineNumber(i) + line + "\n")
+		}
+		b.WriteString(`</pre>`)
+		return nativePage(path.Base(pkg), b.String()), nil
+	}
+
+	var comments [][2]int
+	if strings.HasSuffix(sourceFile, ".go") {
+		comments = highlightComments(src)
+	}
+
+	b.WriteString(`<pre>`)
+	var start, comment int
+	for i, line := range strings.SplitAfter(string(src), "\n") {
+		if line == "" {
+			break
+		}
+		b.WriteString(lineNumber(i))
+
+		end := start + len(strings.TrimRight(line, "\r\n"))
+		for offset := start; offset < end; {
+			for comment < len(comments) && comments[comment][1] <= offset {
+				comment++
+			}
+
+			segmentEnd := end
+			inComment := comment < len(comments) && comments[comment][0] <= offset
+			if inComment && comments[comment][1] < end {
+				segmentEnd = comments[comment][1]
+			} else if !inComment && comment < len(comments) && comments[comment][0] < end {
+				segmentEnd = comments[comment][0]
+			}
+
+			var segment string
+			if inComment {
+				segment = `<span class="comment">` + html.EscapeString(string(src[offset:segmentEnd])) + `</span>`
+			} else {
+				segment = linkIdentifiers(src, offset, segmentEnd, links)
+			}
+			b.WriteString(segment)
+			offset = segmentEnd
+		}
+		b.WriteString("\n")
+		start += len(line)
+	}
+	b.WriteString(`</pre>`)
+
+	return nativePage(path.Base(pkg), b.String()), nil
+}
+
+// nativeCSS is the base stylesheet used when pages are rendered natively, in
+// place of the stylesheet served by godoc.
+const nativeCSS = `body {
+	margin: 0;
+	font-family: Arial, sans-serif;
+	background-color: #fff;
+	line-height: 1.3;
+	text-align: center;
+	color: #222;
+}
+textarea {
+	color: inherit;
+}
+pre,
+code {
+	font-family: Menlo, monospace;
+	font-size: 0.875rem;
+}
+pre {
+	line-height: 1.4;
+	overflow-x: auto;
+}
+pre .comment {
+	color: #006600;
+}
+pre .ln {
+	color: #999;
+	background: #efefef;
+	user-select: none;
+}
+a,
+.exampleHeading .text {
+	color: #375eab;
+	text-decoration: none;
+}
+a:hover,
+.exampleHeading .text:hover {
+	text-decoration: underline;
+}
+p,
+li {
+	max-width: 50rem;
+	word-wrap: break-word;
+}
+p,
+pre,
+ul,
+ol {
+	margin: 1.25rem;
+}
+pre {
+	background: #efefef;
+	padding: 0.625rem;
+	border-radius: 0.3125rem;
+}
+h1,
+h2,
+h3,
+h4 {
+	margin: 1.25rem 0 1.25rem;
+	padding: 0;
+	color: #375eab;
+	font-weight: bold;
+}
+h1 {
+	font-size: 1.75rem;
+	line-height: 1;
+}
+h2 {
+	font-size: 1.25rem;
+	background: #e0ebf5;
+	padding: 0.5rem;
+	line-height: 1.25;
+	font-weight: normal;
+}
+h2 a {
+	font-weight: bold;
+}
+h3 {
+	font-size: 1.25rem;
+}
+h3,
+h4 {
+	margin: 1.25rem 0.3125rem;
+}
+h4 {
+	font-size: 1rem;
+}
+a.permalink {
+	display: none;
+}
+h2:hover a.permalink,
+h3:hover a.permalink {
+	display: inline;
+}
+dl {
+	margin: 1.25rem;
+}
+dd {
+	margin: 0 0 0 1.25rem;
+}
+dl,
+dd {
+	font-size: 0.875rem;
+}
+div#nav table td {
+	vertical-align: top;
+}
+#pkg-index h3 {
+	font-size: 1rem;
+}
+.pkg-dir {
+	padding: 0 0.625rem;
+}
+.pkg-dir table {
+	border-collapse: collapse;
+	border-spacing: 0;
+}
+.pkg-name {
+	padding-right: 0.625rem;
+}
+.alert {
+	color: #aa0000;
+}
+.top-heading {
+	float: left;
+	padding: 1.313rem 0;
+	font-size: 1.25rem;
+	font-weight: normal;
+}
+.top-heading a {
+	color: #222;
+	text-decoration: none;
+}
+#heading-narrow {
+	display: none;
+}
+div#topbar {
+	background: #e0ebf5;
+	height: 4rem;
+	overflow: hidden;
+}
+div#page {
+	width: 100%;
+}
+div#page > .container,
+div#topbar > .container {
+	text-align: left;
+	margin-left: auto;
+	margin-right: auto;
+	padding: 0 1.25rem;
+}
+div#topbar > .container,
+div#page > .container {
+	max-width: 59.38rem;
+}
+div#page.wide > .container,
+div#topbar.wide > .container {
+	max-width: none;
+}
+div#menu {
+	float: right;
+	padding: 0.625rem;
+	white-space: nowrap;
+	font-size: 1rem;
+	margin-top: 0.6rem;
+}
+div#menu > a {
+	display: inline-block;
+	margin-top: 0.5rem;
+	padding: 0.5rem;
+	color: white;
+	background: #375eab;
+	border-radius: 0.3125rem;
+}
+div#footer {
+	text-align: center;
+	color: #666;
+	font-size: 0.875rem;
+	margin: 2.5rem 0;
+}
+div#short-nav dl {
+	margin: 0.625rem 0 0.625rem 1.25rem;
+}
+div#short-nav dd {
+	display: inline;
+	margin: 0 1.25rem 0 0;
+}
+table.layout td {
+	padding-right: 1.25rem;
+}
+.exampleHeading {
+	cursor: pointer;
+}
+@media (max-width: 58.125em) {
+	#heading-wide {
+		display: none;
+	}
+	#heading-narrow {
+		display: block;
+	}
+}
+@media (max-width: 47.5em) {
+	.container .left,
+	.container .right {
+		width: auto;
+		float: none;
+	}
+	div#topbar {
+		height: auto;
+	}
+	div#menu {
+		float: none;
+	}
+}
+`
@@ -0,0 +1,41 @@
+package godocstatic
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// noJS omits all scripts from generated pages.
+var noJS bool
+
+func validateNoJS() error {
+	if !noJS {
+		return nil
+	}
+	if searchMode != "" {
+		return errors.New("--search may not be used with --no-js")
+	}
+	if len(extraJS) > 0 {
+		return errors.New("--extra-js may not be used with --no-js")
+	}
+	return nil
+}
+
+// removeScriptedControls removes the controls of godoc pages which depend on
+// the scripts of godoc, which are not included in generated pages. Sections
+// which godoc collapses with a script are always displayed.
+func removeScriptedControls(doc *goquery.Document) {
+	doc.Find(".js-expandAll").Remove()
+
+	doc.Find(".toggleVisible").Each(func(_ int, selection *goquery.Selection) {
+		selection.ChildrenFiltered(".collapsed").Remove()
+		selection.ChildrenFiltered(".expanded").ChildrenFiltered(".toggleButton").Each(func(_ int, button *goquery.Selection) {
+			button.RemoveAttr("title")
+			button.RemoveClass("toggleButton")
+			button.SetText(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(button.Text()), "▾")))
+		})
+		selection.RemoveClass("toggleVisible")
+	})
+}
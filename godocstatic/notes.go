@@ -0,0 +1,268 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"html"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const notesPage = "notes.html"
+
+var (
+	notes string
+
+	// noteMarkers lists the markers of the notes collected, such as BUG.
+	noteMarkers []string
+)
+
+// noteMarker matches the markers of notes recognized by go/doc.
+var noteMarker = regexp.MustCompile(`^[A-Z][A-Z]+$`)
+
+// parseNoteMarkers parses the markers of the notes listed by -notes.
+func parseNoteMarkers() error {
+	noteMarkers = nil
+	for _, marker := range strings.Split(notes, ",") {
+		marker = strings.TrimSpace(marker)
+		if marker == "" {
+			continue
+		} else if !noteMarker.MatchString(marker) {
+			return fmt.Errorf("invalid note marker %s: must be two or more uppercase letters, such as BUG", marker)
+		}
+		noteMarkers = append(noteMarkers, marker)
+	}
+	noteMarkers = uniqueStrings(noteMarkers)
+	return nil
+}
+
+// packageNote describes a note such as BUG(name): in the source of a package.
+type packageNote struct {
+	Marker string
+	UID    string
+	Body   string
+	File   string
+	Line   int
+	// Symbol is the exported symbol the note belongs to, identified as on
+	// package pages, or an empty string when the note belongs to the
+	// package.
+	Symbol string
+}
+
+// exportedReceiver returns the name of the type of a method receiver, or an
+// empty string when the type is not exported.
+func exportedReceiver(recv *ast.FieldList) string {
+	if recv == nil || len(recv.List) == 0 {
+		return ""
+	}
+
+	expr := recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch index := expr.(type) {
+	case *ast.IndexExpr:
+		expr = index.X
+	case *ast.IndexListExpr:
+		expr = index.X
+	}
+
+	ident, ok := expr.(*ast.Ident)
+	if !ok || !ident.IsExported() {
+		return ""
+	}
+	return ident.Name
+}
+
+// symbolRange is the extent of the declaration of an exported symbol,
+// including its doc comment.
+type symbolRange struct {
+	Start, End token.Pos
+	Symbol     string
+}
+
+// symbolRanges returns the extents of the exported functions, methods and
+// types declared in a file. They must be determined before go/doc removes the
+// doc comments of declarations.
+func symbolRanges(f *ast.File) []symbolRange {
+	extent := func(doc *ast.CommentGroup, node ast.Node, symbol string) symbolRange {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		return symbolRange{Start: start, End: node.End(), Symbol: symbol}
+	}
+
+	var ranges []symbolRange
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if !decl.Name.IsExported() {
+				continue
+			} else if decl.Recv == nil {
+				ranges = append(ranges, extent(decl.Doc, decl, decl.Name.Name))
+			} else if recv := exportedReceiver(decl.Recv); recv != "" {
+				ranges = append(ranges, extent(decl.Doc, decl, recv+"."+decl.Name.Name))
+			}
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				if !spec.Name.IsExported() {
+					continue
+				} else if len(decl.Specs) == 1 {
+					ranges = append(ranges, extent(decl.Doc, decl, spec.Name.Name))
+				} else {
+					ranges = append(ranges, extent(spec.Doc, spec, spec.Name.Name))
+				}
+			}
+		}
+	}
+	return ranges
+}
+
+// loadNotes returns the notes of a package with the markers of -notes, in the
+// order of noteMarkers.
+func loadNotes(ctx context.Context, pkg string) ([]packageNote, error) {
+	p, err := loadNativePackage(ctx, pkg)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	} else if err != nil {
+		return nil, nil // This is expected for packages without source files
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	var ranges []symbolRange
+	for _, file := range append(append([]string{}, p.GoFiles...), p.CgoFiles...) {
+		f, err := parser.ParseFile(fset, filepath.Join(p.Dir, file), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		ranges = append(ranges, symbolRanges(f)...)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	d, err := doc.NewFromFiles(fset, files, p.ImportPath, doc.AllDecls)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []packageNote
+	for _, marker := range noteMarkers {
+		for _, note := range d.Notes[marker] {
+			position := fset.Position(note.Pos)
+
+			var symbol string
+			for _, r := range ranges {
+				if r.Start <= note.Pos && note.Pos < r.End {
+					symbol = r.Symbol
+					break
+				}
+			}
+
+			found = append(found, packageNote{
+				Marker: marker,
+				UID:    note.UID,
+				Body:   strings.TrimSpace(note.Body),
+				File:   filepath.Base(position.Filename),
+				Line:   position.Line,
+				Symbol: symbol,
+			})
+		}
+	}
+	return found, nil
+}
+
+// writeNotesPage writes a page listing the notes of every documented package
+// by marker, linking each note to the symbol it belongs to and its source.
+func writeNotesPage(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	var index string
+	if linkIndex {
+		index = "/index.html"
+	}
+
+	pkgNotes := make(map[string][]packageNote)
+	for _, pkg := range pkgs {
+		if _, ok := listFailures[pkg]; ok {
+			continue
+		}
+
+		found, err := loadNotes(ctx, pkg)
+		if err != nil {
+			return fmt.Errorf("failed to read notes of %s: %s", pkg, err)
+		}
+		pkgNotes[pkg] = found
+	}
+
+	buf.Reset()
+	buf.WriteString(sitePageHeader("Notes - " + siteName))
+	buf.WriteString(`
+<h1>
+	Notes
+</h1>
+<div id="pkg-notes">
+`)
+
+	for _, marker := range noteMarkers {
+		var b strings.Builder
+		var count int
+		for _, pkg := range pkgs {
+			var rows strings.Builder
+			for _, note := range pkgNotes[pkg] {
+				if note.Marker != marker {
+					continue
+				}
+
+				outPkg := vanityPath(pkg)
+				owner := `<a href="` + outPkg + index + `">` + html.EscapeString(displayPath(outPkg)) + `</a>`
+				if note.Symbol != "" {
+					owner = `<a href="` + outPkg + index + `#` + note.Symbol + `">` + html.EscapeString(note.Symbol) + `</a>`
+				}
+				line := strconv.Itoa(note.Line)
+				rows.WriteString(`<tr><td>` + owner + `</td><td>` + html.EscapeString(note.UID) + `</td><td>` + html.EscapeString(note.Body) + `</td><td><a href="src/` + outPkg + "/" + note.File + ".html#L" + line + `">` + html.EscapeString(note.File) + ":" + line + `</a></td></tr>
+`)
+				count++
+			}
+			if rows.Len() == 0 {
+				continue
+			}
+
+			outPkg := vanityPath(pkg)
+			b.WriteString(`<h3><a href="` + outPkg + index + `">` + html.EscapeString(displayPath(outPkg)) + `</a></h3>
+<table>
+<tr><th>Symbol</th><th>Name</th><th>Note</th><th>Source</th></tr>
+` + rows.String() + `</table>
+`)
+		}
+
+		buf.WriteString(`<h2 id="` + marker + `">` + marker + ` (` + strconv.Itoa(count) + `)</h2>
+`)
+		if count == 0 {
+			buf.WriteString(`<p>No ` + marker + ` notes.</p>
+`)
+		}
+		buf.WriteString(b.String())
+	}
+
+	buf.WriteString(`</div>
+<div id="footer">` + siteFooterText("") + `</div>
+</div>
+</div>
+` + searchTags("") + `</body>
+</html>
+`)
+	return writeFile(ctx, buf, "", notesPage)
+}
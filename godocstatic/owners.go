@@ -0,0 +1,302 @@
+package godocstatic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	ownersPage       = "owners.html"
+	searchOwnersFile = "search-owners.json"
+)
+
+var ownersFile string
+
+// ownerRule assigns owners to the packages matching a pattern. When several
+// rules match a package, the last rule applies.
+type ownerRule struct {
+	Pattern *regexp.Regexp
+	Owners  []string
+}
+
+var (
+	ownerRules []ownerRule
+
+	// ownersRoot is the directory the paths of CODEOWNERS patterns are
+	// relative to, or an empty string when rules match package patterns.
+	ownersRoot string
+)
+
+// codeOwnersPattern compiles a CODEOWNERS pattern, which matches the paths of
+// files relative to the root of the repository as in .gitignore files.
+func codeOwnersPattern(p string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(strings.TrimSuffix(p, "/"), "/")
+	// Patterns ending in /* only match the files of a directory, while other
+	// patterns also match the files below the directories they match.
+	nested := !strings.HasSuffix(p, "/*")
+	p = strings.Trim(p, "/")
+
+	var expr strings.Builder
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case p[i] == '*':
+			expr.WriteString("[^/]*")
+		case p[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+
+	prefix, suffix := "^", "$"
+	if !anchored {
+		prefix = "^(.*/)?"
+	}
+	if nested {
+		suffix = "(/.*)?$"
+	}
+	return regexp.Compile(prefix + expr.String() + suffix)
+}
+
+// loadOwners reads the owners of packages from a CODEOWNERS file, or from a
+// JSON object mapping package patterns to an owner or a list of owners. The
+// most specific package pattern applies.
+func loadOwners() error {
+	ownerRules, ownersRoot = nil, ""
+
+	data, err := ioutil.ReadFile(ownersFile)
+	if err != nil {
+		return err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var mapping map[string]json.RawMessage
+		err = json.Unmarshal(data, &mapping)
+		if err != nil {
+			return err
+		}
+
+		patterns := make([]string, 0, len(mapping))
+		for p := range mapping {
+			patterns = append(patterns, p)
+		}
+		sort.Slice(patterns, func(i, j int) bool {
+			if len(patterns[i]) != len(patterns[j]) {
+				return len(patterns[i]) < len(patterns[j])
+			}
+			return patterns[i] < patterns[j]
+		})
+
+		for _, p := range patterns {
+			var owners []string
+			if err := json.Unmarshal(mapping[p], &owners); err != nil {
+				var owner string
+				if err := json.Unmarshal(mapping[p], &owner); err != nil {
+					return fmt.Errorf("owners of %s must be a string or a list of strings", p)
+				}
+				owners = []string{owner}
+			}
+
+			re, err := packagePattern(p)
+			if err != nil {
+				return fmt.Errorf("invalid package pattern %s: %s", p, err)
+			}
+			ownerRules = append(ownerRules, ownerRule{Pattern: re, Owners: owners})
+		}
+		return nil
+	}
+
+	// CODEOWNERS files are located in the root of a repository, or in its
+	// .github or docs directory.
+	ownersRoot, err = filepath.Abs(filepath.Dir(ownersFile))
+	if err != nil {
+		return err
+	}
+	if base := filepath.Base(ownersRoot); base == ".github" || base == "docs" {
+		ownersRoot = filepath.Dir(ownersRoot)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var line int
+	for scanner.Scan() {
+		line++
+
+		text := scanner.Text()
+		if i := strings.Index(text, " #"); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		re, err := codeOwnersPattern(fields[0])
+		if err != nil {
+			return fmt.Errorf("invalid pattern on line %d: %s", line, err)
+		}
+		ownerRules = append(ownerRules, ownerRule{Pattern: re, Owners: fields[1:]})
+	}
+	return scanner.Err()
+}
+
+// packageOwners returns the owners of a package. Packages are matched by
+// import path or vanity path, or by the path of one of their Go files when
+// owners are read from a CODEOWNERS file.
+func packageOwners(pkg string) []string {
+	targets := []string{pkg, vanityPath(pkg)}
+	if ownersRoot != "" {
+		targets = nil
+
+		dir := pkgDirs[pkg]
+		if dir == "" {
+			return nil
+		}
+		rel, err := filepath.Rel(ownersRoot, dir)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return nil
+		}
+
+		target := filepath.ToSlash(rel)
+		infos, _ := ioutil.ReadDir(dir)
+		for _, info := range infos {
+			if name := info.Name(); strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+				target = strings.TrimPrefix(target+"/"+name, "./")
+				break
+			}
+		}
+		targets = append(targets, target)
+	}
+
+	var owners []string
+	for _, rule := range ownerRules {
+		for _, target := range targets {
+			if rule.Pattern.MatchString(target) {
+				owners = rule.Owners
+				break
+			}
+		}
+	}
+	return owners
+}
+
+// ownerBadges returns badges labeling a package on the index with its owners.
+func ownerBadges(pkg string) string {
+	if ownersFile == "" {
+		return ""
+	}
+
+	var badges string
+	for _, owner := range packageOwners(pkg) {
+		badges += ` <span class="pkg-badge pkg-badge-owner" title="Owner">` + html.EscapeString(owner) + `</span>`
+	}
+	return badges
+}
+
+// ownedPackages groups packages by owner, returning the owners sorted by
+// name and the packages without owners. Directories which do not contain a
+// package are omitted.
+func ownedPackages(pkgs []string) ([]string, map[string][]string, []string) {
+	owned := make(map[string][]string)
+	var unowned []string
+	for _, pkg := range pkgs {
+		if pkgDirs[pkg] == "" {
+			continue // Directories without packages are not owned
+		}
+
+		owners := packageOwners(pkg)
+		if len(owners) == 0 {
+			unowned = append(unowned, pkg)
+		}
+		for _, owner := range owners {
+			owned[owner] = append(owned[owner], pkg)
+		}
+	}
+
+	owners := make([]string, 0, len(owned))
+	for owner := range owned {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+	return owners, owned, unowned
+}
+
+// writeOwnersPage writes a page listing the documented packages grouped by
+// owner.
+func writeOwnersPage(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	var index string
+	if linkIndex {
+		index = "/index.html"
+	}
+
+	owners, owned, unowned := ownedPackages(pkgs)
+
+	buf.Reset()
+	buf.WriteString(sitePageHeader("Owners - " + siteName))
+	buf.WriteString(`
+<h1>
+	Owners
+</h1>
+<div id="pkg-owners">
+`)
+	writeGroup := func(heading string, pkgs []string) {
+		buf.WriteString(`<h2>` + html.EscapeString(heading) + `</h2>
+<ul>
+`)
+		for _, pkg := range pkgs {
+			outPkg := vanityPath(pkg)
+			buf.WriteString(`<li><a href="` + outPkg + index + `">` + html.EscapeString(displayPath(outPkg)) + `</a></li>
+`)
+		}
+		buf.WriteString(`</ul>
+`)
+	}
+	for _, owner := range owners {
+		writeGroup(owner, owned[owner])
+	}
+	if len(unowned) > 0 {
+		writeGroup("Unowned", unowned)
+	}
+
+	buf.WriteString(`</div>
+<div id="footer">` + siteFooterText("") + `</div>
+</div>
+</div>
+` + searchTags("") + `</body>
+</html>
+`)
+	return writeFile(ctx, buf, "", ownersPage)
+}
+
+// writeSearchOwners writes the packages of each owner, by which search
+// results may be filtered.
+func writeSearchOwners(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	owners, owned, _ := ownedPackages(pkgs)
+
+	facets := make(map[string][]string, len(owners))
+	for _, owner := range owners {
+		for _, pkg := range owned[owner] {
+			facets[owner] = append(facets[owner], vanityPath(pkg))
+		}
+	}
+
+	data, err := json.Marshal(facets)
+	if err != nil {
+		return err
+	}
+
+	buf.Reset()
+	buf.Write(data)
+	return writeFile(ctx, buf, "lib", searchOwnersFile)
+}
@@ -0,0 +1,867 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const additionalCSS = `
+details { margin-top: 20px; }
+summary { margin-left: 20px; cursor: pointer; }
+#footer > p, #footer > li {	max-width: none; word-wrap: normal; }
+.module-warning { margin: 1.25rem 0; padding: 0 0.625rem; background: var(--warning-background); border: 0.0625rem solid var(--warning-border); }
+.pkg-pages { margin: 1.25rem 0; }
+.pkg-pages a, .pkg-pages strong { margin-right: 0.3125rem; }
+.pkg-filter input { padding: 0.3125rem; width: 20rem; max-width: 100%; }
+.pkg-icon { display: inline-block; width: 1.5rem; margin-right: 0.3125rem; font-family: monospace; font-size: 0.75rem; text-align: center; color: white; border-radius: 0.25rem; }
+.pkg-icon-lib { background: #375eab; }
+.pkg-icon-cmd { background: #2e7d32; }
+.version-switcher { margin-right: 0.625rem; }
+html:not(.js) .js-only, html.js .no-js-only { display: none; }
+.version-links a, .version-links strong { margin-right: 0.3125rem; }
+.version-changes { margin-right: 0.625rem; }
+.pkg-badge { margin-left: 0.3125rem; padding: 0 0.3125rem; font-size: 0.75rem; color: white; background: #8a5a00; border-radius: 0.25rem; }
+.pkg-badge-owner { color: var(--text); background: var(--heading-background); }
+`
+
+const footerText = `Generated by <a href="https://godoc.org/golang.org/x/tools/godoc" target="_blank">godoc</a> + <a href="https://code.rocketnine.space/tslocum/godoc-static" target="_blank">godoc-static</a>`
+
+// topBar returns the content of the top bar of a page, as laid out by the
+// selected theme or topbar.html.
+func topBar(basePath string, siteName string) string {
+	content := currentTheme().TopBar(basePath, siteName)
+
+	var index string
+	if linkIndex {
+		index = "index.html"
+	}
+	out, ok := executeTemplate(templateTopBar, &topBarTemplateData{
+		BasePath:        basePath,
+		SiteName:        siteName,
+		IndexURL:        basePath + index,
+		VersionSwitcher: template.HTML(versionSwitcher(basePath)),
+		Search:          template.HTML(searchBox()),
+		ThemeToggle:     template.HTML(themeToggle()),
+		Content:         template.HTML(content),
+	})
+	if ok {
+		return out
+	}
+	return content
+}
+
+func classicTopBar(basePath string, siteName string) string {
+	var index string
+	if linkIndex {
+		index = "index.html"
+	}
+
+	return `<div class="container">
+<div class="top-heading" id="heading-wide"><a href="` + basePath + index + `">` + siteName + `</a></div>
+<div class="top-heading" id="heading-narrow"><a href="` + basePath + index + `">` + siteName + `</a></div>
+<!--<a href="#" id="menu-button"><span id="menu-button-arrow">&#9661;</span></a>-->
+<div id="menu">
+<a href="` + basePath + index + `" style="margin-right: 10px;">Package Index</a>
+` + versionSwitcher(basePath) + `
+` + searchBox() + `
+` + themeToggle() + `
+</div>
+</div>`
+}
+
+func modernTopBar(basePath string, siteName string) string {
+	var index string
+	if linkIndex {
+		index = "index.html"
+	}
+
+	return `<div class="container top-bar">
+<a class="top-brand" href="` + basePath + index + `">` + siteName + `</a>
+<nav id="menu" aria-label="Site">
+<a href="` + basePath + index + `">Packages</a>
+` + versionSwitcher(basePath) + `
+` + searchBox() + `
+` + themeToggle() + `
+</nav>
+</div>`
+}
+
+// siteFooterText returns the content of the footer of a page, as laid out by
+// footer.html when it is defined.
+func siteFooterText(basePath string) string {
+	content := builtinFooterText(basePath)
+	out, ok := executeTemplate(templateFooter, &footerTemplateData{
+		BasePath:   basePath,
+		SiteFooter: template.HTML(siteFooter),
+		Content:    template.HTML(content),
+	})
+	if ok {
+		return out
+	}
+	return content
+}
+
+func builtinFooterText(basePath string) string {
+	footer := siteFooter
+	addP := footer != ""
+
+	if addP {
+		footer += "<p>"
+	}
+
+	if siteZip != "" && zipSplitBytes > 0 {
+		footer += `Download <a href="` + basePath + zipPartName(1) + `">` + zipPartName(1) + `</a> and its following parts to browse offline - `
+	} else if siteZip != "" {
+		footer += `<a href="` + basePath + siteZip + `">Download ` + siteZip + `</a> to browse offline - `
+	}
+	footer += footerText
+
+	if addP {
+		footer += "</p>"
+	}
+	return footer
+}
+
+// trimHeading removes -trim-prefix from the import path displayed in the
+// heading of directory and source file pages.
+func trimHeading(doc *goquery.Document) {
+	prefix := strings.Trim(trimPrefix, "/")
+	if prefix == "" {
+		return
+	}
+
+	heading := doc.Find("h1").First()
+	links := heading.Find("a")
+	if links.Length() == 0 {
+		text := strings.TrimSpace(heading.Text())
+		for _, label := range []string{"Directory src/", "Source file src/"} {
+			if strings.HasPrefix(text, label) {
+				heading.SetText(strings.TrimSuffix(label, "src/") + displayPath(vanityPath(text[len(label):])))
+				return
+			}
+		}
+		return
+	}
+
+	original := originalPath(prefix)
+	links.Each(func(_ int, selection *goquery.Selection) {
+		href := strings.TrimSuffix(selection.AttrOr("href", ""), "/")
+		if href != "/src" && !(strings.HasPrefix(href, "/src/") && hasPathPrefix(original, href[5:])) {
+			return
+		}
+
+		next := selection.Nodes[0].NextSibling
+		if next != nil && next.Type == html.TextNode && strings.HasPrefix(next.Data, "/") {
+			next.Data = next.Data[1:]
+		}
+		selection.Remove()
+	})
+}
+
+func updatePage(doc *goquery.Document, page string, basePath string, siteName string) {
+	doc.Find("link").Remove()
+	doc.Find("script").Remove()
+
+	linkTag := &html.Node{
+		Type:     html.ElementNode,
+		DataAtom: atom.Link,
+		Data:     "link",
+		Attr: []html.Attribute{
+			{Key: "type", Val: "text/css"},
+			{Key: "rel", Val: "stylesheet"},
+			{Key: "href", Val: basePath + "lib/style.css"},
+		},
+	}
+
+	doc.Find("head").AppendNodes(linkTag)
+
+	if !noJS {
+		themeTag := &html.Node{
+			Type:     html.ElementNode,
+			DataAtom: atom.Script,
+			Data:     "script",
+			Attr: []html.Attribute{
+				{Key: "src", Val: basePath + "lib/theme.js"},
+			},
+		}
+
+		doc.Find("head").AppendNodes(themeTag)
+	}
+
+	addExtraAssets(doc, basePath)
+
+	addSocialMeta(doc, page)
+
+	expectElement(doc, page, "top bar", "#topbar").First().SetHtml(topBar(basePath, siteName))
+
+	trimHeading(doc)
+
+	removeScriptedControls(doc)
+
+	importPathDisplay := doc.Find("#short-nav").First().Find("code").First()
+	if importPathDisplay.Length() > 0 {
+		importPathDisplayText := importPathDisplay.Text()
+		if strings.ContainsRune(importPathDisplayText, '.') && strings.HasPrefix(importPathDisplayText, `import "`) && strings.HasSuffix(importPathDisplayText, `"`) {
+			importPath := importPathDisplayText[8 : len(importPathDisplayText)-1]
+
+			browseImportPath := importPath
+			var browseInsert string
+			if strings.HasPrefix(importPath, "gitlab.com/") {
+				browseInsert = "/-/tree/master"
+			} else if strings.HasPrefix(importPath, "github.com/") || strings.HasPrefix(importPath, "git.sr.ht/") {
+				browseInsert = "/tree/master"
+			} else if strings.HasPrefix(importPath, "bitbucket.org/") || strings.HasPrefix(importPath, "code.rocketnine.space/") {
+				browseInsert = "/src/master"
+			}
+			if browseInsert != "" {
+				var insertPos int
+				var found int
+				for i, c := range importPath {
+					if c == '/' {
+						found++
+						if found == 3 {
+							insertPos = i
+							break
+						}
+					}
+				}
+				if insertPos > 0 {
+					browseImportPath = importPath[0:insertPos] + browseInsert + importPath[insertPos:]
+				}
+			}
+
+			importPathDisplay.SetHtml(fmt.Sprintf(`import "<a href="https://` + browseImportPath + `" target="_blank">` + vanityPath(importPath) + `</a>"`))
+		}
+	}
+
+	doc.Find("a").Each(func(_ int, selection *goquery.Selection) {
+		href := selection.AttrOr("href", "")
+		if strings.HasPrefix(href, "/pkg/") && linkExternalPackage(selection, href) {
+			return
+		}
+		if strings.HasPrefix(href, "/src/") || strings.HasPrefix(href, "/pkg/") {
+			// Anchors such as #T.Method do not name files.
+			linkedPath := href
+			if end := strings.IndexAny(linkedPath, "?#"); end >= 0 {
+				linkedPath = linkedPath[:end]
+			}
+			if strings.ContainsRune(path.Base(linkedPath), '.') {
+				queryPos := strings.IndexRune(href, '?')
+				if queryPos >= 0 {
+					href = href[0:queryPos] + ".html" + href[queryPos:]
+				} else {
+					hashPos := strings.IndexRune(href, '#')
+					if hashPos >= 0 {
+						href = href[0:hashPos] + ".html" + href[hashPos:]
+					} else {
+						href += ".html"
+					}
+				}
+			} else if linkIndex {
+				queryPos := strings.IndexRune(href, '?')
+				if queryPos >= 0 {
+					href = href[0:queryPos] + "/index.html" + href[queryPos:]
+				} else {
+					hashPos := strings.IndexRune(href, '#')
+					if hashPos >= 0 {
+						href = href[0:hashPos] + "/index.html" + href[hashPos:]
+					} else {
+						href += "/index.html"
+					}
+				}
+			}
+
+			if strings.HasPrefix(href, "/pkg/") {
+				href = "/" + vanityPath(href[5:])
+			} else {
+				href = "/src/" + vanityPath(href[5:])
+			}
+
+			selection.SetAttr("href", basePath+href[1:])
+		}
+	})
+
+	doc.Find("div").Each(func(_ int, selection *goquery.Selection) {
+		if selection.HasClass("toggle") {
+			var summary string
+			var err error
+			selection.Find("div").Each(func(_ int, subSelection *goquery.Selection) {
+				if subSelection.HasClass("collapsed") {
+					summary, err = subSelection.Find("span.text").First().Html()
+					if err != nil {
+						summary = "Summary not available"
+					}
+
+					subSelection.Remove()
+				}
+			})
+
+			selection.Find(".toggleButton").Remove()
+
+			selection.PrependHtml(fmt.Sprintf("<summary>%s</summary>", summary))
+
+			selection.RemoveClass("toggle")
+
+			selection.Nodes[0].Data = "details"
+			selection.Nodes[0].DataAtom = atom.Details
+		}
+	})
+
+	expectElement(doc, page, "footer", "#footer").Last().SetHtml(siteFooterText(basePath))
+
+	// Controls requiring the scripts of godoc, which are removed, must have
+	// been replaced.
+	if doc.Find(`[id^="example_"]:not(details)`).Length() > 0 {
+		recordRewriteMiss(page, "example toggles", "div.toggle")
+	}
+	if doc.Find(".toggle, .toggleVisible, .toggleButton, .js-expandAll").Length() > 0 {
+		recordRewriteMiss(page, "scripted controls", ".toggle, .toggleVisible, .toggleButton, .js-expandAll")
+	}
+
+	if searchMode != "" {
+		doc.Find("body").AppendHtml(searchTags(basePath))
+	}
+}
+
+type indexRow struct {
+	Pkg      string
+	OutPkg   string
+	Synopsis string
+	Link     bool
+	Package  bool
+	Command  bool
+}
+
+// pkgIcon returns the icon displayed beside a package on the index.
+func pkgIcon(command bool) string {
+	if command {
+		return `<span class="pkg-icon pkg-icon-cmd" title="Command" aria-hidden="true">&gt;_</span>`
+	}
+	return `<span class="pkg-icon pkg-icon-lib" title="Library" aria-hidden="true">{}</span>`
+}
+
+func indexRows(ctx context.Context, pkgs []string, filterPkgs []string) []indexRow {
+	var rows []indexRow
+	var pkgBuf bytes.Buffer
+	for _, pkg := range pkgs {
+		pkgBuf.Reset()
+		cmd := exec.CommandContext(ctx, "go", "list", "-find", "-f", `{{ .Name }} {{ .Doc }}`, pkg)
+		cmd.Env = godocEnv
+		cmd.Dir = pkgPaths[pkg]
+		if cmd.Dir == "" {
+			cmd.Dir = getTmpDir()
+		}
+		cmd.Stdout = &pkgBuf
+		setDeathSignal(cmd)
+
+		cmd.Run() // Ignore error
+
+		var linkPackage bool
+		for _, filterPkg := range filterPkgs {
+			if pkg == filterPkg {
+				linkPackage = true
+				break
+			}
+		}
+
+		var name, synopsis string
+		output := strings.TrimSpace(pkgBuf.String())
+		if firstSpace := strings.IndexRune(output, ' '); firstSpace > 0 {
+			name, synopsis = output[:firstSpace], strings.TrimSpace(output[firstSpace+1:])
+		} else {
+			name = output
+		}
+
+		rows = append(rows, indexRow{
+			Pkg:      pkg,
+			OutPkg:   vanityPath(pkg),
+			Synopsis: synopsis,
+			Link:     linkPackage,
+			Package:  name != "",
+			Command:  name == "main",
+		})
+	}
+
+	// Directories which only contain commands are listed with the commands.
+	for i := range rows {
+		if rows[i].Package {
+			continue
+		}
+
+		var commands int
+		for _, row := range rows {
+			if row.Package && strings.HasPrefix(row.OutPkg, rows[i].OutPkg+"/") {
+				if !row.Command {
+					commands = 0
+					break
+				}
+				commands++
+			}
+		}
+		rows[i].Command = commands > 0
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return !rows[i].Command && rows[j].Command
+	})
+	return rows
+}
+
+// indexPageName returns the file name of a page of the package index.
+func indexPageName(page int) string {
+	if page == 0 {
+		return "index.html"
+	}
+	return "index-" + strconv.Itoa(page+1) + ".html"
+}
+
+func indexPagination(page int, pages int) string {
+	if pages <= 1 {
+		return ""
+	}
+
+	nav := `<div class="pkg-pages">Page `
+	for i := 0; i < pages; i++ {
+		if i == page {
+			nav += `<strong>` + strconv.Itoa(i+1) + `</strong> `
+		} else {
+			nav += `<a href="` + indexPageName(i) + `">` + strconv.Itoa(i+1) + `</a> `
+		}
+	}
+	return nav + `</div>`
+}
+
+func writeIndex(ctx context.Context, buf *bytes.Buffer, pkgs []string, filterPkgs []string) error {
+	rows := indexRows(ctx, pkgs, filterPkgs)
+
+	// Commands are listed separately from libraries when there are any.
+	var sections bool
+	for _, row := range rows {
+		if row.Command {
+			sections = true
+			break
+		}
+	}
+
+	pages := 1
+	if indexPageSize > 0 && len(rows) > indexPageSize {
+		pages = (len(rows) + indexPageSize - 1) / indexPageSize
+	}
+	indexPages = pages
+
+	for page := 0; page < pages; page++ {
+		start := page * indexPageSize
+		end := len(rows)
+		if pages > 1 && start+indexPageSize < end {
+			end = start + indexPageSize
+		}
+
+		writeIndexPage(buf, rows[start:end], page, pages, sections)
+		err := writeFile(ctx, buf, "", indexPageName(page))
+		if err != nil {
+			return err
+		}
+	}
+
+	err := writeIndexFeed(ctx, buf, rows)
+	if err != nil {
+		return err
+	}
+
+	if pages == 1 || noJS {
+		return nil
+	}
+
+	var filterRows [][]interface{}
+	for _, row := range rows {
+		filterRows = append(filterRows, []interface{}{row.OutPkg, row.Synopsis, row.Link, row.Package, row.Command, displayPath(row.OutPkg)})
+	}
+	data, err := json.Marshal(filterRows)
+	if err != nil {
+		return err
+	}
+
+	buf.Reset()
+	buf.Write(data)
+	return writeFile(ctx, buf, "lib", "index-filter.json")
+}
+
+// themeScriptTag returns the element loading theme.js, unless scripts are
+// omitted.
+func themeScriptTag(basePath string) string {
+	if noJS {
+		return ""
+	}
+	return `<script src="` + basePath + `lib/theme.js"></script>
+`
+}
+
+// sitePageHeader returns the markup preceding the content of a page written
+// to the root of the site.
+func sitePageHeader(title string) string {
+	return pageHeader(title, "")
+}
+
+// pageHeader returns the markup preceding the content of a page which links
+// to the root of the site using basePath.
+func pageHeader(title string, basePath string) string {
+	return `<!DOCTYPE html>
+<html>
+<head>
+<meta http-equiv="Content-Type" content="text/html; charset=utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="theme-color" content="#375EAB">
+<title>` + title + `</title>
+<link type="text/css" rel="stylesheet" href="` + basePath + `lib/style.css">
+` + themeScriptTag(basePath) + extraTags(basePath) + `</head>
+<body>
+
+<div id="lowframe" style="position: fixed; bottom: 0; left: 0; height: 0; width: 100%; border-top: thin solid grey; background-color: white; overflow: auto;">
+...
+</div><!-- #lowframe -->
+
+<div id="topbar" class="wide">` + topBar(basePath, siteName) + `</div>
+<div id="page" class="wide">
+<div class="container">
+`
+}
+
+func writeIndexPage(buf *bytes.Buffer, rows []indexRow, page int, pages int, sections bool) {
+	var index string
+	if linkIndex {
+		index = "/index.html"
+	}
+
+	title := siteName
+	if page > 0 {
+		title += " - Page " + strconv.Itoa(page+1)
+	}
+
+	buf.Reset()
+	buf.WriteString(sitePageHeader(title))
+
+	// The content of the page is replaced by index.html when it is defined.
+	contentStart := buf.Len()
+
+	if siteDescription != "" && page == 0 {
+		buf.WriteString(siteDescription)
+	}
+
+	buf.WriteString(`
+<h1>
+	Packages
+</h1>
+`)
+
+	buf.WriteString(`<p><a href="` + siteMapPage + `">Site map</a>`)
+	if symbolIndex {
+		buf.WriteString(` - <a href="` + symbolIndexPage + `">Index of all symbols</a>`)
+	}
+	if fuzzTargets {
+		buf.WriteString(` - <a href="` + fuzzTargetsPage + `">Fuzz targets</a>`)
+	}
+	if len(noteMarkers) > 0 {
+		buf.WriteString(` - <a href="` + notesPage + `">Notes</a>`)
+	}
+	if deprecations {
+		buf.WriteString(` - <a href="` + deprecationsPage + `">Deprecations</a>`)
+	}
+	if importGraph {
+		buf.WriteString(` - <a href="` + importGraphPage + `">Import graph</a>`)
+	}
+	if licenses {
+		buf.WriteString(` - <a href="` + licensesPage + `">Licenses</a>`)
+	}
+	if len(skippedDocs) > 0 || len(skippedSources) > 0 {
+		buf.WriteString(` - <a href="` + statusPage + `">Generation status</a>`)
+	}
+	if ownersFile != "" {
+		buf.WriteString(` - <a href="` + ownersPage + `">Packages by owner</a>`)
+	}
+	buf.WriteString(`</p>
+`)
+
+	if (len(moduleLandingPages) > 0 || len(moduleDependencyPages) > 0 || len(moduleInfoPages) > 0 || len(moduleVendorPages) > 0) && page == 0 {
+		buf.WriteString(`<h2 id="pkg-modules">Modules</h2>
+<ul>
+`)
+		for _, name := range moduleNames() {
+			landingPage, hasLanding := moduleLandingPages[name]
+			infoPage, hasInfo := moduleInfoPages[name]
+			dependenciesPage, hasDependencies := moduleDependencyPages[name]
+			vendorPage, hasVendor := moduleVendorPages[name]
+			if !hasLanding && !hasInfo && !hasDependencies && !hasVendor {
+				continue
+			}
+
+			item := html.EscapeString(displayPath(vanityPath(name)))
+			if hasLanding {
+				item = `<a href="` + landingPage + `">` + item + `</a>`
+			}
+			if hasInfo {
+				item += ` - <a href="` + infoPage + `">go.mod</a>`
+			}
+			if hasDependencies {
+				item += ` - <a href="` + dependenciesPage + `">Dependencies</a>`
+			}
+			if hasVendor {
+				item += ` - <a href="` + vendorPage + `">Vendored modules</a>`
+			}
+			buf.WriteString(`<li>` + item + `</li>
+`)
+		}
+		buf.WriteString(`</ul>
+`)
+	}
+
+	if pages > 1 && !noJS {
+		buf.WriteString(`<div class="pkg-filter js-only"><input type="search" id="pkg-filter" placeholder="Filter all packages" aria-label="Filter all packages"></div>
+<div id="pkg-filter-results" class="pkg-dir"></div>
+` + indexPagination(page, pages) + `
+`)
+	}
+
+	buf.WriteString(`<div class="pkg-dir" id="pkg-list">
+`)
+	if len(workspaceModules) > 1 {
+		writeWorkspaceIndexList(buf, rows, index)
+	} else if !sections {
+		currentTheme().IndexList(buf, rows, index)
+	} else {
+		var libraries, commands []indexRow
+		for _, row := range rows {
+			if row.Command {
+				commands = append(commands, row)
+			} else {
+				libraries = append(libraries, row)
+			}
+		}
+
+		if len(libraries) > 0 {
+			buf.WriteString(`<h2 id="pkg-libraries">Libraries</h2>
+`)
+			currentTheme().IndexList(buf, libraries, index)
+		}
+		if len(commands) > 0 {
+			buf.WriteString(`<h2 id="pkg-commands">Commands</h2>
+`)
+			currentTheme().IndexList(buf, commands, index)
+		}
+	}
+	buf.WriteString(`</div>
+` + indexPagination(page, pages) + `
+`)
+
+	var description string
+	if page == 0 {
+		description = siteDescription
+	}
+	out, ok := executeTemplate(templateIndex, &indexTemplateData{
+		SiteName:    siteName,
+		Description: template.HTML(description),
+		Page:        page + 1,
+		Pages:       pages,
+		Packages:    indexTemplatePackages(rows, index),
+		Content:     template.HTML(buf.String()[contentStart:]),
+	})
+	if ok {
+		buf.Truncate(contentStart)
+		buf.WriteString(out)
+	}
+
+	buf.WriteString(`<div id="footer">` + siteFooterText("") + `</div>
+</div>
+</div>
+` + searchTags(""))
+
+	if pages > 1 && !noJS {
+		buf.WriteString(`<script>` + strings.Replace(indexFilterJS, "{{index}}", index, 1) + `</script>
+`)
+	}
+
+	buf.WriteString(`</body>
+</html>
+`)
+}
+
+// writeIndexTable writes a table listing packages, indenting each package
+// beneath the packages it shares a path with.
+func writeIndexTable(buf *bytes.Buffer, rows []indexRow, index string) {
+	buf.WriteString(`	<table>
+		<tr>
+			<th class="pkg-name">Name</th>
+			<th class="pkg-synopsis">Synopsis</th>
+		</tr>
+`)
+
+	var padding int
+	var lastPkg string
+	for _, row := range rows {
+		displayPkg := displayPath(row.OutPkg)
+		pkgLabel := displayPkg
+		if lastPkg != "" {
+			lastPkgSplit := strings.Split(lastPkg, "/")
+			pkgSplit := strings.Split(displayPkg, "/")
+			shared := 0
+			for i := range pkgSplit {
+				if i < len(lastPkgSplit) && strings.ToLower(lastPkgSplit[i]) == strings.ToLower(pkgSplit[i]) {
+					shared++
+				}
+			}
+
+			padding = shared * 20
+			pkgLabel = strings.Join(pkgSplit[shared:], "/")
+		}
+		lastPkg = displayPkg
+
+		buf.WriteString(`
+		<tr>
+			<td class="pkg-name" style="padding-left: ` + strconv.Itoa(padding) + `px;">`)
+		if row.Package {
+			buf.WriteString(pkgIcon(row.Command))
+		}
+		if !row.Link {
+			buf.WriteString(pkgLabel)
+		} else {
+			buf.WriteString(`<a href="` + row.OutPkg + index + `">` + pkgLabel + `</a>`)
+		}
+		buf.WriteString(moduleBadges(row.Pkg))
+		buf.WriteString(ownerBadges(row.Pkg))
+		buf.WriteString(listFailureBadge(row.Pkg))
+		buf.WriteString(`</td>
+			<td class="pkg-synopsis">
+				` + row.Synopsis + `
+			</td>
+		</tr>
+`)
+	}
+	buf.WriteString(`
+	</table>
+`)
+}
+
+// writeIndexCards writes a list of cards describing packages.
+func writeIndexCards(buf *bytes.Buffer, rows []indexRow, index string) {
+	buf.WriteString(`	<ul class="pkg-cards">
+`)
+	for _, row := range rows {
+		if !row.Package && !row.Link {
+			continue
+		}
+
+		buf.WriteString(`		<li class="pkg-card">
+			<div class="pkg-card-name">`)
+		if row.Package {
+			buf.WriteString(pkgIcon(row.Command))
+		}
+		if !row.Link {
+			buf.WriteString(displayPath(row.OutPkg))
+		} else {
+			buf.WriteString(`<a href="` + row.OutPkg + index + `">` + displayPath(row.OutPkg) + `</a>`)
+		}
+		buf.WriteString(moduleBadges(row.Pkg))
+		buf.WriteString(ownerBadges(row.Pkg))
+		buf.WriteString(listFailureBadge(row.Pkg))
+		buf.WriteString(`</div>
+`)
+		if row.Synopsis != "" {
+			buf.WriteString(`			<p class="pkg-card-synopsis">` + row.Synopsis + `</p>
+`)
+		}
+		buf.WriteString(`		</li>
+`)
+	}
+	buf.WriteString(`	</ul>
+`)
+}
+
+// indexFilterJS filters the packages of all index pages.
+const indexFilterJS = `(function() {
+	var input = document.getElementById('pkg-filter');
+	var results = document.getElementById('pkg-filter-results');
+	var list = document.getElementById('pkg-list');
+	var pages = document.querySelectorAll('.pkg-pages');
+	var rows = null;
+
+	function show(filtering) {
+		list.style.display = filtering ? 'none' : '';
+		for (var i = 0; i < pages.length; i++) {
+			pages[i].style.display = filtering ? 'none' : '';
+		}
+	}
+
+	function render(q) {
+		var table = document.createElement('table');
+		var matches = 0;
+		rows.forEach(function(row) {
+			if (row[0].toLowerCase().indexOf(q) < 0 && row[1].toLowerCase().indexOf(q) < 0) {
+				return;
+			}
+			matches++;
+			var tr = document.createElement('tr');
+			var name = document.createElement('td');
+			name.className = 'pkg-name';
+			if (row[3]) {
+				var icon = document.createElement('span');
+				icon.className = 'pkg-icon ' + (row[4] ? 'pkg-icon-cmd' : 'pkg-icon-lib');
+				icon.title = row[4] ? 'Command' : 'Library';
+				icon.setAttribute('aria-hidden', 'true');
+				icon.appendChild(document.createTextNode(row[4] ? '>_' : '{}'));
+				name.appendChild(icon);
+			}
+			if (row[2]) {
+				var a = document.createElement('a');
+				a.href = row[0] + '{{index}}';
+				a.appendChild(document.createTextNode(row[5]));
+				name.appendChild(a);
+			} else {
+				name.appendChild(document.createTextNode(row[5]));
+			}
+			var synopsis = document.createElement('td');
+			synopsis.className = 'pkg-synopsis';
+			synopsis.appendChild(document.createTextNode(row[1]));
+			tr.appendChild(name);
+			tr.appendChild(synopsis);
+			table.appendChild(tr);
+		});
+		results.innerHTML = '';
+		if (matches === 0) {
+			results.appendChild(document.createTextNode('No packages found.'));
+		} else {
+			results.appendChild(table);
+		}
+	}
+
+	input.addEventListener('input', function() {
+		var q = input.value.trim().toLowerCase();
+		if (q === '') {
+			results.innerHTML = '';
+			show(false);
+			return;
+		}
+		show(true);
+		if (rows) {
+			render(q);
+			return;
+		}
+		fetch('lib/index-filter.json').then(function(res) {
+			return res.json();
+		}).then(function(data) {
+			rows = data;
+			render(input.value.trim().toLowerCase());
+		});
+	});
+})();
+`
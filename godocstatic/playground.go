@@ -0,0 +1,158 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const defaultPlaygroundURL = "https://play.golang.org"
+
+var (
+	playground    bool
+	playgroundURL string
+)
+
+var (
+	// playgroundShares maps the programs shared with the playground to the
+	// URLs they may be run at.
+	playgroundShares     = make(map[string]string)
+	playgroundSharesLock sync.Mutex
+)
+
+const playgroundCSS = `
+.example-run { margin: 0.625rem 0; }
+.example-run a { display: inline-block; padding: 0.3125rem 0.625rem; color: var(--link); border: 0.0625rem solid var(--border); border-radius: 0.3125rem; text-decoration: none; }
+.example-run a:hover { background: var(--heading-background); }
+`
+
+// shareProgram shares a program using the share API of the playground,
+// returning the URL it may be run at.
+func shareProgram(ctx context.Context, src []byte) (string, error) {
+	playgroundSharesLock.Lock()
+	u, ok := playgroundShares[string(src)]
+	playgroundSharesLock.Unlock()
+	if ok {
+		return u, nil
+	}
+
+	base := strings.TrimSuffix(playgroundURL, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/share", bytes.NewReader(src))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	} else if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	id := strings.TrimSpace(string(body))
+	if id == "" || strings.ContainsAny(id, "/?#<>\"") {
+		return "", fmt.Errorf("unexpected response %q", id)
+	}
+	u = base + "/p/" + id
+
+	playgroundSharesLock.Lock()
+	playgroundShares[string(src)] = u
+	playgroundSharesLock.Unlock()
+	return u, nil
+}
+
+// playableExamples returns the programs running the examples of a package,
+// by the IDs of the elements the examples are displayed in. Only examples
+// declared in an external test package whose imports may be resolved can
+// be run as programs.
+func playableExamples(p *nativePackage) (map[string][]byte, error) {
+	fset, examples, err := packageExamples(p)
+	if err != nil {
+		return nil, err
+	}
+
+	programs := make(map[string][]byte)
+	for _, ex := range examples {
+		if ex.Play == nil {
+			continue
+		}
+
+		var buf bytes.Buffer
+		err := format.Node(&buf, fset, ex.Play)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range exampleIDs(ex) {
+			programs[id] = buf.Bytes()
+		}
+	}
+	return programs, nil
+}
+
+// addPlaygroundLinks adds links running the examples of a package in the
+// playground beneath their code. Examples which fail to be shared are not
+// linked.
+func addPlaygroundLinks(ctx context.Context, doc *goquery.Document, pkg string) error {
+	if !playground {
+		return nil
+	}
+
+	examples := doc.Find(`details[id^="example_"]`)
+	if examples.Length() == 0 {
+		return nil
+	}
+
+	p, err := loadNativePackage(ctx, pkg)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	} else if err != nil {
+		return nil // This is expected for packages without source files
+	}
+
+	programs, err := playableExamples(p)
+	if err != nil {
+		return err
+	}
+
+	examples.EachWithBreak(func(_ int, example *goquery.Selection) bool {
+		src, ok := programs[example.AttrOr("id", "")]
+		if !ok {
+			return true
+		}
+
+		// Examples which fail to be shared, as when the playground is
+		// unreachable, are not linked.
+		u, shareErr := shareProgram(ctx, src)
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			return false
+		} else if shareErr != nil {
+			logger.Printf("Warning: failed to share %s of %s with the playground: %s", example.AttrOr("id", ""), pkg, shareErr)
+			return true
+		}
+
+		code := example.Find("pre.code").First()
+		if code.Length() == 0 {
+			return true
+		}
+		code.AfterHtml(`<p class="example-run"><a href="` + html.EscapeString(u) + `" target="_blank" rel="noopener">Run in the Go Playground</a></p>`)
+		return true
+	})
+	return err
+}
@@ -0,0 +1,59 @@
+package godocstatic
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var priority string
+
+// priorityPatterns matches the packages whose pages are written first, in
+// order of priority.
+var priorityPatterns []*regexp.Regexp
+
+// parsePriority compiles the comma-separated package patterns of -priority.
+func parsePriority() error {
+	priorityPatterns = nil
+	for _, p := range strings.Split(priority, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		re, err := packagePattern(p)
+		if err != nil {
+			return fmt.Errorf("invalid priority pattern %s: %s", p, err)
+		}
+		priorityPatterns = append(priorityPatterns, re)
+	}
+	return nil
+}
+
+// packagePriority returns the position of the first -priority pattern
+// matching a package by import path or vanity path, or the number of patterns
+// when none match.
+func packagePriority(pkg string) int {
+	for i, re := range priorityPatterns {
+		if re.MatchString(pkg) || re.MatchString(vanityPath(pkg)) {
+			return i
+		}
+	}
+	return len(priorityPatterns)
+}
+
+// prioritizePackages returns packages in the order their pages are written:
+// packages matching earlier -priority patterns first, otherwise in the order
+// supplied.
+func prioritizePackages(pkgs []string) []string {
+	if len(priorityPatterns) == 0 {
+		return pkgs
+	}
+
+	ordered := append([]string{}, pkgs...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return packagePriority(ordered[i]) < packagePriority(ordered[j])
+	})
+	return ordered
+}
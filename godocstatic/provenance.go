@@ -0,0 +1,130 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"go/build"
+	"html"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var provenance bool
+
+// sourceProvenance describes the source a page was generated from.
+type sourceProvenance struct {
+	Module  string
+	Version string
+	Commit  string
+}
+
+var (
+	provenanceDirs     = make(map[string]*sourceProvenance)
+	provenanceDirsLock sync.Mutex
+)
+
+// generatorVersion returns the version of godoc-static.
+func generatorVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// commandOutput returns the trimmed output of a command run in dir, or an
+// empty string when it fails.
+func commandOutput(ctx context.Context, dir string, name string, args ...string) string {
+	var buf bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = godocEnv
+	cmd.Dir = dir
+	cmd.Stdout = &buf
+	setDeathSignal(cmd)
+
+	err := cmd.Run()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// packageProvenance returns the module, version and commit a package is
+// generated from. The version of a module which is checked out is described
+// by git, and the version of a module in the module cache is read from its
+// directory name.
+func packageProvenance(ctx context.Context, pkg string) *sourceProvenance {
+	dir := pkgDirs[pkg]
+	module := ""
+	if m := packageModule(pkg); m != nil {
+		dir = m.Dir
+		module = m.Path
+	}
+	if dir == "" {
+		return &sourceProvenance{}
+	}
+
+	provenanceDirsLock.Lock()
+	defer provenanceDirsLock.Unlock()
+
+	if p, ok := provenanceDirs[dir]; ok {
+		return p
+	}
+
+	p := &sourceProvenance{Module: module}
+	if goRoot := filepath.Join(build.Default.GOROOT, "src"); dir == goRoot || strings.HasPrefix(dir, goRoot+string(filepath.Separator)) {
+		p.Module = "std"
+		p.Version = commandOutput(ctx, getTmpDir(), "go", "env", "GOVERSION")
+	} else if at := strings.LastIndex(filepath.Base(dir), "@"); at >= 0 {
+		p.Version = filepath.Base(dir)[at+1:]
+	} else {
+		p.Commit = commandOutput(ctx, dir, "git", "rev-parse", "HEAD")
+		if p.Commit != "" {
+			p.Version = commandOutput(ctx, dir, "git", "describe", "--tags", "--always", "--dirty")
+		}
+	}
+	provenanceDirs[dir] = p
+	return p
+}
+
+// addProvenance records the source a page was generated from and the version
+// of godoc-static in data attributes of the page, and in its footer.
+func addProvenance(ctx context.Context, doc *goquery.Document, pkg string) {
+	if !provenance {
+		return
+	}
+
+	p := packageProvenance(ctx, pkg)
+
+	root := doc.Find("html").First()
+	root.SetAttr("data-generator", "godoc-static "+generatorVersion())
+	if p.Module != "" {
+		root.SetAttr("data-module", p.Module)
+	}
+	if p.Version != "" {
+		root.SetAttr("data-module-version", p.Version)
+	}
+	if p.Commit != "" {
+		root.SetAttr("data-source-commit", p.Commit)
+	}
+
+	from := p.Module
+	if from == "" {
+		from = pkg
+	}
+	if p.Version != "" {
+		from += "@" + p.Version
+	}
+	if p.Commit != "" {
+		commit := p.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		from += " (commit " + commit + ")"
+	}
+	doc.Find("#footer").Last().AppendHtml(`<p class="provenance">Generated from ` + html.EscapeString(from) + ` by godoc-static ` + html.EscapeString(generatorVersion()) + `</p>`)
+}
@@ -0,0 +1,111 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var (
+	publishTarget string
+	publishDelete bool
+)
+
+func registerPublishFlags(flags *flag.FlagSet) {
+	flags.StringVar(&siteDestination, "destination", "", "path to generated site")
+	flags.StringVar(&publishTarget, "target", "", "path to publish site to")
+	flags.BoolVar(&publishDelete, "delete", false, "delete files in target which are not part of the generated site")
+	registerCommonFlags(flags)
+}
+
+// siteFiles returns the paths of all files within a generated site, relative
+// to its root.
+func siteFiles(root string) (map[string]bool, error) {
+	files := make(map[string]bool)
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = true
+		return nil
+	})
+	return files, err
+}
+
+func runPublish(ctx context.Context, args []string) error {
+	if siteDestination == "" {
+		return errors.New("--destination must be set")
+	} else if publishTarget == "" {
+		return errors.New("--target must be set")
+	}
+
+	files, err := siteFiles(siteDestination)
+	if err != nil {
+		return fmt.Errorf("failed to list files of %s: %s", siteDestination, err)
+	}
+
+	var copied int
+	for file := range files {
+		src := filepath.Join(siteDestination, filepath.FromSlash(file))
+		dst := filepath.Join(publishTarget, filepath.FromSlash(file))
+
+		data, err := ioutil.ReadFile(src)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %s", src, err)
+		}
+
+		existing, err := ioutil.ReadFile(dst)
+		if err == nil && bytes.Equal(existing, data) {
+			continue
+		}
+
+		err = os.MkdirAll(filepath.Dir(dst), 0755)
+		if err != nil {
+			return fmt.Errorf("failed to make directory %s: %s", filepath.Dir(dst), err)
+		}
+
+		err = ioutil.WriteFile(dst, data, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %s", dst, err)
+		}
+		copied++
+	}
+
+	var deleted int
+	if publishDelete {
+		targetFiles, err := siteFiles(publishTarget)
+		if err != nil {
+			return fmt.Errorf("failed to list files of %s: %s", publishTarget, err)
+		}
+
+		for file := range targetFiles {
+			if files[file] {
+				continue
+			}
+
+			err = os.Remove(filepath.Join(publishTarget, filepath.FromSlash(file)))
+			if err != nil {
+				return fmt.Errorf("failed to delete %s: %s", file, err)
+			}
+			deleted++
+		}
+	}
+
+	if verbose {
+		logger.Printf("Published %s to %s: %d files updated, %d deleted.", siteDestination, publishTarget, copied, deleted)
+	}
+	return nil
+}
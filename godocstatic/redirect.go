@@ -0,0 +1,138 @@
+package godocstatic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type redirect struct {
+	From string
+	To   string
+}
+
+// readRedirects reads a redirects file consisting of an old import path and
+// a new import path or URL separated by whitespace, one per line. Blank lines
+// and lines starting with # are ignored.
+func readRedirects(redirectsPath string) ([]redirect, error) {
+	f, err := os.Open(redirectsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var redirects []redirect
+
+	scanner := bufio.NewScanner(f)
+	var line int
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid redirect on line %d: expected old-path new-path", line)
+		}
+
+		redirects = append(redirects, redirect{
+			From: strings.Trim(fields[0], "/"),
+			To:   fields[1],
+		})
+	}
+	return redirects, scanner.Err()
+}
+
+func isURL(s string) bool {
+	return strings.Contains(s, "://")
+}
+
+// redirectTarget returns the URL a redirect points to, relative to basePath
+// when it points to a documented package.
+func redirectTarget(r redirect, basePath string) string {
+	if isURL(r.To) {
+		return r.To
+	}
+
+	target := basePath + vanityPath(strings.Trim(r.To, "/")) + "/"
+	if linkIndex {
+		target += "index.html"
+	}
+	return target
+}
+
+func redirectPage(r redirect) string {
+	target := html.EscapeString(redirectTarget(r, relativeBasePath(r.From)))
+	name := html.EscapeString(r.To)
+	script := symbolRedirectScript("", relativeBasePath(r.From), symbolRedirects[r.From])
+	if script != "" {
+		script += "\n"
+	}
+	return `<!DOCTYPE html>
+<html>
+<head>
+<meta http-equiv="Content-Type" content="text/html; charset=utf-8">
+` + script + `<meta http-equiv="refresh" content="0; url=` + target + `">
+<meta name="robots" content="noindex">
+<link rel="canonical" href="` + target + `">
+<title>` + html.EscapeString(r.From) + ` has moved - ` + siteName + `</title>
+</head>
+<body>
+<p>` + html.EscapeString(r.From) + ` has moved to <a href="` + target + `">` + name + `</a>.</p>
+</body>
+</html>
+`
+}
+
+// writeRedirects writes a stub page for each redirect, along with redirect
+// rules for static hosts supporting _redirects files and Apache.
+func writeRedirects(ctx context.Context, buf *bytes.Buffer) error {
+	redirects, err := readRedirects(redirectsFile)
+	if err != nil {
+		return fmt.Errorf("failed to read redirects file %s: %s", redirectsFile, err)
+	}
+
+	var rules, htaccess strings.Builder
+	for _, r := range redirects {
+		err = os.MkdirAll(filepath.Join(siteDestination, r.From), 0755)
+		if err != nil {
+			return fmt.Errorf("failed to make directory %s: %s", r.From, err)
+		}
+
+		buf.Reset()
+		buf.WriteString(redirectPage(r))
+		err = writeFile(ctx, buf, r.From, "index.html")
+		if err != nil {
+			return err
+		}
+
+		target := redirectTarget(r, "/")
+		if isURL(r.To) {
+			rules.WriteString(fmt.Sprintf("/%s/* %s 301\n", r.From, target))
+			htaccess.WriteString(fmt.Sprintf("RedirectMatch 301 ^/%s(/.*)?$ %s\n", regexp.QuoteMeta(r.From), target))
+		} else {
+			rules.WriteString(fmt.Sprintf("/%s/* %s:splat 301\n", r.From, target))
+			htaccess.WriteString(fmt.Sprintf("RedirectMatch 301 ^/%s(?:/(.*))?$ %s$1\n", regexp.QuoteMeta(r.From), target))
+		}
+	}
+
+	buf.Reset()
+	buf.WriteString(rules.String())
+	err = writeFile(ctx, buf, "", "_redirects")
+	if err != nil {
+		return err
+	}
+
+	buf.Reset()
+	buf.WriteString(htaccess.String())
+	return writeFile(ctx, buf, "", ".htaccess")
+}
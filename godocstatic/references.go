@@ -0,0 +1,230 @@
+package godocstatic
+
+import (
+	"context"
+	"fmt"
+	"go/doc"
+	"go/doc/comment"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var references bool
+
+// packageReferences lists the documented packages referred to by the doc
+// comments of each package, with the symbols of each package referred to.
+// References to a package itself are listed as an empty string.
+var packageReferences map[string]map[string][]string
+
+// docLinks calls fn with each link to another package or its symbols in a
+// doc comment.
+func docLinks(blocks []comment.Block, fn func(link *comment.DocLink)) {
+	var texts func(text []comment.Text)
+	texts = func(text []comment.Text) {
+		for _, t := range text {
+			switch t := t.(type) {
+			case *comment.DocLink:
+				if t.ImportPath != "" {
+					fn(t)
+				}
+			case *comment.Link:
+				texts(t.Text)
+			}
+		}
+	}
+
+	for _, block := range blocks {
+		switch block := block.(type) {
+		case *comment.Paragraph:
+			texts(block.Text)
+		case *comment.Heading:
+			texts(block.Text)
+		case *comment.List:
+			for _, item := range block.Items {
+				docLinks(item.Content, fn)
+			}
+		}
+	}
+}
+
+// packageDocComments returns the doc comments of a package and its exported
+// declarations.
+func packageDocComments(d *doc.Package) []string {
+	comments := []string{d.Doc}
+	addValues := func(values []*doc.Value) {
+		for _, v := range values {
+			comments = append(comments, v.Doc)
+		}
+	}
+	addFuncs := func(funcs []*doc.Func) {
+		for _, f := range funcs {
+			comments = append(comments, f.Doc)
+		}
+	}
+
+	addValues(d.Consts)
+	addValues(d.Vars)
+	addFuncs(d.Funcs)
+	for _, t := range d.Types {
+		comments = append(comments, t.Doc)
+		addValues(t.Consts)
+		addValues(t.Vars)
+		addFuncs(t.Funcs)
+		addFuncs(t.Methods)
+	}
+	return comments
+}
+
+// loadReferences finds the references between the doc comments of the
+// documented packages, written as [pkg] or [pkg.Name].
+func loadReferences(ctx context.Context, pkgs []string) error {
+	packageReferences = make(map[string]map[string][]string)
+	for _, pkg := range pkgs {
+		if _, ok := listFailures[pkg]; ok {
+			continue
+		}
+
+		p, err := loadNativePackage(ctx, pkg)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		} else if err != nil {
+			continue // This is expected for packages without source files
+		}
+
+		_, d, err := parsePackageDoc(p, 0)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %s", pkg, err)
+		} else if d == nil {
+			continue
+		}
+
+		refs := make(map[string][]string)
+		parser := d.Parser()
+		for _, text := range packageDocComments(d) {
+			if text == "" {
+				continue
+			}
+			docLinks(parser.Parse(text).Content, func(link *comment.DocLink) {
+				if link.ImportPath == pkg || !documentedPackages[link.ImportPath] {
+					return
+				}
+
+				symbol := link.Name
+				if link.Recv != "" {
+					symbol = link.Recv + "." + link.Name
+				}
+				refs[link.ImportPath] = append(refs[link.ImportPath], symbol)
+			})
+		}
+		for target, symbols := range refs {
+			sort.Strings(symbols)
+			refs[target] = uniqueStrings(symbols)
+		}
+		if len(refs) > 0 {
+			packageReferences[pkg] = refs
+		}
+	}
+	return nil
+}
+
+// referencesKey describes the references from and to the doc comments of a
+// package, which are listed on its page, so that its cached pages are not
+// reused when the doc comments of another package change what they refer to.
+func referencesKey(pkg string) string {
+	var lines []string
+	for from, refs := range packageReferences {
+		for target, symbols := range refs {
+			if from == pkg || target == pkg {
+				lines = append(lines, from+" "+target+" "+strings.Join(symbols, ","))
+			}
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// referenceList returns a list of packages, each followed by the symbols
+// referred to. Symbols link to the package they are declared in, which is the
+// current package when local is set.
+func referenceList(refs map[string][]string, basePath string, local bool) string {
+	var index string
+	if linkIndex {
+		index = "index.html"
+	}
+
+	pkgs := make([]string, 0, len(refs))
+	for pkg := range refs {
+		pkgs = append(pkgs, pkg)
+	}
+	sortByVanityPath(pkgs)
+
+	var b strings.Builder
+	b.WriteString(`<ul>
+`)
+	for _, pkg := range pkgs {
+		outPkg := vanityPath(pkg)
+		pkgURL := basePath + outPkg + "/" + index
+
+		symbolURL := pkgURL
+		if local {
+			symbolURL = ""
+		}
+		var symbols []string
+		for _, symbol := range refs[pkg] {
+			if symbol != "" {
+				symbols = append(symbols, `<a href="`+symbolURL+`#`+html.EscapeString(symbol)+`">`+html.EscapeString(symbol)+`</a>`)
+			}
+		}
+
+		b.WriteString(`<li><a href="` + pkgURL + `">` + html.EscapeString(displayPath(outPkg)) + `</a>`)
+		if len(symbols) > 0 {
+			b.WriteString(`: ` + strings.Join(symbols, ", "))
+		}
+		b.WriteString(`</li>
+`)
+	}
+	b.WriteString(`</ul>
+`)
+	return b.String()
+}
+
+// addReferences adds a See also section to the page of a package, listing the
+// documented packages its doc comments refer to and the packages whose doc
+// comments refer to it.
+func addReferences(doc *goquery.Document, pkg string, basePath string) {
+	if !references {
+		return
+	}
+
+	referencedBy := make(map[string][]string)
+	for from, refs := range packageReferences {
+		if symbols, ok := refs[pkg]; ok {
+			referencedBy[from] = symbols
+		}
+	}
+
+	refs := packageReferences[pkg]
+	if len(refs) == 0 && len(referencedBy) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(`<div id="pkg-see-also">
+<h2>See also</h2>
+`)
+	if len(refs) > 0 {
+		b.WriteString(`<h3 id="pkg-references">References</h3>
+` + referenceList(refs, basePath, false))
+	}
+	if len(referencedBy) > 0 {
+		b.WriteString(`<h3 id="pkg-referenced-by">Referenced by</h3>
+` + referenceList(referencedBy, basePath, true))
+	}
+	b.WriteString(`</div>`)
+
+	doc.Find("#footer").Last().BeforeHtml(b.String())
+	doc.Find("#short-nav").First().Find("dl").Last().AppendHtml(`<dd><a href="#pkg-see-also">See also</a></dd>`)
+}
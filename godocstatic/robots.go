@@ -0,0 +1,171 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	robotsAllow = "allow"
+	robotsDeny  = "deny"
+	robotsNone  = "none"
+)
+
+var (
+	robots         string
+	robotsDisallow string
+	noindexPattern string
+)
+
+var (
+	// noindexPatterns matches the packages whose pages are excluded from
+	// search engine indexes.
+	noindexPatterns []*regexp.Regexp
+
+	// noindexPages lists the pages excluded from search engine indexes, which
+	// are omitted from sitemap.xml.
+	noindexPages     = make(map[string]bool)
+	noindexPagesLock sync.Mutex
+)
+
+func validateRobots() error {
+	switch robots {
+	case robotsAllow, robotsDeny, robotsNone:
+		return nil
+	default:
+		return fmt.Errorf("unknown robots policy %s: must be one of %s, %s, %s", robots, robotsAllow, robotsDeny, robotsNone)
+	}
+}
+
+// packagePattern compiles a package pattern. As with the go command, "..."
+// matches any string, and a pattern ending in /... also matches the package
+// before it.
+func packagePattern(p string) (*regexp.Regexp, error) {
+	expr := strings.Replace(regexp.QuoteMeta(p), `\.\.\.`, `.*`, -1)
+	if strings.HasSuffix(expr, `/.*`) {
+		expr = strings.TrimSuffix(expr, `/.*`) + `(/.*)?`
+	}
+	return regexp.Compile("^" + expr + "$")
+}
+
+// validateNoindexPattern compiles the comma-separated package patterns of
+// -noindex-pattern.
+func validateNoindexPattern() error {
+	noindexPatterns = nil
+	for _, p := range strings.Split(noindexPattern, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		re, err := packagePattern(p)
+		if err != nil {
+			return fmt.Errorf("invalid noindex pattern %s: %s", p, err)
+		}
+		noindexPatterns = append(noindexPatterns, re)
+	}
+	return nil
+}
+
+// noindexPackage returns whether the pages of a package are excluded from
+// search engine indexes. Packages are matched by import path or vanity path.
+func noindexPackage(pkg string) bool {
+	for _, re := range noindexPatterns {
+		if re.MatchString(pkg) || re.MatchString(vanityPath(pkg)) {
+			return true
+		}
+	}
+	return false
+}
+
+// addNoindex excludes a page of a package matching -noindex-pattern from
+// search engine indexes.
+func addNoindex(doc *goquery.Document, pkg string, page string) {
+	if !noindexPackage(pkg) {
+		return
+	}
+
+	doc.Find("head").First().AppendNodes(metaTag("name", "robots", "noindex"))
+
+	noindexPagesLock.Lock()
+	defer noindexPagesLock.Unlock()
+
+	noindexPages[page] = true
+}
+
+// siteBasePath returns the absolute path the site is served from, as
+// specified by -base-url, for pages which may be served at any path.
+func siteBasePath() string {
+	if baseURL != "" {
+		u, err := url.Parse(baseURL)
+		if err == nil {
+			return strings.TrimSuffix(u.Path, "/") + "/"
+		}
+	}
+	return "/"
+}
+
+// writeRobots writes robots.txt, allowing or denying crawlers access to the
+// site, except for the paths listed by -robots-disallow.
+func writeRobots(ctx context.Context, buf *bytes.Buffer) error {
+	buf.Reset()
+	buf.WriteString("User-agent: *\n")
+	if robots == robotsDeny {
+		buf.WriteString("Disallow: /\n")
+	} else {
+		var disallowed bool
+		for _, p := range strings.Split(robotsDisallow, ",") {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				continue
+			}
+			if !strings.HasPrefix(p, "/") {
+				p = siteBasePath() + p
+			}
+			buf.WriteString("Disallow: " + p + "\n")
+			disallowed = true
+		}
+		if !disallowed {
+			buf.WriteString("Disallow:\n")
+		}
+	}
+
+	if baseURL != "" && robots != robotsDeny {
+		buf.WriteString("\nSitemap: " + strings.TrimSuffix(baseURL, "/") + "/" + siteMapXML + "\n")
+	}
+	return writeFile(ctx, buf, "", "robots.txt")
+}
+
+// writeNotFoundPage writes 404.html, which links to the site using absolute
+// paths as it may be served in place of any missing page.
+func writeNotFoundPage(ctx context.Context, buf *bytes.Buffer) error {
+	basePath := siteBasePath()
+
+	var index string
+	if linkIndex {
+		index = "index.html"
+	}
+
+	buf.Reset()
+	buf.WriteString(pageHeader("Page not found - "+siteName, basePath))
+	buf.WriteString(`
+<h1>
+	Page not found
+</h1>
+<p>The page you requested does not exist. It may have been moved or removed.</p>
+<p><a href="` + basePath + index + `">Browse all packages</a> - <a href="` + basePath + siteMapPage + `">Site map</a></p>
+<div id="footer">` + siteFooterText(basePath) + `</div>
+</div>
+</div>
+` + searchTags(basePath) + `</body>
+</html>
+`)
+	return writeFile(ctx, buf, "", "404.html")
+}
@@ -0,0 +1,594 @@
+package godocstatic
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	themeSCSS string
+	themeVars stringsFlag
+
+	// themeSCSSCSS is the style sheet compiled from themeSCSS.
+	themeSCSSCSS string
+)
+
+// scssScope holds the variables declared in a block of a style sheet.
+// Variables of the enclosing blocks are visible within the block.
+type scssScope struct {
+	vars   map[string]string
+	parent *scssScope
+}
+
+func newSCSSScope(parent *scssScope) *scssScope {
+	return &scssScope{vars: make(map[string]string), parent: parent}
+}
+
+func (s *scssScope) lookup(name string) (string, bool) {
+	for ; s != nil; s = s.parent {
+		if value, ok := s.vars[name]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func (s *scssScope) global() *scssScope {
+	for s.parent != nil {
+		s = s.parent
+	}
+	return s
+}
+
+// scssNode is a statement of a style sheet: a declaration, a variable
+// assignment or an at-rule ending with a semicolon, or a rule or at-rule
+// followed by a block of statements.
+type scssNode struct {
+	Line     int
+	Prelude  string
+	Block    bool
+	Children []*scssNode
+}
+
+// stripSCSSComments replaces the comments of a style sheet with spaces,
+// preserving line breaks so that line numbers are not affected.
+func stripSCSSComments(src string) string {
+	b := []byte(src)
+	var quote byte
+	for i := 0; i < len(b); i++ {
+		switch {
+		case quote != 0:
+			if b[i] == '\\' {
+				i++
+			} else if b[i] == quote {
+				quote = 0
+			}
+		case b[i] == '"' || b[i] == '\'':
+			quote = b[i]
+		case b[i] == '/' && i+1 < len(b) && b[i+1] == '*':
+			for ; i < len(b) && !(b[i] == '*' && i+1 < len(b) && b[i+1] == '/'); i++ {
+				if b[i] != '\n' {
+					b[i] = ' '
+				}
+			}
+			if i+1 < len(b) {
+				b[i], b[i+1] = ' ', ' '
+				i++
+			}
+		case b[i] == '/' && i+1 < len(b) && b[i+1] == '/' && (i == 0 || b[i-1] != ':'):
+			// Line comments, but not the scheme separator of URLs.
+			for ; i < len(b) && b[i] != '\n'; i++ {
+				b[i] = ' '
+			}
+		}
+	}
+	return string(b)
+}
+
+// parseSCSS parses the statements of a style sheet.
+func parseSCSS(src string) ([]*scssNode, error) {
+	src = stripSCSSComments(src)
+
+	line := 1
+	var parse func(i int, nested bool) ([]*scssNode, int, error)
+	parse = func(i int, nested bool) ([]*scssNode, int, error) {
+		var nodes []*scssNode
+		var prelude strings.Builder
+		start := line
+		var quote byte
+		var parens int
+		for ; i < len(src); i++ {
+			c := src[i]
+			if c == '\n' {
+				line++
+			}
+			if prelude.Len() == 0 && strings.TrimSpace(string(c)) == "" {
+				start = line
+				continue
+			}
+
+			switch {
+			case quote != 0:
+				if c == '\\' && i+1 < len(src) {
+					prelude.WriteByte(c)
+					i++
+					c = src[i]
+				} else if c == quote {
+					quote = 0
+				}
+			case c == '"' || c == '\'':
+				quote = c
+			case c == '(':
+				parens++
+			case c == ')':
+				parens--
+			case c == '#' && i+1 < len(src) && src[i+1] == '{':
+				end := strings.IndexByte(src[i:], '}')
+				if end < 0 {
+					return nil, i, fmt.Errorf("line %d: unterminated interpolation", line)
+				}
+				line += strings.Count(src[i:i+end], "\n")
+				prelude.WriteString(src[i : i+end+1])
+				i += end
+				continue
+			case parens > 0:
+			case c == ';':
+				if p := strings.TrimSpace(prelude.String()); p != "" {
+					nodes = append(nodes, &scssNode{Line: start, Prelude: p})
+				}
+				prelude.Reset()
+				continue
+			case c == '{':
+				node := &scssNode{Line: start, Prelude: strings.TrimSpace(prelude.String()), Block: true}
+				children, end, err := parse(i+1, true)
+				if err != nil {
+					return nil, end, err
+				}
+				node.Children = children
+				nodes = append(nodes, node)
+				prelude.Reset()
+				i = end
+				continue
+			case c == '}':
+				if !nested {
+					return nil, i, fmt.Errorf("line %d: unexpected }", line)
+				}
+				if p := strings.TrimSpace(prelude.String()); p != "" {
+					nodes = append(nodes, &scssNode{Line: start, Prelude: p})
+				}
+				return nodes, i, nil
+			}
+			prelude.WriteByte(c)
+		}
+
+		if nested {
+			return nil, i, fmt.Errorf("line %d: expected }", line)
+		} else if p := strings.TrimSpace(prelude.String()); p != "" {
+			nodes = append(nodes, &scssNode{Line: start, Prelude: p})
+		}
+		return nodes, i, nil
+	}
+
+	nodes, _, err := parse(0, false)
+	return nodes, err
+}
+
+// splitSCSSList splits a comma separated list, such as a selector list,
+// ignoring the commas within parentheses and strings.
+func splitSCSSList(s string) []string {
+	var items []string
+	var quote byte
+	var parens, start int
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			parens++
+		case c == ')':
+			parens--
+		case c == ',' && parens == 0:
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(items, strings.TrimSpace(s[start:]))
+}
+
+// cssRule is a rule of a compiled style sheet. Rules without a selector
+// contain the declarations of an at-rule such as @font-face.
+type cssRule struct {
+	Media    string
+	Selector string
+	Decls    []string
+	// Raw is the text of a rule which is written as is, such as @keyframes.
+	Raw string
+}
+
+// scssContext is the context a block of statements is compiled in.
+type scssContext struct {
+	file      string
+	scope     *scssScope
+	selectors []string
+	media     string
+	// declarations is whether declarations are allowed outside of a rule.
+	declarations bool
+}
+
+var (
+	scssVariable      = regexp.MustCompile(`\$[A-Za-z_][A-Za-z0-9_-]*`)
+	scssInterpolation = regexp.MustCompile(`#\{([^}]*)\}`)
+	scssAdjustColor   = regexp.MustCompile(`\b(lighten|darken)\(\s*(#[0-9A-Fa-f]{3,6})\s*,\s*([0-9.]+)%\s*\)`)
+	scssAlphaColor    = regexp.MustCompile(`\brgba\(\s*(#[0-9A-Fa-f]{3,6})\s*,\s*([0-9.]+)\s*\)`)
+)
+
+// parseHexColor returns the red, green and blue components of a color in
+// hexadecimal notation.
+func parseHexColor(hex string) (float64, float64, float64, bool) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	} else if len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return float64(v >> 16), float64(v >> 8 & 0xff), float64(v & 0xff), true
+}
+
+// adjustLightness changes the lightness of a color, in the HSL color space,
+// by amount percent.
+func adjustLightness(hex string, amount float64) (string, bool) {
+	r, g, b, ok := parseHexColor(hex)
+	if !ok {
+		return "", false
+	}
+	r, g, b = r/255, g/255, b/255
+
+	max, min := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	l := (max + min) / 2
+	var h, s float64
+	if d := max - min; d != 0 {
+		if l > 0.5 {
+			s = d / (2 - max - min)
+		} else {
+			s = d / (max + min)
+		}
+		switch max {
+		case r:
+			h = math.Mod((g-b)/d+6, 6)
+		case g:
+			h = (b-r)/d + 2
+		default:
+			h = (r-g)/d + 4
+		}
+		h /= 6
+	}
+
+	l = math.Max(0, math.Min(1, l+amount/100))
+
+	hue := func(p, q, t float64) float64 {
+		t = math.Mod(t+1, 1)
+		switch {
+		case t < 1.0/6:
+			return p + (q-p)*6*t
+		case t < 0.5:
+			return q
+		case t < 2.0/3:
+			return p + (q-p)*(2.0/3-t)*6
+		}
+		return p
+	}
+	r, g, b = l, l, l
+	if s != 0 {
+		q := l + s - l*s
+		if l < 0.5 {
+			q = l * (1 + s)
+		}
+		p := 2*l - q
+		r, g, b = hue(p, q, h+1.0/3), hue(p, q, h), hue(p, q, h-1.0/3)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", int(math.Round(r*255)), int(math.Round(g*255)), int(math.Round(b*255))), true
+}
+
+// value evaluates the variables, interpolations and color functions of a
+// value.
+func (c *scssContext) value(s string, line int) (string, error) {
+	var err error
+	s = scssInterpolation.ReplaceAllStringFunc(s, func(m string) string {
+		v, e := c.value(scssInterpolation.FindStringSubmatch(m)[1], line)
+		if e != nil {
+			err = e
+		}
+		return strings.Trim(v, `"'`)
+	})
+	s = scssVariable.ReplaceAllStringFunc(s, func(m string) string {
+		v, ok := c.scope.lookup(m[1:])
+		if !ok {
+			err = fmt.Errorf("%s:%d: undefined variable %s", c.file, line, m)
+		}
+		return v
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		evaluated := scssAdjustColor.ReplaceAllStringFunc(s, func(m string) string {
+			match := scssAdjustColor.FindStringSubmatch(m)
+			amount, _ := strconv.ParseFloat(match[3], 64)
+			if match[1] == "darken" {
+				amount = -amount
+			}
+			color, ok := adjustLightness(match[2], amount)
+			if !ok {
+				return m
+			}
+			return color
+		})
+		evaluated = scssAlphaColor.ReplaceAllStringFunc(evaluated, func(m string) string {
+			match := scssAlphaColor.FindStringSubmatch(m)
+			r, g, b, ok := parseHexColor(match[1])
+			if !ok {
+				return m
+			}
+			return fmt.Sprintf("rgba(%d, %d, %d, %s)", int(r), int(g), int(b), match[2])
+		})
+		if evaluated == s {
+			return s, nil
+		}
+		s = evaluated
+	}
+}
+
+// nestSelectors combines the selectors of a nested rule with the selectors of
+// the enclosing rule, replacing & with the enclosing selector.
+func nestSelectors(parents []string, selectors []string) []string {
+	if len(parents) == 0 {
+		return selectors
+	}
+
+	var nested []string
+	for _, parent := range parents {
+		for _, selector := range selectors {
+			if strings.Contains(selector, "&") {
+				nested = append(nested, strings.Replace(selector, "&", parent, -1))
+			} else {
+				nested = append(nested, parent+" "+selector)
+			}
+		}
+	}
+	return nested
+}
+
+// importSCSS resolves the path of a style sheet imported from file, which may
+// omit the .scss extension and the underscore prefixing partials.
+func importSCSS(file string, name string) (string, error) {
+	dir, base := filepath.Dir(name), filepath.Base(name)
+	candidates := []string{base}
+	if filepath.Ext(base) == "" {
+		candidates = []string{base + ".scss", "_" + base + ".scss"}
+	}
+	for _, candidate := range candidates {
+		p := filepath.Join(filepath.Dir(file), dir, candidate)
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("failed to find imported style sheet %s", name)
+}
+
+// compile compiles a block of statements into rules.
+func (c *scssContext) compile(nodes []*scssNode) ([]cssRule, error) {
+	rules := []cssRule{{Media: c.media, Selector: strings.Join(c.selectors, ", ")}}
+	for _, node := range nodes {
+		errorf := func(format string, a ...interface{}) error {
+			return fmt.Errorf("%s:%d: %s", c.file, node.Line, fmt.Sprintf(format, a...))
+		}
+
+		switch {
+		case !node.Block && strings.HasPrefix(node.Prelude, "$"):
+			i := strings.IndexByte(node.Prelude, ':')
+			if i < 0 {
+				return nil, errorf("expected : after variable name")
+			}
+			name, value := strings.TrimSpace(node.Prelude[1:i]), strings.TrimSpace(node.Prelude[i+1:])
+
+			scope := c.scope
+			var isDefault bool
+			for {
+				if strings.HasSuffix(value, "!default") {
+					isDefault = true
+					value = strings.TrimSpace(strings.TrimSuffix(value, "!default"))
+				} else if strings.HasSuffix(value, "!global") {
+					scope = scope.global()
+					value = strings.TrimSpace(strings.TrimSuffix(value, "!global"))
+				} else {
+					break
+				}
+			}
+			if _, ok := c.scope.lookup(name); ok && isDefault {
+				continue
+			}
+
+			evaluated, err := c.value(value, node.Line)
+			if err != nil {
+				return nil, err
+			}
+			scope.vars[name] = evaluated
+		case !node.Block && strings.HasPrefix(node.Prelude, "@import "):
+			for _, name := range splitSCSSList(strings.TrimPrefix(node.Prelude, "@import ")) {
+				unquoted := strings.Trim(name, `"'`)
+				if strings.HasSuffix(unquoted, ".css") || strings.HasPrefix(name, "url(") || strings.Contains(unquoted, "://") {
+					return nil, errorf("style sheet %s must be supplied with -extra-css", name)
+				}
+
+				file, err := importSCSS(c.file, unquoted)
+				if err != nil {
+					return nil, errorf("%s", err)
+				}
+				imported, err := compileSCSSFile(file, *c)
+				if err != nil {
+					return nil, err
+				}
+				rules = append(rules, imported...)
+			}
+		case !node.Block && strings.HasPrefix(node.Prelude, "@charset "):
+			rules = append(rules, cssRule{Raw: node.Prelude + ";\n"})
+		case strings.HasPrefix(node.Prelude, "@media ") && node.Block:
+			media, err := c.value(node.Prelude, node.Line)
+			if err != nil {
+				return nil, err
+			}
+			if c.media != "" {
+				media = c.media + " and " + strings.TrimSpace(strings.TrimPrefix(media, "@media "))
+			}
+
+			nested := *c
+			nested.scope, nested.media = newSCSSScope(c.scope), media
+			nestedRules, err := nested.compile(node.Children)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, nestedRules...)
+		case strings.HasPrefix(node.Prelude, "@") && node.Block:
+			// At-rules such as @font-face and @keyframes are written as is,
+			// with their variables evaluated.
+			if len(c.selectors) > 0 {
+				return nil, errorf("%s must not be nested within a rule", strings.Fields(node.Prelude)[0])
+			}
+
+			prelude, err := c.value(node.Prelude, node.Line)
+			if err != nil {
+				return nil, err
+			}
+
+			nested := scssContext{file: c.file, scope: newSCSSScope(c.scope), declarations: true}
+			nestedRules, err := nested.compile(node.Children)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, cssRule{Media: c.media, Raw: prelude + " {\n" + renderCSSRules(nestedRules) + "}\n"})
+		case strings.HasPrefix(node.Prelude, "@"):
+			return nil, errorf("%s is not supported", strings.Fields(node.Prelude)[0])
+		case node.Block:
+			selectors, err := c.value(node.Prelude, node.Line)
+			if err != nil {
+				return nil, err
+			}
+
+			nested := *c
+			nested.scope = newSCSSScope(c.scope)
+			nested.selectors = nestSelectors(c.selectors, splitSCSSList(selectors))
+			nested.declarations = false
+			nestedRules, err := nested.compile(node.Children)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, nestedRules...)
+		default:
+			if len(c.selectors) == 0 && !c.declarations {
+				return nil, errorf("declaration outside of a rule")
+			}
+
+			i := strings.IndexByte(node.Prelude, ':')
+			if i < 0 {
+				return nil, errorf("expected declaration")
+			}
+			property, err := c.value(strings.TrimSpace(node.Prelude[:i]), node.Line)
+			if err != nil {
+				return nil, err
+			}
+			value, err := c.value(strings.TrimSpace(node.Prelude[i+1:]), node.Line)
+			if err != nil {
+				return nil, err
+			}
+			rules[0].Decls = append(rules[0].Decls, property+": "+value+";")
+		}
+	}
+	return rules, nil
+}
+
+// renderCSSRules writes compiled rules as CSS, one rule per line.
+func renderCSSRules(rules []cssRule) string {
+	var b strings.Builder
+	for _, rule := range rules {
+		text := rule.Raw
+		if text == "" {
+			if len(rule.Decls) == 0 {
+				continue
+			}
+			text = strings.Join(rule.Decls, " ") + "\n"
+			if rule.Selector != "" {
+				text = rule.Selector + " { " + strings.Join(rule.Decls, " ") + " }\n"
+			}
+		}
+		if rule.Media != "" {
+			text = rule.Media + " {\n" + text + "}\n"
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+// compileSCSSFile compiles a style sheet in the context it is imported in.
+func compileSCSSFile(file string, c scssContext) ([]cssRule, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := parseSCSS(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+
+	c.file = file
+	return c.compile(nodes)
+}
+
+// compileThemeSCSS compiles the style sheet supplied with -theme-scss, which
+// is written in the subset of SCSS supporting variables, nested rules and
+// the parent selector &, @import, @media and the lighten, darken and rgba
+// color functions. Variables supplied with -theme-var are declared before
+// the style sheet is compiled, overriding the variables it declares with
+// !default, such as brand colors.
+func compileThemeSCSS() error {
+	themeSCSSCSS = ""
+
+	scope := newSCSSScope(nil)
+	for _, v := range themeVars {
+		i := strings.IndexByte(v, '=')
+		if i <= 0 {
+			return fmt.Errorf("invalid theme variable %s: must be specified as NAME=VALUE", v)
+		}
+		scope.vars[strings.TrimPrefix(v[:i], "$")] = v[i+1:]
+	}
+
+	if themeSCSS == "" {
+		if len(themeVars) > 0 {
+			return fmt.Errorf("theme variables require -theme-scss")
+		}
+		return nil
+	}
+
+	rules, err := compileSCSSFile(themeSCSS, scssContext{scope: scope})
+	if err != nil {
+		return fmt.Errorf("failed to compile %s: %s", themeSCSS, err)
+	}
+	themeSCSSCSS = "\n" + renderCSSRules(rules)
+	return nil
+}
@@ -0,0 +1,884 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	searchModeJSON = "json"
+	searchModeWasm = "wasm"
+)
+
+// Symbol kinds stored in the search index.
+const (
+	searchKindPackage = 'p'
+	searchKindFunc    = 'f'
+	searchKindType    = 't'
+	searchKindMethod  = 'm'
+)
+
+type searchEntry struct {
+	Name    string
+	Package string
+	Kind    byte
+	File    string
+	Line    int
+}
+
+var (
+	searchEntries     []searchEntry
+	searchEntriesLock sync.Mutex
+)
+
+func validateSearchMode() error {
+	switch searchMode {
+	case "", searchModeJSON, searchModeWasm:
+		return nil
+	default:
+		return fmt.Errorf("unknown search mode %s: must be one of %s, %s", searchMode, searchModeJSON, searchModeWasm)
+	}
+}
+
+// addSearchEntries records the exported symbols listed in the index of a
+// package page, along with the location of their declarations.
+func addSearchEntries(ctx context.Context, pkg string, outPkg string, doc *goquery.Document) {
+	if searchMode == "" {
+		return
+	}
+
+	var dir string
+	sources := make(map[string][]byte)
+	sourceLine := func(file string, offset int) int {
+		if dir == "" {
+			dir, _, _ = packageFiles(ctx, pkg)
+		}
+		data, ok := sources[file]
+		if !ok {
+			data, _ = ioutil.ReadFile(filepath.Join(dir, file))
+			sources[file] = data
+		}
+		if dir == "" || offset > len(data) {
+			return 0
+		}
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+
+	entries := []searchEntry{{Name: outPkg, Package: outPkg, Kind: searchKindPackage}}
+	doc.Find("#manual-nav").First().Find("dd > a").Each(func(_ int, selection *goquery.Selection) {
+		href := selection.AttrOr("href", "")
+		if !strings.HasPrefix(href, "#") || strings.HasPrefix(href, "#pkg-") {
+			return
+		}
+
+		kind := byte(searchKindFunc)
+		text := strings.TrimSpace(selection.Text())
+		if strings.HasPrefix(text, "type ") {
+			kind = searchKindType
+		} else if strings.HasPrefix(text, "func (") {
+			kind = searchKindMethod
+		} else if !strings.HasPrefix(text, "func ") {
+			return
+		}
+
+		file, line, offset := declarationSource(doc, href[1:])
+		if offset >= 0 {
+			line = sourceLine(file, offset)
+		}
+		entries = append(entries, searchEntry{Name: href[1:], Package: outPkg, Kind: kind, File: file, Line: line})
+	})
+
+	searchEntriesLock.Lock()
+	defer searchEntriesLock.Unlock()
+
+	searchEntries = append(searchEntries, entries...)
+}
+
+// declarationSource returns the source file and line of a declaration, as
+// linked from its heading on a package page. When godoc links to a selection
+// within the file, the byte offset of the selection is returned instead of a
+// line, as godoc positions the line a few lines above the declaration.
+func declarationSource(doc *goquery.Document, id string) (file string, line int, offset int) {
+	var href string
+	doc.Find(`[id="` + id + `"]`).First().Find("a").EachWithBreak(func(_ int, selection *goquery.Selection) bool {
+		h := selection.AttrOr("href", "")
+		if strings.Contains(h, "#L") {
+			href = h
+			return false
+		}
+		return true
+	})
+	if href == "" {
+		return "", 0, -1
+	}
+
+	hash := strings.LastIndex(href, "#L")
+	line, err := strconv.Atoi(href[hash+2:])
+	if err != nil {
+		return "", 0, -1
+	}
+
+	file = href[:hash]
+	offset = -1
+	if query := strings.IndexRune(file, '?'); query >= 0 {
+		if strings.HasPrefix(file[query:], "?s=") {
+			selection := strings.SplitN(file[query+3:], ":", 2)
+			offset, err = strconv.Atoi(selection[0])
+			if err != nil {
+				offset = -1
+			}
+		}
+		file = file[:query]
+	}
+	return strings.TrimSuffix(path.Base(file), ".html"), line, offset
+}
+
+// searchTags returns the markup which loads the search script on a page.
+func searchTags(basePath string) string {
+	if searchMode == "" {
+		return ""
+	}
+	return `<script src="` + basePath + `lib/search.js" data-base="` + basePath + `" data-mode="` + searchMode + `" data-link-index="` + fmt.Sprint(linkIndex) + `"></script>`
+}
+
+func searchBox() string {
+	if searchMode == "" {
+		return ""
+	}
+	var owner string
+	if ownersFile != "" {
+		owner = `<select id="search-owner" class="search-owner" aria-label="Owner"><option value="">All owners</option></select>`
+	}
+	return `<span class="search-box js-only"><input type="search" id="search" placeholder="Search" aria-label="Search" autocomplete="off">` + owner + `<label class="search-source" title="Link results to their declaration in the source"><input type="checkbox" id="search-source" aria-label="Link to source"> Source</label></span><div id="search-results"></div>`
+}
+
+func sortedSearchEntries() ([]string, []searchEntry) {
+	searchEntriesLock.Lock()
+	defer searchEntriesLock.Unlock()
+
+	sort.Slice(searchEntries, func(i, j int) bool {
+		if searchEntries[i].Package != searchEntries[j].Package {
+			return searchEntries[i].Package < searchEntries[j].Package
+		}
+		return searchEntries[i].Name < searchEntries[j].Name
+	})
+
+	var packages []string
+	for _, entry := range searchEntries {
+		if len(packages) == 0 || packages[len(packages)-1] != entry.Package {
+			packages = append(packages, entry.Package)
+		}
+	}
+
+	entries := make([]searchEntry, len(searchEntries))
+	copy(entries, searchEntries)
+	return packages, entries
+}
+
+// writeSearchIndex writes the search script and index.
+func writeSearchIndex(ctx context.Context, buf *bytes.Buffer) error {
+	asset, err := sharedAsset("search.js", constantAsset(searchJS))
+	if err != nil {
+		return err
+	}
+
+	buf.Reset()
+	buf.Write(asset)
+	err = writeFile(ctx, buf, "lib", "search.js")
+	if err != nil {
+		return err
+	}
+
+	if searchMode == searchModeWasm {
+		return writeWasmSearchIndex(ctx, buf)
+	}
+
+	packages, entries := sortedSearchEntries()
+	packageIndex := make(map[string]int)
+	for i, pkg := range packages {
+		packageIndex[pkg] = i
+	}
+
+	index := struct {
+		Packages []string        `json:"packages"`
+		Symbols  [][]interface{} `json:"symbols"`
+	}{
+		Packages: packages,
+	}
+	for _, entry := range entries {
+		if entry.Kind == searchKindPackage {
+			continue
+		}
+		index.Symbols = append(index.Symbols, []interface{}{entry.Name, packageIndex[entry.Package], string(entry.Kind), entry.File, entry.Line})
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	buf.Reset()
+	buf.Write(data)
+	return writeFile(ctx, buf, "lib", "search-index.json")
+}
+
+// searchShard returns the name of the index shard of a character.
+func searchShard(c byte) string {
+	if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+		return string(c)
+	}
+	return "_"
+}
+
+// searchShards returns the names of the index shards containing a symbol: one
+// for each character of its name. Every symbol containing a query is in the
+// shard of the first character of the query.
+func searchShards(name string) []string {
+	var shards []string
+	seen := make(map[string]bool)
+	lower := strings.ToLower(name)
+	for i := 0; i < len(lower); i++ {
+		shard := searchShard(lower[i])
+		if !seen[shard] {
+			seen[shard] = true
+			shards = append(shards, shard)
+		}
+	}
+	return shards
+}
+
+func appendUvarint(b []byte, v int) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], uint64(v))
+	return append(b, tmp[:n]...)
+}
+
+func appendString(b []byte, s string) []byte {
+	return append(appendUvarint(b, len(s)), s...)
+}
+
+// writeWasmSearchIndex writes the WebAssembly search implementation and a
+// compact binary index, split into shards by each letter of each symbol so
+// that only the shard matching the first letter of a query is downloaded.
+func writeWasmSearchIndex(ctx context.Context, buf *bytes.Buffer) error {
+	wasm, err := sharedAsset("search.wasm", func() ([]byte, error) {
+		return buildSearchWasm(ctx)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build search.wasm: %s", err)
+	}
+
+	buf.Reset()
+	buf.Write(wasm)
+	err = writeFile(ctx, buf, "lib", "search.wasm")
+	if err != nil {
+		return err
+	}
+
+	wasmExec, err := sharedAsset("wasm_exec.js", func() ([]byte, error) {
+		return wasmExecJS(ctx)
+	})
+	if err != nil {
+		return err
+	}
+
+	buf.Reset()
+	buf.Write(wasmExec)
+	err = writeFile(ctx, buf, "lib", "wasm_exec.js")
+	if err != nil {
+		return err
+	}
+
+	packages, entries := sortedSearchEntries()
+	packageIndex := make(map[string]int)
+
+	data := appendUvarint(nil, len(packages))
+	for i, pkg := range packages {
+		packageIndex[pkg] = i
+		data = appendString(data, pkg)
+	}
+
+	buf.Reset()
+	buf.Write(data)
+	err = writeFile(ctx, buf, "lib", "search-packages.bin")
+	if err != nil {
+		return err
+	}
+
+	shards := make(map[string][]searchEntry)
+	for _, entry := range entries {
+		if entry.Kind == searchKindPackage {
+			continue
+		}
+		for _, shard := range searchShards(entry.Name) {
+			shards[shard] = append(shards[shard], entry)
+		}
+	}
+
+	err = os.MkdirAll(filepath.Join(siteDestination, "lib", "search"), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to make directory lib/search: %s", err)
+	}
+
+	for shard, shardEntries := range shards {
+		data = appendUvarint(nil, len(shardEntries))
+		for _, entry := range shardEntries {
+			data = appendString(data, entry.Name)
+			data = appendUvarint(data, packageIndex[entry.Package])
+			data = append(data, entry.Kind)
+			data = appendString(data, entry.File)
+			data = appendUvarint(data, entry.Line)
+		}
+
+		buf.Reset()
+		buf.Write(data)
+		err = writeFile(ctx, buf, "lib/search", shard+".bin")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func buildSearchWasm(ctx context.Context) ([]byte, error) {
+	dir, err := ioutil.TempDir(getTmpDir(), "godoc-static-search")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module godoc-static-search\n\ngo 1.15\n"), 0644)
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(searchWasmSource), 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "build", "-trimpath", "-ldflags=-s -w", "-o", "search.wasm", ".")
+	cmd.Env = append(godocEnv, "GOOS=js", "GOARCH=wasm", "GO111MODULE=on", "GOFLAGS=-mod=mod")
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	setDeathSignal(cmd)
+
+	err = cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return ioutil.ReadFile(filepath.Join(dir, "search.wasm"))
+}
+
+// wasmExecJS returns the JavaScript support file for WebAssembly binaries
+// built by the installed Go toolchain.
+func wasmExecJS(ctx context.Context) ([]byte, error) {
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "env", "GOROOT")
+	cmd.Env = godocEnv
+	cmd.Dir = getTmpDir()
+	cmd.Stdout = &out
+	setDeathSignal(cmd)
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate GOROOT: %s", err)
+	}
+
+	goRoot := strings.TrimSpace(out.String())
+	for _, p := range []string{filepath.Join(goRoot, "lib", "wasm", "wasm_exec.js"), filepath.Join(goRoot, "misc", "wasm", "wasm_exec.js")} {
+		data, err := ioutil.ReadFile(p)
+		if err == nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to locate wasm_exec.js in %s", goRoot)
+}
+
+const searchCSS = `
+#menu .search-box { width: 20rem; }
+#menu .search-owner { margin-left: 0.3125rem; max-width: 10rem; }
+#menu .search-source { margin-left: 0.3125rem; font-size: 0.875rem; cursor: pointer; }
+#search-results { display: none; position: absolute; z-index: 10; right: 0; max-height: 70vh; overflow-y: auto; min-width: 20rem; background: var(--background); border: 0.0625rem solid var(--border); text-align: left; }
+#search-results.visible { display: block; }
+#search-results a { display: block; padding: 0.3rem 0.6rem; margin: 0; border: 0; color: var(--text); background: var(--background); font-size: 0.875rem; }
+#search-results a:hover, #search-results a.selected { background: var(--heading-background); text-decoration: none; }
+#search-results .kind { color: var(--text-muted); margin-left: 0.5rem; }
+#menu { position: relative; }
+`
+
+const searchJS = `(function() {
+	var script = document.currentScript;
+	var base = script.getAttribute('data-base');
+	var mode = script.getAttribute('data-mode');
+	var linkIndex = script.getAttribute('data-link-index') === 'true';
+	var kinds = {p: 'package', f: 'func', t: 'type', m: 'method'};
+	var maxResults = 50;
+
+	var packages = null, symbols = null, shards = {}, ready = null;
+
+	// allowed lists the packages of the owner selected to filter results by.
+	var allowed = null;
+
+	function permitted(pkg) {
+		return !allowed || allowed.hasOwnProperty(pkg);
+	}
+
+	function fetchBytes(url) {
+		return fetch(url).then(function(res) {
+			if (!res.ok) {
+				throw new Error(res.statusText);
+			}
+			return res.arrayBuffer();
+		}).then(function(buf) {
+			return new Uint8Array(buf);
+		});
+	}
+
+	function target(pkg, name) {
+		return base + pkg + '/' + (linkIndex ? 'index.html' : '') + (name ? '#' + name : '');
+	}
+
+	function sourceTarget(pkg, file, line) {
+		if (!file) {
+			return base + 'src/' + pkg + '/' + (linkIndex ? 'index.html' : '');
+		}
+		return base + 'src/' + pkg + '/' + file + '.html#L' + line;
+	}
+
+	function score(name, q) {
+		var n = name.toLowerCase();
+		if (n === q) {
+			return 0;
+		} else if (n.indexOf(q) === 0) {
+			return 1;
+		}
+		var dot = n.lastIndexOf('.');
+		if (dot >= 0 && n.substr(dot + 1).indexOf(q) === 0) {
+			return 2;
+		}
+		var slash = n.lastIndexOf('/');
+		if (slash >= 0 && n.substr(slash + 1).indexOf(q) === 0) {
+			return 2;
+		}
+		return n.indexOf(q) >= 0 ? 3 : -1;
+	}
+
+	function rank(matches) {
+		matches.sort(function(a, b) {
+			return a.score - b.score || a.name.length - b.name.length || (a.name < b.name ? -1 : 1);
+		});
+		return matches.slice(0, maxResults);
+	}
+
+	function loadJSON() {
+		if (!ready) {
+			ready = fetch(base + 'lib/search-index.json').then(function(res) {
+				return res.json();
+			}).then(function(index) {
+				packages = index.packages;
+				symbols = index.symbols || [];
+			});
+		}
+		return ready;
+	}
+
+	function searchJSON(q) {
+		return loadJSON().then(function() {
+			var matches = [];
+			packages.forEach(function(pkg) {
+				if (!permitted(pkg)) {
+					return;
+				}
+				var s = score(pkg, q);
+				if (s >= 0) {
+					matches.push({name: pkg, pkg: pkg, kind: 'p', score: s});
+				}
+			});
+			symbols.forEach(function(sym) {
+				if (!permitted(packages[sym[1]])) {
+					return;
+				}
+				var s = score(sym[0], q);
+				if (s >= 0) {
+					matches.push({name: sym[0], pkg: packages[sym[1]], kind: sym[2], file: sym[3], line: sym[4], score: s});
+				}
+			});
+			return rank(matches);
+		});
+	}
+
+	function loadWasm() {
+		if (!ready) {
+			ready = new Promise(function(resolve, reject) {
+				var s = document.createElement('script');
+				s.src = base + 'lib/wasm_exec.js';
+				s.onload = resolve;
+				s.onerror = reject;
+				document.head.appendChild(s);
+			}).then(function() {
+				return fetch(base + 'lib/search.wasm');
+			}).then(function(res) {
+				return res.arrayBuffer();
+			}).then(function(buf) {
+				var go = new Go();
+				return WebAssembly.instantiate(buf, go.importObject).then(function(result) {
+					go.run(result.instance);
+				});
+			}).then(function() {
+				return fetchBytes(base + 'lib/search-packages.bin');
+			}).then(function(data) {
+				godocStaticSearchLoadPackages(data);
+			});
+		}
+		return ready;
+	}
+
+	function shardName(q) {
+		var c = q.charAt(0);
+		return /[a-z0-9]/.test(c) ? c : '_';
+	}
+
+	function searchWasm(q) {
+		return loadWasm().then(function() {
+			var shard = shardName(q);
+			if (!shards[shard]) {
+				shards[shard] = fetchBytes(base + 'lib/search/' + shard + '.bin').then(function(data) {
+					godocStaticSearchLoad(shard, data);
+				}, function() {
+					// Shards without symbols are not written
+				});
+			}
+			return shards[shard];
+		}).then(function() {
+			return godocStaticSearch(q, maxResults, allowed ? Object.keys(allowed) : null).map(function(r) {
+				return {name: r[0], pkg: r[1], kind: r[2], file: r[3], line: r[4]};
+			});
+		});
+	}
+
+	function init() {
+		var input = document.getElementById('search');
+		var results = document.getElementById('search-results');
+		var source = document.getElementById('search-source');
+		if (!input || !results) {
+			return;
+		}
+
+		var lastMatches = [];
+		if (source) {
+			try {
+				source.checked = window.localStorage.getItem('godoc-static-search-source') === 'true';
+			} catch (e) {
+			}
+			source.addEventListener('change', function() {
+				try {
+					window.localStorage.setItem('godoc-static-search-source', source.checked ? 'true' : 'false');
+				} catch (e) {
+				}
+				render(lastMatches);
+			});
+		}
+
+		var owner = document.getElementById('search-owner');
+		if (owner) {
+			var owners = {};
+			fetch(base + 'lib/search-owners.json').then(function(res) {
+				return res.json();
+			}).then(function(data) {
+				owners = data;
+				Object.keys(owners).sort().forEach(function(name) {
+					var option = document.createElement('option');
+					option.value = name;
+					option.appendChild(document.createTextNode(name));
+					owner.appendChild(option);
+				});
+			});
+			owner.addEventListener('change', function() {
+				allowed = null;
+				if (owner.value) {
+					allowed = {};
+					(owners[owner.value] || []).forEach(function(pkg) {
+						allowed[pkg] = true;
+					});
+				}
+				input.dispatchEvent(new Event('input'));
+			});
+		}
+
+		var selected = -1;
+		var latest = '';
+
+		function select(i) {
+			var links = results.getElementsByTagName('a');
+			if (links.length === 0) {
+				return;
+			}
+			if (selected >= 0 && selected < links.length) {
+				links[selected].className = '';
+			}
+			selected = (i + links.length) % links.length;
+			links[selected].className = 'selected';
+			links[selected].scrollIntoView({block: 'nearest'});
+		}
+
+		function render(matches) {
+			results.innerHTML = '';
+			selected = -1;
+			lastMatches = matches;
+			matches.forEach(function(m) {
+				var a = document.createElement('a');
+				if (source && source.checked) {
+					a.href = sourceTarget(m.pkg, m.file, m.line);
+				} else {
+					a.href = m.kind === 'p' ? target(m.pkg, '') : target(m.pkg, m.name);
+				}
+				a.appendChild(document.createTextNode(m.kind === 'p' ? m.pkg : m.pkg.split('/').pop() + '.' + m.name));
+				var kind = document.createElement('span');
+				kind.className = 'kind';
+				kind.appendChild(document.createTextNode(kinds[m.kind] || ''));
+				a.appendChild(kind);
+				results.appendChild(a);
+			});
+			results.className = matches.length > 0 ? 'visible' : '';
+		}
+
+		input.addEventListener('input', function() {
+			var q = input.value.trim().toLowerCase();
+			latest = q;
+			if (q === '') {
+				render([]);
+				return;
+			}
+			(mode === 'wasm' ? searchWasm(q) : searchJSON(q)).then(function(matches) {
+				if (q === latest) {
+					render(matches);
+				}
+			});
+		});
+
+		input.addEventListener('keydown', function(e) {
+			var links = results.getElementsByTagName('a');
+			if (e.key === 'ArrowDown') {
+				select(selected + 1);
+				e.preventDefault();
+			} else if (e.key === 'ArrowUp') {
+				select(selected - 1);
+				e.preventDefault();
+			} else if (e.key === 'Enter' && links.length > 0) {
+				window.location.href = links[selected >= 0 ? selected : 0].href;
+			} else if (e.key === 'Escape') {
+				input.value = '';
+				render([]);
+			}
+		});
+	}
+
+	if (document.readyState === 'loading') {
+		document.addEventListener('DOMContentLoaded', init);
+	} else {
+		init();
+	}
+})();
+`
+
+// searchWasmSource is compiled to search.wasm when -search=wasm is supplied.
+const searchWasmSource = `// +build js,wasm
+
+package main
+
+import (
+	"encoding/binary"
+	"sort"
+	"strings"
+	"syscall/js"
+)
+
+type symbol struct {
+	name  string
+	lower string
+	pkg   int
+	kind  byte
+	file  string
+	line  int
+}
+
+var (
+	packages []string
+	// symbols lists the symbols of each loaded shard.
+	symbols = make(map[string][]symbol)
+)
+
+func bytesArg(v js.Value) []byte {
+	b := make([]byte, v.Get("length").Int())
+	js.CopyBytesToGo(b, v)
+	return b
+}
+
+func readUvarint(b []byte, pos *int) int {
+	v, n := binary.Uvarint(b[*pos:])
+	if n <= 0 {
+		*pos = len(b)
+		return 0
+	}
+	*pos += n
+	return int(v)
+}
+
+func readString(b []byte, pos *int) string {
+	l := readUvarint(b, pos)
+	if *pos+l > len(b) {
+		*pos = len(b)
+		return ""
+	}
+	s := string(b[*pos : *pos+l])
+	*pos += l
+	return s
+}
+
+func loadPackages(this js.Value, args []js.Value) interface{} {
+	b := bytesArg(args[0])
+	var pos int
+	count := readUvarint(b, &pos)
+	for i := 0; i < count && pos < len(b); i++ {
+		packages = append(packages, readString(b, &pos))
+	}
+	return nil
+}
+
+// shardName returns the name of the shard containing the symbols which may
+// match a query.
+func shardName(q string) string {
+	if q == "" {
+		return "_"
+	}
+	c := q[0]
+	if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+		return string(c)
+	}
+	return "_"
+}
+
+func load(this js.Value, args []js.Value) interface{} {
+	shard := args[0].String()
+	b := bytesArg(args[1])
+	var pos int
+	count := readUvarint(b, &pos)
+	for i := 0; i < count && pos < len(b); i++ {
+		name := readString(b, &pos)
+		pkg := readUvarint(b, &pos)
+		if pos >= len(b) {
+			break
+		}
+		kind := b[pos]
+		pos++
+		file := readString(b, &pos)
+		line := readUvarint(b, &pos)
+		symbols[shard] = append(symbols[shard], symbol{name: name, lower: strings.ToLower(name), pkg: pkg, kind: kind, file: file, line: line})
+	}
+	return nil
+}
+
+func score(name string, q string) int {
+	if name == q {
+		return 0
+	} else if strings.HasPrefix(name, q) {
+		return 1
+	}
+	if dot := strings.LastIndexByte(name, '.'); dot >= 0 && strings.HasPrefix(name[dot+1:], q) {
+		return 2
+	}
+	if slash := strings.LastIndexByte(name, '/'); slash >= 0 && strings.HasPrefix(name[slash+1:], q) {
+		return 2
+	}
+	if strings.Contains(name, q) {
+		return 3
+	}
+	return -1
+}
+
+type match struct {
+	name  string
+	pkg   string
+	kind  byte
+	file  string
+	line  int
+	score int
+}
+
+func search(this js.Value, args []js.Value) interface{} {
+	q := strings.ToLower(args[0].String())
+	max := args[1].Int()
+
+	// Results may be limited to a list of packages.
+	var allowed map[string]bool
+	if len(args) > 2 && args[2].Type() == js.TypeObject {
+		allowed = make(map[string]bool)
+		for i := 0; i < args[2].Length(); i++ {
+			allowed[args[2].Index(i).String()] = true
+		}
+	}
+	permitted := func(pkg string) bool {
+		return allowed == nil || allowed[pkg]
+	}
+
+	var matches []match
+	for _, pkg := range packages {
+		if !permitted(pkg) {
+			continue
+		}
+		if s := score(strings.ToLower(pkg), q); s >= 0 {
+			matches = append(matches, match{name: pkg, pkg: pkg, kind: 'p', score: s})
+		}
+	}
+	// Only the shard of the query is searched. It contains every symbol
+	// matching the query, regardless of which other shards were loaded.
+	for _, sym := range symbols[shardName(q)] {
+		if sym.pkg >= len(packages) || !permitted(packages[sym.pkg]) {
+			continue
+		}
+		if s := score(sym.lower, q); s >= 0 {
+			matches = append(matches, match{name: sym.name, pkg: packages[sym.pkg], kind: sym.kind, file: sym.file, line: sym.line, score: s})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		} else if len(matches[i].name) != len(matches[j].name) {
+			return len(matches[i].name) < len(matches[j].name)
+		}
+		return matches[i].name < matches[j].name
+	})
+	if len(matches) > max {
+		matches = matches[:max]
+	}
+
+	results := make([]interface{}, len(matches))
+	for i, m := range matches {
+		results[i] = []interface{}{m.name, m.pkg, string(m.kind), m.file, m.line}
+	}
+	return results
+}
+
+func main() {
+	js.Global().Set("godocStaticSearchLoadPackages", js.FuncOf(loadPackages))
+	js.Global().Set("godocStaticSearchLoad", js.FuncOf(load))
+	js.Global().Set("godocStaticSearch", js.FuncOf(search))
+	select {}
+}
+`
@@ -0,0 +1,142 @@
+package godocstatic
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	serveAddress string
+	serveAuth    string
+)
+
+// serveTypes lists the content types of files written to generated sites
+// which may be missing from the system MIME type database.
+var serveTypes = map[string]string{
+	".css":  "text/css; charset=utf-8",
+	".html": "text/html; charset=utf-8",
+	".js":   "text/javascript; charset=utf-8",
+	".json": "application/json",
+	".txt":  "text/plain; charset=utf-8",
+	".wasm": "application/wasm",
+	".xml":  "text/xml; charset=utf-8",
+	".zip":  "application/zip",
+}
+
+func registerServeFlags(flags *flag.FlagSet) {
+	flags.StringVar(&siteDestination, "destination", "", "path to generated site")
+	flags.StringVar(&serveAddress, "address", "localhost:8080", "address to serve site on")
+	flags.StringVar(&serveAuth, "auth", "", "require HTTP basic authentication, as username:password")
+	registerCommonFlags(flags)
+}
+
+// siteHandler serves a generated site. Unlike http.FileServer, requests for
+// index.html are served rather than redirected and directories without
+// index.html are not listed, so that links are served as they would be by
+// most static hosts.
+type siteHandler struct {
+	root     string
+	username string
+	password string
+}
+
+func (h *siteHandler) authorized(r *http.Request) bool {
+	if h.username == "" && h.password == "" {
+		return true
+	}
+
+	username, password, ok := r.BasicAuth()
+	return ok &&
+		subtle.ConstantTimeCompare([]byte(username), []byte(h.username)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(password), []byte(h.password)) == 1
+}
+
+// notFound serves 404.html, when present, as most static hosts do.
+func (h *siteHandler) notFound(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadFile(filepath.Join(h.root, "404.html"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	w.Write(data)
+}
+
+func (h *siteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="godoc-static"`)
+		http.Error(w, "401 unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	urlPath := path.Clean("/" + r.URL.Path)
+	name := filepath.Join(h.root, filepath.FromSlash(urlPath))
+
+	info, err := os.Stat(name)
+	if err == nil && info.IsDir() {
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, path.Base(urlPath)+"/", http.StatusMovedPermanently)
+			return
+		}
+
+		name = filepath.Join(name, "index.html")
+		info, err = os.Stat(name)
+	}
+	if err != nil || info.IsDir() {
+		if !quiet {
+			logger.Printf("Not found: %s", r.URL.Path)
+		}
+		h.notFound(w, r)
+		return
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		http.Error(w, "500 internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	if verbose {
+		logger.Printf("Serving %s", r.URL.Path)
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+func runServe(ctx context.Context, args []string) error {
+	if siteDestination == "" {
+		return errors.New("--destination must be set")
+	}
+
+	h := &siteHandler{root: siteDestination}
+	if serveAuth != "" {
+		colon := strings.IndexRune(serveAuth, ':')
+		if colon <= 0 {
+			return errors.New("invalid authentication: expected username:password")
+		}
+		h.username, h.password = serveAuth[:colon], serveAuth[colon+1:]
+	}
+
+	for ext, typ := range serveTypes {
+		err := mime.AddExtensionType(ext, typ)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !quiet {
+		logger.Printf("Serving %s at http://%s", siteDestination, serveAddress)
+	}
+	return http.ListenAndServe(serveAddress, h)
+}
@@ -0,0 +1,204 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	siteMapPage = "sitemap.html"
+	siteMapXML  = "sitemap.xml"
+)
+
+var (
+	baseURL string
+
+	// indexPages is the number of pages of the package index written.
+	indexPages int
+
+	// sitePages lists the pages written, for sitemap.xml.
+	sitePages     []string
+	sitePagesLock sync.Mutex
+)
+
+// addSitePage records a page written to the site.
+func addSitePage(page string) {
+	if baseURL == "" || !strings.HasSuffix(page, ".html") || path.Base(page) == "fragment.html" {
+		return
+	}
+
+	sitePagesLock.Lock()
+	defer sitePagesLock.Unlock()
+
+	sitePages = append(sitePages, page)
+}
+
+// writeSiteMapXML writes sitemap.xml, listing each page written with the time
+// it was last modified.
+func writeSiteMapXML(ctx context.Context, buf *bytes.Buffer) error {
+	type url struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	}
+	type urlSet struct {
+		XMLName xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+		URLs    []url    `xml:"url"`
+	}
+
+	pages := uniqueStrings(sitePages)
+	sort.Strings(pages)
+
+	set := urlSet{}
+	for _, page := range pages {
+		if noindexPages[page] {
+			continue
+		}
+
+		info, err := os.Stat(filepath.Join(siteDestination, page))
+		if err != nil {
+			continue
+		}
+
+		set.URLs = append(set.URLs, url{
+			Loc:     pageURL(page),
+			LastMod: info.ModTime().UTC().Format(time.RFC3339),
+		})
+	}
+
+	data, err := xml.MarshalIndent(set, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	buf.Reset()
+	buf.WriteString(xml.Header)
+	buf.Write(data)
+	buf.WriteString("\n")
+	return writeFile(ctx, buf, "", siteMapXML)
+}
+
+// writeSiteMapList writes a list of links to the documentation of packages.
+func writeSiteMapList(buf *bytes.Buffer, pkgs []string, index string) {
+	buf.WriteString(`<ul>
+`)
+	for _, pkg := range pkgs {
+		outPkg := vanityPath(pkg)
+		buf.WriteString(`<li><a href="` + outPkg + index + `">` + html.EscapeString(displayPath(outPkg)) + `</a></li>
+`)
+	}
+	buf.WriteString(`</ul>
+`)
+}
+
+// writeSiteMap writes a page listing the pages of the site, with the packages
+// of each module listed in a section of their own.
+func writeSiteMap(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	var index string
+	if linkIndex {
+		index = "/index.html"
+	}
+
+	buf.Reset()
+	buf.WriteString(sitePageHeader("Site map - " + siteName))
+	buf.WriteString(`
+<h1>
+	Site map
+</h1>
+<div id="site-map">
+<h2 id="site-map-pages">Pages</h2>
+<ul>
+`)
+	for page := 0; page < indexPages; page++ {
+		label := "Package index"
+		if indexPages > 1 {
+			label += " (page " + strconv.Itoa(page+1) + ")"
+		}
+		buf.WriteString(`<li><a href="` + indexPageName(page) + `">` + label + `</a></li>
+`)
+	}
+	buf.WriteString(`<li><a href="` + indexFeedFile + `">Package index (JSON)</a></li>
+`)
+	if symbolIndex {
+		buf.WriteString(`<li><a href="` + symbolIndexPage + `">Index of all symbols</a></li>
+`)
+	}
+	if a11yReport != "" {
+		buf.WriteString(`<li><a href="` + html.EscapeString(a11yReport) + `">Accessibility report</a></li>
+`)
+	}
+	if docReport != "" {
+		buf.WriteString(`<li><a href="` + html.EscapeString(docReport) + `">Documentation report</a></li>
+`)
+	}
+	if siteZip != "" {
+		buf.WriteString(`<li><a href="` + html.EscapeString(zipPartName(1)) + `">Download ` + html.EscapeString(zipPartName(1)) + `</a> to browse offline</li>
+`)
+	}
+	buf.WriteString(`</ul>
+`)
+
+	var other []string
+	modulePkgs := make(map[string][]string)
+	for _, pkg := range pkgs {
+		if m := packageModule(pkg); m != nil {
+			modulePkgs[m.Path] = append(modulePkgs[m.Path], pkg)
+		} else {
+			other = append(other, pkg)
+		}
+	}
+
+	for _, name := range moduleNames() {
+		if len(modulePkgs[name]) == 0 {
+			continue
+		}
+
+		buf.WriteString(`<h2 id="module-` + html.EscapeString(vanityPath(name)) + `">Module ` + html.EscapeString(displayPath(vanityPath(name))) + `</h2>
+`)
+		if landingPage, ok := moduleLandingPages[name]; ok {
+			buf.WriteString(`<p><a href="` + landingPage + `">About this module</a></p>
+`)
+		}
+		if infoPage, ok := moduleInfoPages[name]; ok {
+			buf.WriteString(`<p><a href="` + infoPage + `">go.mod</a></p>
+`)
+		}
+		if dependenciesPage, ok := moduleDependencyPages[name]; ok {
+			buf.WriteString(`<p><a href="` + dependenciesPage + `">Dependencies</a></p>
+`)
+		}
+		if vendorPage, ok := moduleVendorPages[name]; ok {
+			buf.WriteString(`<p><a href="` + vendorPage + `">Vendored modules</a></p>
+`)
+		}
+		writeSiteMapList(buf, modulePkgs[name], index)
+	}
+
+	if len(other) > 0 {
+		heading := "Packages"
+		if len(modulePkgs) > 0 {
+			heading = "Other packages"
+		}
+		buf.WriteString(`<h2 id="site-map-packages">` + heading + `</h2>
+`)
+		writeSiteMapList(buf, other, index)
+	}
+
+	buf.WriteString(`</div>
+<div id="footer">` + siteFooterText("") + `</div>
+</div>
+</div>
+` + searchTags("") + `</body>
+</html>
+`)
+	return writeFile(ctx, buf, "", siteMapPage)
+}
@@ -0,0 +1,66 @@
+package godocstatic
+
+import "github.com/PuerkitoBio/goquery"
+
+const sourceCSS = `
+.src-controls { margin: 0.625rem 0; font-size: 0.875rem; }
+.src-controls label { margin-right: 1.25rem; cursor: pointer; }
+.src-wrap pre { white-space: pre-wrap; overflow-wrap: anywhere; }
+div#page.src-narrow > .container { max-width: 59.38rem; }
+pre .ln a { color: inherit; text-decoration: none; }
+pre .ln:target { background: var(--highlight); }
+`
+
+// sourceJS toggles line wrapping and the content width of source pages,
+// persisting the selection across pages.
+const sourceJS = `(function() {
+	var page = document.getElementById('page');
+	var controls = document.querySelectorAll('.src-controls input[data-class]');
+	function stored(key) {
+		try {
+			return window.localStorage.getItem(key) === 'true';
+		} catch (e) {
+			return false;
+		}
+	}
+	function store(key, value) {
+		try {
+			window.localStorage.setItem(key, value ? 'true' : 'false');
+		} catch (e) {
+		}
+	}
+	Array.prototype.forEach.call(controls, function(input) {
+		var className = input.getAttribute('data-class');
+		var key = 'godoc-static-' + className;
+		input.checked = stored(key);
+		page.classList.toggle(className, input.checked);
+		input.addEventListener('change', function() {
+			page.classList.toggle(className, input.checked);
+			store(key, input.checked);
+		});
+	});
+})();
+`
+
+// addSourceControls adds line wrapping and width toggles above the code of a
+// source page.
+func addSourceControls(doc *goquery.Document, basePath string) {
+	pre := doc.Find("#page pre").First()
+	if pre.Length() == 0 || noJS {
+		return
+	}
+
+	pre.BeforeHtml(`<div class="src-controls js-only">
+<label><input type="checkbox" data-class="src-wrap"> Wrap lines</label>
+<label><input type="checkbox" data-class="src-narrow"> Limit width</label>
+</div>`)
+	doc.Find("body").AppendHtml(`<script src="` + basePath + `lib/source.js"></script>`)
+}
+
+// linkLineNumbers links the line numbers of a source page to their lines, so
+// that links such as file.go.html#L42 may be copied from them.
+func linkLineNumbers(doc *goquery.Document) {
+	doc.Find("#page pre .ln[id^=L]").Each(func(_ int, line *goquery.Selection) {
+		line.WrapInnerHtml(`<a href="#` + line.AttrOr("id", "") + `"></a>`)
+	})
+}
@@ -0,0 +1,326 @@
+package godocstatic
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"html"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const sourceLinksCSS = `
+pre a.ident { color: inherit; text-decoration: none; }
+pre a.ident:hover, pre a.ident:focus { text-decoration: underline; }
+`
+
+var sourceLinks bool
+
+// sourceLink is a link from an identifier in a source file to the declaration
+// it refers to.
+type sourceLink struct {
+	End   int
+	Href  string
+	Title string
+}
+
+var (
+	// packageSourceLinks lists the links from the identifiers in the Go source
+	// files of a package by package, file name and the byte offset of each
+	// identifier. Links are kept until the source files of the package are
+	// written.
+	packageSourceLinks = make(map[string]map[string]map[int]sourceLink)
+	// sourceLinksLoaded lists the packages whose links have been loaded.
+	sourceLinksLoaded      = make(map[string]bool)
+	packageSourceLinksLock sync.Mutex
+)
+
+// fileSourceLinks returns the links from the identifiers in a Go source file
+// of a package, by byte offset. The documented packages of the module
+// containing the package are type-checked together, sharing the dependencies
+// type-checked from source.
+func fileSourceLinks(ctx context.Context, pkg string, sourceFile string) map[int]sourceLink {
+	packageSourceLinksLock.Lock()
+	defer packageSourceLinksLock.Unlock()
+
+	if links, ok := packageSourceLinks[pkg]; ok {
+		return links[sourceFile]
+	}
+
+	batch := []string{pkg}
+	if m := packageModule(pkg); m != nil {
+		for p := range documentedPackages {
+			if p != pkg && !sourceLinksLoaded[p] && packageModule(p) == m {
+				batch = append(batch, p)
+			}
+		}
+		sort.Strings(batch[1:])
+	}
+
+	links := loadSourceLinks(ctx, batch)
+	for _, p := range batch {
+		sourceLinksLoaded[p] = true
+		packageSourceLinks[p] = links[p]
+	}
+	return packageSourceLinks[pkg][sourceFile]
+}
+
+// releaseSourceLinks discards the links from the source files of a package
+// once they are written.
+func releaseSourceLinks(pkg string) {
+	packageSourceLinksLock.Lock()
+	defer packageSourceLinksLock.Unlock()
+
+	delete(packageSourceLinks, pkg)
+}
+
+// testImporter imports the packages type-checked, except for the package
+// under test, which is imported along with its test files by its external
+// tests.
+type testImporter struct {
+	types.Importer
+	pkg *types.Package
+}
+
+func (i testImporter) Import(path string) (*types.Package, error) {
+	if path == i.pkg.Path() {
+		return i.pkg, nil
+	}
+	return i.Importer.Import(path)
+}
+
+// loadSourceLinks type-checks packages of the same module, including their
+// tests, and resolves the identifiers in each of their Go source files,
+// returning links by package and file name. Dependencies are type-checked from
+// source once for all packages. Identifiers which may not be resolved, as when
+// a package fails to type-check, are not linked.
+func loadSourceLinks(ctx context.Context, pkgs []string) map[string]map[string]map[int]sourceLink {
+	links := make(map[string]map[string]map[int]sourceLink)
+
+	fset := token.NewFileSet()
+	checked := make(map[string]*types.Package)
+	imp := importerFunc(func(path string) (*types.Package, error) {
+		if imported := checked[path]; imported != nil {
+			return imported, nil
+		}
+		return nil, fmt.Errorf("package %s was not listed", path)
+	})
+	sizes := types.SizesFor("gc", runtime.GOARCH)
+
+	check := func(path string, dir string, fileNames []string, imp types.Importer, pkgLinks map[string]map[int]sourceLink) *types.Package {
+		var files []*ast.File
+		for _, fileName := range fileNames {
+			f, err := parser.ParseFile(fset, filepath.Join(dir, fileName), nil, 0)
+			if err != nil {
+				continue
+			}
+			files = append(files, f)
+		}
+
+		conf := &types.Config{
+			Importer:    imp,
+			Sizes:       sizes,
+			FakeImportC: true,
+			Error:       func(error) {}, // Identifiers are resolved despite errors
+		}
+		info := &types.Info{
+			Defs: make(map[*ast.Ident]types.Object),
+			Uses: make(map[*ast.Ident]types.Object),
+		}
+		tpkg, _ := conf.Check(path, fset, files, info)
+
+		for _, f := range files {
+			fileLinks := make(map[int]sourceLink)
+			ast.Inspect(f, func(n ast.Node) bool {
+				id, ok := n.(*ast.Ident)
+				if !ok {
+					return true
+				}
+
+				obj, def := info.Uses[id], false
+				if obj == nil {
+					obj, def = info.Defs[id], true
+				}
+				href := objectLink(fset, obj, def)
+				if href == "" {
+					return true
+				}
+
+				offset := fset.Position(id.Pos()).Offset
+				fileLinks[offset] = sourceLink{
+					End:   offset + len(id.Name),
+					Href:  href,
+					Title: objectTitle(obj, tpkg),
+				}
+				return true
+			})
+			pkgLinks[filepath.Base(fset.Position(f.Pos()).Filename)] = fileLinks
+		}
+		return tpkg
+	}
+
+	native := make(map[string]*nativePackage)
+	patterns := append([]string{}, pkgs...)
+	for _, pkg := range pkgs {
+		links[pkg] = make(map[string]map[int]sourceLink)
+
+		p, err := loadNativePackage(ctx, pkg)
+		if ctx.Err() != nil {
+			return links
+		} else if err != nil {
+			if verbose {
+				logger.Printf("Warning: failed to resolve identifiers of %s: %s", pkg, err)
+			}
+			continue
+		}
+		native[pkg] = p
+
+		// The imports of tests are not listed with -find.
+		for _, fileName := range append(append([]string{}, p.TestGoFiles...), p.XTestGoFiles...) {
+			f, err := parser.ParseFile(fset, filepath.Join(p.Dir, fileName), nil, parser.ImportsOnly)
+			if err != nil {
+				continue
+			}
+			for _, spec := range f.Imports {
+				path, err := strconv.Unquote(spec.Path.Value)
+				if err == nil && path != "C" && path != pkg {
+					patterns = append(patterns, path)
+				}
+			}
+		}
+	}
+	if len(native) == 0 {
+		return links
+	}
+
+	// Dependencies, including those of tests, are listed within the module
+	// of the packages, with the build tags supplied.
+	dir := pkgPaths[pkgs[0]]
+	if dir == "" {
+		dir = getTmpDir()
+	}
+	_, _, err := typeCheckPackages(ctx, dir, fset, checked, uniqueStrings(patterns)...)
+	if ctx.Err() != nil {
+		return links
+	} else if err != nil && verbose {
+		logger.Printf("Warning: failed to type-check the dependencies of %s: %s", strings.Join(pkgs, ", "), err)
+	}
+
+	for _, pkg := range pkgs {
+		p := native[pkg]
+		if p == nil {
+			continue
+		}
+
+		files := append(append(append([]string{}, p.GoFiles...), p.CgoFiles...), p.TestGoFiles...)
+		tpkg := check(pkg, p.Dir, files, imp, links[pkg])
+		if len(p.XTestGoFiles) > 0 {
+			check(pkg+"_test", p.Dir, p.XTestGoFiles, testImporter{imp, tpkg}, links[pkg])
+		}
+	}
+	return links
+}
+
+// objectAnchor returns the anchor of the documentation of an object, or an
+// empty string when it is not documented: exported package-level objects and
+// the exported methods of exported types of libraries are documented.
+func objectAnchor(obj types.Object) string {
+	if !obj.Exported() || obj.Pkg() == nil || obj.Pkg().Name() == "main" {
+		return ""
+	} else if obj.Parent() == obj.Pkg().Scope() {
+		return obj.Name()
+	}
+
+	f, ok := obj.(*types.Func)
+	if !ok {
+		return ""
+	}
+	recv := f.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return ""
+	}
+	t := recv.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || !named.Obj().Exported() || types.IsInterface(named) {
+		return ""
+	}
+	return named.Obj().Name() + "." + obj.Name()
+}
+
+// objectTitle describes an object as it is declared, omitting the underlying
+// types of type declarations.
+func objectTitle(obj types.Object, pkg *types.Package) string {
+	if t, ok := obj.(*types.TypeName); ok && t.Pkg() != nil && !t.IsAlias() {
+		return "type " + types.TypeString(t.Type(), types.RelativeTo(pkg))
+	}
+	return types.ObjectString(obj, types.RelativeTo(pkg))
+}
+
+// objectLink returns the link from an identifier to the object it refers to,
+// in the form of the links of godoc: the documentation of the object when it
+// is documented, or else the line of the source file declaring it when its
+// package is documented. Declarations are linked to their documentation only.
+// An empty string is returned when the identifier is not linked.
+func objectLink(fset *token.FileSet, obj types.Object, def bool) string {
+	switch o := obj.(type) {
+	case nil, *types.Label:
+		return ""
+	case *types.PkgName:
+		return "/pkg/" + o.Imported().Path()
+	case *types.Builtin:
+		return "/pkg/builtin#" + o.Name()
+	case *types.TypeName:
+		if o.Pkg() == nil {
+			return "/pkg/builtin#" + o.Name()
+		}
+	}
+	if obj.Pkg() == nil {
+		return ""
+	}
+
+	// Objects declared in test files are not documented, including those of
+	// external test packages.
+	pkg := strings.TrimSuffix(obj.Pkg().Path(), "_test")
+	p := fset.Position(obj.Pos())
+	if anchor := objectAnchor(obj); anchor != "" && !strings.HasSuffix(p.Filename, "_test.go") {
+		return "/pkg/" + pkg + "#" + anchor
+	} else if def || !documentedPackages[pkg] || !p.IsValid() {
+		return ""
+	} else if filepath.Dir(p.Filename) != filepath.Clean(pkgDirs[pkg]) {
+		return ""
+	}
+	return "/src/" + pkg + "/" + filepath.Base(p.Filename) + "#L" + strconv.Itoa(p.Line)
+}
+
+// linkIdentifiers returns src[start:end] escaped, linking the identifiers it
+// contains.
+func linkIdentifiers(src []byte, start int, end int, links map[int]sourceLink) string {
+	if len(links) == 0 {
+		return html.EscapeString(string(src[start:end]))
+	}
+
+	var b strings.Builder
+	from := start
+	for offset := start; offset < end; offset++ {
+		link, ok := links[offset]
+		if !ok || link.End > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(src[from:offset])))
+		b.WriteString(`<a class="ident" href="` + html.EscapeString(link.Href) + `" title="` + html.EscapeString(link.Title) + `">` + html.EscapeString(string(src[offset:link.End])) + `</a>`)
+		from = link.End
+		offset = link.End - 1
+	}
+	b.WriteString(html.EscapeString(string(src[from:end])))
+	return b.String()
+}
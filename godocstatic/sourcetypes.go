@@ -0,0 +1,77 @@
+package godocstatic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sourceFileTypes maps the names accepted by -source-types to the go list
+// fields listing files of that type.
+var sourceFileTypes = []struct {
+	Name   string
+	Fields []string
+}{
+	{"go", []string{"GoFiles"}},
+	{"cgo", []string{"CgoFiles"}},
+	{"c", []string{"CFiles"}},
+	{"cxx", []string{"CXXFiles"}},
+	{"m", []string{"MFiles"}},
+	{"h", []string{"HFiles"}},
+	{"f", []string{"FFiles"}},
+	{"s", []string{"SFiles"}},
+	{"swig", []string{"SwigFiles"}},
+	{"swigcxx", []string{"SwigCXXFiles"}},
+	{"test", []string{"TestGoFiles", "XTestGoFiles"}},
+}
+
+var (
+	sourceTypes  string
+	sourceFields []string
+)
+
+// parseSourceTypes determines the go list fields of the source files to
+// write pages for. All source files are written when -source-types is blank.
+func parseSourceTypes() error {
+	sourceFields = nil
+
+	selected := make(map[string]bool)
+	for _, name := range strings.Split(sourceTypes, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		var found bool
+		for _, t := range sourceFileTypes {
+			if t.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			var names []string
+			for _, t := range sourceFileTypes {
+				names = append(names, t.Name)
+			}
+			return fmt.Errorf("unknown source type %s: must be one of %s", name, strings.Join(names, ", "))
+		}
+		selected[name] = true
+	}
+
+	for _, t := range sourceFileTypes {
+		if len(selected) == 0 || selected[t.Name] {
+			sourceFields = append(sourceFields, t.Fields...)
+		}
+	}
+	return nil
+}
+
+// sourceFilesTemplate returns a go list template printing the selected
+// source files of a package, one per line.
+func sourceFilesTemplate() string {
+	var lines []string
+	for _, field := range sourceFields {
+		lines = append(lines, `{{ join .`+field+` "\n" }}`)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,147 @@
+package godocstatic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// stateFormat is incremented when the format of the state file changes.
+const stateFormat = 1
+
+var (
+	saveStateFile string
+	loadStateFile string
+)
+
+// generationState is the result of resolving the packages to document, which
+// may be saved by -save-state and loaded by -load-state to skip resolving them
+// again.
+type generationState struct {
+	Format    int
+	Arguments []string
+	Packages  []string
+	// Paths and Dirs are the directories each package is served from and
+	// contains its source.
+	Paths map[string]string
+	Dirs  map[string]string
+	// Modules are the directories of the modules containing the packages,
+	// by module path.
+	Modules          map[string]string
+	Failures         map[string]string `json:",omitempty"`
+	WorkspaceModules []string          `json:",omitempty"`
+}
+
+// stateArguments returns the packages and paths supplied as arguments, with
+// paths made absolute.
+func stateArguments(args []string) []string {
+	var arguments []string
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		if _, err := os.Stat(arg); err == nil {
+			arg = absoluteDir(arg)
+		}
+		if arg != "" {
+			arguments = append(arguments, arg)
+		}
+	}
+	return arguments
+}
+
+// absoluteDir returns dir as an absolute path, so that the state may be loaded
+// from another directory.
+func absoluteDir(dir string) string {
+	if dir == "" {
+		return ""
+	} else if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
+
+// saveState writes the packages resolved from the supplied arguments, and
+// their paths, directories and modules, to the file of -save-state.
+func saveState(args []string, pkgs []string) error {
+	state := &generationState{
+		Format:           stateFormat,
+		Arguments:        stateArguments(args),
+		Packages:         pkgs,
+		Paths:            make(map[string]string),
+		Dirs:             make(map[string]string),
+		Modules:          make(map[string]string),
+		Failures:         listFailures,
+		WorkspaceModules: workspaceModules,
+	}
+	for pkg, dir := range pkgPaths {
+		state.Paths[pkg] = absoluteDir(dir)
+	}
+	for pkg, dir := range pkgDirs {
+		state.Dirs[pkg] = absoluteDir(dir)
+	}
+	for name, m := range modules {
+		state.Modules[name] = absoluteDir(m.Dir)
+	}
+
+	data, err := json.MarshalIndent(state, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(saveStateFile, data, 0644)
+}
+
+// loadState restores the packages, and their paths, directories and modules,
+// resolved by a previous run from the file of -load-state. Packages supplied
+// as arguments are not resolved again.
+func loadState(args []string) ([]string, error) {
+	data, err := ioutil.ReadFile(loadStateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &generationState{}
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return nil, err
+	} else if state.Format != stateFormat {
+		return nil, fmt.Errorf("unsupported format %d: save the state again using this version of godoc-static", state.Format)
+	}
+
+	if arguments := stateArguments(args); len(arguments) > 0 && !reflect.DeepEqual(arguments, state.Arguments) {
+		logger.Printf("Warning: packages supplied differ from those of the state file %s, which are documented instead", loadStateFile)
+	}
+
+	for _, dir := range state.Dirs {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("package directory %s is missing: save the state again", dir)
+		}
+	}
+
+	pkgPaths = state.Paths
+	pkgDirs = state.Dirs
+	if pkgPaths == nil {
+		pkgPaths = make(map[string]string)
+	}
+	if pkgDirs == nil {
+		pkgDirs = make(map[string]string)
+	}
+
+	names := make([]string, 0, len(state.Modules))
+	for name := range state.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		addDependencyModule(name, state.Modules[name])
+	}
+
+	for pkg, failure := range state.Failures {
+		listFailures[pkg] = failure
+	}
+	workspaceModules = state.WorkspaceModules
+	return state.Packages, nil
+}
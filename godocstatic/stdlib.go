@@ -0,0 +1,40 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var stdlib bool
+
+// listStdlib lists the packages of the standard library in GOROOT, including
+// the documentation of predeclared identifiers in package builtin, but not
+// the packages vendored into it.
+func listStdlib(ctx context.Context) ([]listedPackage, error) {
+	var buf, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "go", "list", "-f", `{{ .ImportPath }} {{ .Dir }}`, "std", "builtin")
+	cmd.Env = godocEnv
+	cmd.Dir = getTmpDir()
+	cmd.Stdout = &buf
+	cmd.Stderr = &stderr
+	setDeathSignal(cmd)
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list standard library packages: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	var pkgs []listedPackage
+	for _, line := range strings.Split(buf.String(), "\n") {
+		firstSpace := strings.Index(line, " ")
+		if firstSpace <= 0 || strings.HasPrefix(line, "vendor/") {
+			continue
+		}
+		pkgs = append(pkgs, listedPackage{Pkg: line[:firstSpace], Dir: line[firstSpace+1:]})
+	}
+	return pkgs, nil
+}
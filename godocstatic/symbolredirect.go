@@ -0,0 +1,239 @@
+package godocstatic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var symbolRedirectsFile string
+
+// symbolRedirects lists the symbols which were renamed or moved, by the
+// package they were declared in.
+var symbolRedirects map[string][]symbolRedirect
+
+// symbolRedirect describes a symbol which was renamed or moved, identified by
+// the anchor of its declaration on package pages, such as Client.Do.
+type symbolRedirect struct {
+	From string
+	// ToPkg and To are the package and anchor of the new declaration of the
+	// symbol, or an empty string and a URL when it is no longer documented
+	// by the site.
+	ToPkg string
+	To    string
+}
+
+const symbolRedirectJS = `(function() {
+	var moved = {{moved}};
+	function follow() {
+		var id = decodeURIComponent(location.hash.slice(1));
+		if (Object.prototype.hasOwnProperty.call(moved, id) && !document.getElementById(id)) {
+			location.replace(moved[id]);
+		}
+	}
+	follow();
+	window.addEventListener('hashchange', follow);
+})();`
+
+// readSymbolRedirects reads a symbol redirects file consisting of an old
+// import path and anchor and a new import path and anchor or URL separated by
+// whitespace, one per line, such as example.com/pkg#Old example.com/pkg#New.
+// The new import path may be omitted when the symbol was renamed within its
+// package. Blank lines and lines starting with # are ignored.
+func readSymbolRedirects(redirectsPath string) (map[string][]symbolRedirect, error) {
+	f, err := os.Open(redirectsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	redirects := make(map[string][]symbolRedirect)
+
+	scanner := bufio.NewScanner(f)
+	var line int
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid symbol redirect on line %d: expected old-path#Old new-path#New", line)
+		}
+
+		hash := strings.IndexByte(fields[0], '#')
+		if hash <= 0 || hash == len(fields[0])-1 {
+			return nil, fmt.Errorf("invalid symbol redirect on line %d: %s is not an import path and anchor", line, fields[0])
+		}
+		pkg := strings.Trim(fields[0][:hash], "/")
+		r := symbolRedirect{From: fields[0][hash+1:]}
+
+		if isURL(fields[1]) {
+			r.To = fields[1]
+		} else {
+			hash = strings.IndexByte(fields[1], '#')
+			if hash < 0 || hash == len(fields[1])-1 {
+				return nil, fmt.Errorf("invalid symbol redirect on line %d: %s is not an anchor, import path and anchor or URL", line, fields[1])
+			}
+			r.ToPkg = strings.Trim(fields[1][:hash], "/")
+			if r.ToPkg == "" {
+				r.ToPkg = pkg
+			}
+			r.To = fields[1][hash+1:]
+		}
+
+		redirects[pkg] = append(redirects[pkg], r)
+	}
+	return redirects, scanner.Err()
+}
+
+// loadSymbolRedirects reads the symbol redirects file.
+func loadSymbolRedirects() error {
+	redirects, err := readSymbolRedirects(symbolRedirectsFile)
+	if err != nil {
+		return err
+	}
+	symbolRedirects = redirects
+	return nil
+}
+
+// symbolRedirectTarget returns the URL a symbol of pkg moved to, relative to
+// basePath when it moved to a documented package.
+func symbolRedirectTarget(r symbolRedirect, pkg string, basePath string) string {
+	if r.ToPkg == "" {
+		return r.To
+	} else if r.ToPkg == pkg {
+		return "#" + r.To
+	}
+
+	target := basePath + vanityPath(r.ToPkg) + "/"
+	if linkIndex {
+		target += "index.html"
+	}
+	return target + "#" + r.To
+}
+
+// symbolRedirectScript returns a script following links to the old anchors
+// of the symbols of pkg which moved, or an empty string when there are none.
+func symbolRedirectScript(pkg string, basePath string, redirects []symbolRedirect) string {
+	if noJS || len(redirects) == 0 {
+		return ""
+	}
+
+	moved := make(map[string]string)
+	for _, r := range redirects {
+		moved[r.From] = symbolRedirectTarget(r, pkg, basePath)
+	}
+
+	data, err := json.Marshal(moved)
+	if err != nil {
+		return ""
+	}
+	return `<script>` + strings.Replace(symbolRedirectJS, "{{moved}}", string(data), 1) + `</script>`
+}
+
+// addSymbolRedirects makes links to the old anchors of the symbols of a
+// package which moved land on their new declarations. Symbols renamed within
+// the package are given their old anchor as well, so links to them land on
+// their declaration without scripts.
+func addSymbolRedirects(doc *goquery.Document, pkg string, basePath string) {
+	var scripted []symbolRedirect
+	for _, r := range symbolRedirects[pkg] {
+		if doc.Find(`[id="`+r.From+`"]`).Length() > 0 {
+			if verbose {
+				logger.Printf("Warning: %s declares %s, which is listed as moved", pkg, r.From)
+			}
+			continue
+		}
+
+		if r.ToPkg == pkg {
+			if target := doc.Find(`[id="` + r.To + `"]`).First(); target.Length() > 0 {
+				target.BeforeHtml(`<span id="` + html.EscapeString(r.From) + `"></span>`)
+				continue
+			}
+		}
+		scripted = append(scripted, r)
+	}
+
+	if script := symbolRedirectScript(pkg, basePath, scripted); script != "" {
+		doc.Find("body").First().AppendHtml(script)
+	}
+}
+
+// writeSymbolRedirectPages writes a page for each package which is no longer
+// documented, listing the symbols of the package which moved and following
+// links to their old anchors. Packages listed by -redirects are followed by
+// their redirect pages instead.
+func writeSymbolRedirectPages(ctx context.Context, buf *bytes.Buffer) error {
+	redirected := make(map[string]bool)
+	if redirectsFile != "" {
+		redirects, err := readRedirects(redirectsFile)
+		if err != nil {
+			return fmt.Errorf("failed to read redirects file %s: %s", redirectsFile, err)
+		}
+		for _, r := range redirects {
+			redirected[r.From] = true
+		}
+	}
+
+	var pkgs []string
+	for pkg := range symbolRedirects {
+		if !documentedPackages[pkg] && !redirected[pkg] {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	sort.Strings(pkgs)
+
+	for _, pkg := range pkgs {
+		outPkg := vanityPath(pkg)
+		basePath := relativeBasePath(outPkg)
+
+		err := os.MkdirAll(filepath.Join(siteDestination, outPkg), 0755)
+		if err != nil {
+			return fmt.Errorf("failed to make directory %s: %s", outPkg, err)
+		}
+
+		buf.Reset()
+		buf.WriteString(pageHeader("Moved symbols of "+html.EscapeString(pageTitle(pkg))+" - "+siteName, basePath))
+		buf.WriteString(`
+<h1>
+	Package ` + html.EscapeString(displayPath(outPkg)) + `
+</h1>
+<p>The following symbols of this package have moved.</p>
+<ul>
+`)
+		for _, r := range symbolRedirects[pkg] {
+			name := r.To
+			if r.ToPkg != "" && r.ToPkg != pkg {
+				name = displayPath(vanityPath(r.ToPkg)) + "." + r.To
+			}
+			buf.WriteString(`<li><code>` + html.EscapeString(r.From) + `</code> has moved to <a href="` + html.EscapeString(symbolRedirectTarget(r, "", basePath)) + `">` + html.EscapeString(name) + `</a></li>
+`)
+		}
+		buf.WriteString(`</ul>
+<div id="footer">` + siteFooterText(basePath) + `</div>
+</div>
+</div>
+` + searchTags(basePath) + symbolRedirectScript("", basePath, symbolRedirects[pkg]) + `</body>
+</html>
+`)
+
+		err = writeFile(ctx, buf, outPkg, "index.html")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
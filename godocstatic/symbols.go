@@ -0,0 +1,122 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/doc"
+	"html"
+	"sort"
+	"strings"
+)
+
+const symbolIndexPage = "symbols.html"
+
+type symbolIndexEntry struct {
+	Kind   string
+	Name   string
+	Anchor string
+}
+
+// packageSymbols returns the exported constants, variables, functions, types
+// and methods of a package, sorted by name. Constants and variables are
+// linked to the section of the page listing them.
+func packageSymbols(d *doc.Package) []symbolIndexEntry {
+	var symbols []symbolIndexEntry
+	addValues := func(kind string, values []*doc.Value, anchor string) {
+		for _, v := range values {
+			for _, spec := range v.Decl.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					if name.IsExported() {
+						symbols = append(symbols, symbolIndexEntry{Kind: kind, Name: name.Name, Anchor: anchor})
+					}
+				}
+			}
+		}
+	}
+
+	addValues("const", d.Consts, "pkg-constants")
+	addValues("var", d.Vars, "pkg-variables")
+	for _, f := range d.Funcs {
+		symbols = append(symbols, symbolIndexEntry{Kind: "func", Name: f.Name, Anchor: f.Name})
+	}
+	for _, t := range d.Types {
+		symbols = append(symbols, symbolIndexEntry{Kind: "type", Name: t.Name, Anchor: t.Name})
+		addValues("const", t.Consts, t.Name)
+		addValues("var", t.Vars, t.Name)
+		for _, f := range t.Funcs {
+			symbols = append(symbols, symbolIndexEntry{Kind: "func", Name: f.Name, Anchor: f.Name})
+		}
+		for _, m := range t.Methods {
+			symbols = append(symbols, symbolIndexEntry{Kind: "method", Name: t.Name + "." + m.Name, Anchor: t.Name + "." + m.Name})
+		}
+	}
+
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return strings.ToLower(symbols[i].Name) < strings.ToLower(symbols[j].Name)
+	})
+	return symbols
+}
+
+// writeSymbolIndex writes a page listing the exported symbols of every
+// documented package.
+func writeSymbolIndex(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	var index string
+	if linkIndex {
+		index = "/index.html"
+	}
+
+	buf.Reset()
+	buf.WriteString(sitePageHeader("Symbols - " + siteName))
+	buf.WriteString(`
+<h1>
+	Symbols
+</h1>
+<div id="pkg-symbols">
+`)
+
+	for _, pkg := range pkgs {
+		if _, ok := listFailures[pkg]; ok {
+			continue
+		}
+
+		p, err := loadNativePackage(ctx, pkg)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		} else if err != nil {
+			continue // This is expected for directories without source files
+		}
+
+		_, d, err := parsePackageDoc(p, 0)
+		if err != nil {
+			return err
+		} else if d == nil || d.Name == "main" {
+			continue
+		}
+
+		symbols := packageSymbols(d)
+		if len(symbols) == 0 {
+			continue
+		}
+
+		outPkg := vanityPath(pkg)
+		buf.WriteString(`<h2 id="` + html.EscapeString(outPkg) + `"><a href="` + outPkg + index + `">` + html.EscapeString(displayPath(outPkg)) + `</a></h2>
+<dl>
+`)
+		for _, s := range symbols {
+			buf.WriteString(`<dd>` + s.Kind + ` <a href="` + outPkg + index + `#` + s.Anchor + `">` + html.EscapeString(s.Name) + `</a></dd>
+`)
+		}
+		buf.WriteString(`</dl>
+`)
+	}
+
+	buf.WriteString(`</div>
+<div id="footer">` + siteFooterText("") + `</div>
+</div>
+</div>
+` + searchTags("") + `</body>
+</html>
+`)
+	return writeFile(ctx, buf, "", symbolIndexPage)
+}
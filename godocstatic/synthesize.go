@@ -0,0 +1,115 @@
+package godocstatic
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var syntheticModulePath string
+
+// errGoFileFound stops walking a directory once a Go file is found.
+var errGoFileFound = errors.New("go file found")
+
+// bareSourceDir returns whether a supplied path is a directory containing Go
+// files without a go.mod or go.work file, as in projects laid out for GOPATH.
+func bareSourceDir(arg string) bool {
+	if info, err := os.Stat(arg); err != nil || !info.IsDir() {
+		return false
+	}
+	for _, name := range []string{"go.mod", "go.work"} {
+		if _, err := os.Stat(filepath.Join(arg, name)); !os.IsNotExist(err) {
+			return false
+		}
+	}
+
+	err := filepath.Walk(arg, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		} else if info.IsDir() && p != arg && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		} else if !info.IsDir() && strings.HasSuffix(info.Name(), ".go") {
+			return errGoFileFound
+		}
+		return nil
+	})
+	return err == errGoFileFound
+}
+
+// syntheticPath returns the module path of a directory without a go.mod file:
+// the path set by -module-path, the import path of the directory when it is
+// in GOPATH, or else the name of the directory.
+func syntheticPath(dir string) string {
+	if syntheticModulePath != "" {
+		return syntheticModulePath
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+	for _, p := range filepath.SplitList(goPath) {
+		rel, err := filepath.Rel(filepath.Join(p, "src"), abs)
+		if err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.Base(abs)
+}
+
+// synthesizeModules replaces each supplied directory containing Go files
+// without a go.mod file with a copy of it below a temporary directory, with a
+// go.mod file declaring a module, so that it may be listed and documented
+// like a module. The temporary directory, created when tmpDir is not set, is
+// returned when any modules were synthesized and must be removed once
+// documentation is generated.
+func synthesizeModules(args []string, tmpDir string) ([]string, string, error) {
+	var bare []string
+	for _, arg := range args {
+		if bareSourceDir(arg) {
+			bare = append(bare, arg)
+		}
+	}
+	if len(bare) == 0 {
+		return args, tmpDir, nil
+	} else if len(bare) > 1 && syntheticModulePath != "" {
+		return nil, tmpDir, fmt.Errorf("--module-path may only be set when a single directory without a go.mod file is supplied, %d were supplied", len(bare))
+	}
+
+	if tmpDir == "" {
+		var err error
+		tmpDir, err = ioutil.TempDir(getTmpDir(), "godoc-static-download")
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	synthesized := make(map[string]string)
+	for i, arg := range bare {
+		modulePath := syntheticPath(arg)
+		if !quiet {
+			logger.Printf("Synthesizing module %s for %s, which has no go.mod file...", modulePath, arg)
+		}
+
+		dir := filepath.Join(tmpDir, "module"+fmt.Sprint(i), path.Base(modulePath))
+		err := copyModule(&downloadedModule{Path: modulePath, Dir: arg}, dir)
+		if err != nil {
+			return nil, tmpDir, fmt.Errorf("failed to copy %s: %s", arg, err)
+		}
+		synthesized[arg] = dir
+	}
+
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		if dir, ok := synthesized[arg]; ok {
+			expanded[i] = dir
+		} else {
+			expanded[i] = arg
+		}
+	}
+	return expanded, tmpDir, nil
+}
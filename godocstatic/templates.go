@@ -0,0 +1,213 @@
+package godocstatic
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Names of the templates which override parts of the generated pages.
+const (
+	templateIndex  = "index.html"
+	templateTopBar = "topbar.html"
+	templatePage   = "page.html"
+	templateFooter = "footer.html"
+)
+
+var templatesDir string
+
+// siteTemplates holds the templates read from templatesDir, or nil when
+// pages are written using the built-in markup only.
+var siteTemplates *template.Template
+
+// topBarTemplateData is the data of topbar.html.
+type topBarTemplateData struct {
+	BasePath        string
+	SiteName        string
+	IndexURL        string
+	VersionSwitcher template.HTML
+	Search          template.HTML
+	ThemeToggle     template.HTML
+	// Content is the built-in top bar.
+	Content template.HTML
+}
+
+// footerTemplateData is the data of footer.html.
+type footerTemplateData struct {
+	BasePath   string
+	SiteFooter template.HTML
+	// Content is the built-in footer.
+	Content template.HTML
+}
+
+// indexTemplatePackage is a package listed by index.html. URL is empty when
+// the package is not documented, as for directories containing packages.
+type indexTemplatePackage struct {
+	Path        string
+	DisplayPath string
+	URL         string
+	Synopsis    string
+	Package     bool
+	Command     bool
+}
+
+// indexTemplateData is the data of index.html, which writes the content of
+// each page of the index.
+type indexTemplateData struct {
+	SiteName    string
+	Description template.HTML
+	Page        int
+	Pages       int
+	Packages    []indexTemplatePackage
+	// Content is the built-in content of the page.
+	Content template.HTML
+}
+
+// pageTemplateData is the data of page.html, which writes each page laid out
+// with a top bar and footer, such as package, source file and index pages.
+type pageTemplateData struct {
+	Title    string
+	BasePath string
+	SiteName string
+	// Head holds the elements of the head of the page other than its title,
+	// such as its style sheets and meta tags.
+	Head    template.HTML
+	TopBar  template.HTML
+	Content template.HTML
+	Footer  template.HTML
+	// Scripts holds the elements following the page, such as search scripts.
+	Scripts template.HTML
+}
+
+// loadTemplates parses the Go html/template files in templatesDir. Files
+// other than index.html, topbar.html, page.html and footer.html may define
+// templates used by them.
+func loadTemplates() error {
+	siteTemplates = nil
+	if templatesDir == "" {
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(templatesDir, "*.html"))
+	if err != nil {
+		return fmt.Errorf("failed to read templates: %s", err)
+	} else if len(files) == 0 {
+		return fmt.Errorf("failed to read templates: no .html files found in %s", templatesDir)
+	}
+	sort.Strings(files)
+
+	siteTemplates, err = template.ParseFiles(files...)
+	if err != nil {
+		return fmt.Errorf("failed to parse templates: %s", err)
+	}
+	return nil
+}
+
+// templatesHash returns a hash of the template files, or an empty string when
+// pages are not templated.
+func templatesHash() string {
+	if siteTemplates == nil {
+		return ""
+	}
+
+	files, _ := filepath.Glob(filepath.Join(templatesDir, "*.html"))
+	sort.Strings(files)
+
+	var b strings.Builder
+	for _, file := range files {
+		data, _ := ioutil.ReadFile(file)
+		b.WriteString(filepath.Base(file) + " " + hashBytes(data) + "\n")
+	}
+	return hashBytes([]byte(b.String()))
+}
+
+// executeTemplate executes a template of templatesDir, returning false when it
+// is not defined or fails, in which case the built-in markup is used.
+func executeTemplate(name string, data interface{}) (string, bool) {
+	if siteTemplates == nil || siteTemplates.Lookup(name) == nil {
+		return "", false
+	}
+
+	var b strings.Builder
+	err := siteTemplates.ExecuteTemplate(&b, name, data)
+	if err != nil {
+		logger.Printf("Warning: failed to execute template %s: %s", name, err)
+		return "", false
+	}
+	return b.String(), true
+}
+
+// indexTemplatePackages returns the packages listed on a page of the index.
+func indexTemplatePackages(rows []indexRow, index string) []indexTemplatePackage {
+	pkgs := make([]indexTemplatePackage, len(rows))
+	for i, row := range rows {
+		pkgs[i] = indexTemplatePackage{
+			Path:        row.Pkg,
+			DisplayPath: displayPath(row.OutPkg),
+			Synopsis:    row.Synopsis,
+			Package:     row.Package,
+			Command:     row.Command,
+		}
+		if row.Link {
+			pkgs[i].URL = row.OutPkg + index
+		}
+	}
+	return pkgs
+}
+
+// applyPageTemplate lays out a page with page.html, when it is defined and
+// the page has a top bar and footer.
+func applyPageTemplate(buf *bytes.Buffer, fileDir string) error {
+	if siteTemplates == nil || siteTemplates.Lookup(templatePage) == nil {
+		return nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return fmt.Errorf("failed to parse HTML: %s", err)
+	}
+	topBar := doc.Find("#topbar").First()
+	container := doc.Find("#page > .container").First()
+	if topBar.Length() == 0 || container.Length() == 0 {
+		return nil
+	}
+
+	footer, _ := container.Find("#footer").Last().Html()
+	container.Find("#footer").Remove()
+
+	title := doc.Find("title").First().Text()
+	doc.Find("head title").Remove()
+
+	var scripts strings.Builder
+	doc.Find("#page").NextAll().Each(func(_ int, s *goquery.Selection) {
+		if h, err := goquery.OuterHtml(s); err == nil {
+			scripts.WriteString(h + "\n")
+		}
+	})
+
+	head, _ := doc.Find("head").Html()
+	topBarHTML, _ := topBar.Html()
+	content, _ := container.Html()
+
+	out, ok := executeTemplate(templatePage, &pageTemplateData{
+		Title:    title,
+		BasePath: relativeBasePath(fileDir),
+		SiteName: siteName,
+		Head:     template.HTML(head),
+		TopBar:   template.HTML(topBarHTML),
+		Content:  template.HTML(content),
+		Footer:   template.HTML(footer),
+		Scripts:  template.HTML(scripts.String()),
+	})
+	if ok {
+		buf.Reset()
+		buf.WriteString(out)
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"html"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const testDocsPage = "tests.html"
+
+var testDocs bool
+
+// testFuncKinds lists the prefixes of the functions run by go test, in the
+// order they are listed, with the heading of each list.
+var testFuncKinds = []struct {
+	Prefix  string
+	Heading string
+	ID      string
+}{
+	{"Test", "Tests", "tests"},
+	{"Benchmark", "Benchmarks", "benchmarks"},
+	{"Fuzz", "Fuzz targets", "fuzz"},
+	{"Example", "Examples", "examples"},
+}
+
+type testDecl struct {
+	Name string
+	Decl string
+	Doc  string
+	File string
+	Line int
+}
+
+// testDocumentation describes the test files of a package.
+type testDocumentation struct {
+	// Helpers lists the exported functions and types declared in test files
+	// which are not run by go test.
+	Helpers []testDecl
+	// Funcs lists the functions run by go test, by prefix.
+	Funcs map[string][]testDecl
+}
+
+// testFuncPrefix returns the prefix of testFuncKinds a function run by go test
+// is named with, or an empty string when the function is not run by go test.
+func testFuncPrefix(fn *ast.FuncDecl) string {
+	if fn.Recv != nil || fn.Name.Name == "TestMain" {
+		return ""
+	}
+	for _, kind := range testFuncKinds {
+		if !strings.HasPrefix(fn.Name.Name, kind.Prefix) {
+			continue
+		}
+		// The name must not continue with a lower case letter, as with go test.
+		rest := fn.Name.Name[len(kind.Prefix):]
+		if r, _ := utf8.DecodeRuneInString(rest); rest != "" && unicode.IsLower(r) {
+			continue
+		}
+		params := fn.Type.Params.NumFields()
+		if (kind.Prefix == "Example" && params == 0) || (kind.Prefix != "Example" && params == 1) {
+			return kind.Prefix
+		}
+	}
+	return ""
+}
+
+// packageTestDocs parses the test files of a package, returning nil when it
+// has none.
+func packageTestDocs(p *nativePackage) (*testDocumentation, error) {
+	files := append(append([]string{}, p.TestGoFiles...), p.XTestGoFiles...)
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	r := &nativeRenderer{fset: token.NewFileSet(), pkg: p.ImportPath}
+	d := &testDocumentation{Funcs: make(map[string][]testDecl)}
+	for _, file := range files {
+		f, err := parser.ParseFile(r.fset, filepath.Join(p.Dir, file), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		declaration := func(name string, node ast.Node, pos token.Pos, comment *ast.CommentGroup) testDecl {
+			return testDecl{
+				Name: name,
+				Decl: r.node(node),
+				Doc:  comment.Text(),
+				File: file,
+				Line: r.fset.Position(pos).Line,
+			}
+		}
+
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if prefix := testFuncPrefix(decl); prefix != "" {
+					d.Funcs[prefix] = append(d.Funcs[prefix], declaration(decl.Name.Name, decl, decl.Pos(), decl.Doc))
+					continue
+				} else if !decl.Name.IsExported() || decl.Name.Name == "TestMain" {
+					continue
+				}
+
+				name := decl.Name.Name
+				if decl.Recv != nil {
+					typ := decl.Recv.List[0].Type
+					if star, ok := typ.(*ast.StarExpr); ok {
+						typ = star.X
+					}
+					ident, ok := typ.(*ast.Ident)
+					if !ok || !ident.IsExported() {
+						continue
+					}
+					name = ident.Name + "." + name
+				}
+				signature := *decl
+				signature.Body = nil
+				signature.Doc = nil
+				d.Helpers = append(d.Helpers, declaration(name, &signature, decl.Pos(), decl.Doc))
+			case *ast.GenDecl:
+				if decl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					spec := spec.(*ast.TypeSpec)
+					if !spec.Name.IsExported() {
+						continue
+					}
+					comment := spec.Doc
+					if comment == nil && len(decl.Specs) == 1 {
+						comment = decl.Doc
+					}
+					typeDecl := &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{spec}}
+					d.Helpers = append(d.Helpers, declaration(spec.Name.Name, typeDecl, spec.Pos(), comment))
+				}
+			}
+		}
+	}
+
+	if len(d.Helpers) == 0 && len(d.Funcs) == 0 {
+		return nil, nil
+	}
+	return d, nil
+}
+
+// addTestDocs links the page documenting the tests of a package from its
+// page, returning the documentation of its tests, or nil when it has none.
+func addTestDocs(ctx context.Context, doc *goquery.Document, pkg string) (*testDocumentation, error) {
+	if !testDocs {
+		return nil, nil
+	}
+
+	p, err := loadNativePackage(ctx, pkg)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	} else if err != nil {
+		return nil, nil // This is expected for packages without source files
+	}
+
+	d, err := packageTestDocs(p)
+	if err != nil || d == nil {
+		return nil, err
+	}
+
+	doc.Find("#short-nav").First().Find("dl").Last().AppendHtml(`<dd><a href="` + testDocsPage + `">Tests</a></dd>`)
+	return d, nil
+}
+
+// writeTestDocsPage writes a page documenting the exported test helpers of a
+// package and listing its tests, benchmarks, fuzz targets and examples.
+func writeTestDocsPage(ctx context.Context, buf *bytes.Buffer, pkg string, outPkg string, d *testDocumentation) error {
+	basePath := relativeBasePath(outPkg)
+	r := &nativeRenderer{}
+
+	var index string
+	if linkIndex {
+		index = "index.html"
+	}
+
+	sourceLink := func(decl testDecl) string {
+		line := strconv.Itoa(decl.Line)
+		return `<a href="` + basePath + "src/" + outPkg + "/" + decl.File + ".html#L" + line + `">` + html.EscapeString(decl.File) + ":" + line + `</a>`
+	}
+
+	buf.Reset()
+	buf.WriteString(pageHeader("Tests of "+html.EscapeString(pageTitle(pkg))+" - "+siteName, basePath))
+	buf.WriteString(`
+<h1>
+	Tests of package ` + html.EscapeString(displayPath(outPkg)) + `
+</h1>
+<p><a href="./` + index + `">Package documentation</a></p>
+<div id="short-nav">
+<dl>
+`)
+	if len(d.Helpers) > 0 {
+		buf.WriteString(`<dd><a href="#test-helpers">Test helpers</a></dd>
+`)
+	}
+	for _, kind := range testFuncKinds {
+		if len(d.Funcs[kind.Prefix]) > 0 {
+			buf.WriteString(`<dd><a href="#` + kind.ID + `">` + kind.Heading + `</a></dd>
+`)
+		}
+	}
+	buf.WriteString(`</dl>
+</div>
+`)
+
+	if len(d.Helpers) > 0 {
+		buf.WriteString(`<h2 id="test-helpers">Test helpers</h2>
+`)
+		for _, helper := range d.Helpers {
+			buf.WriteString(`<h3 id="` + html.EscapeString(helper.Name) + `">` + html.EscapeString(helper.Name) + ` <a class="permalink" href="#` + html.EscapeString(helper.Name) + `">&#xb6;</a></h3>
+<pre>` + html.EscapeString(helper.Decl) + `</pre>
+` + r.comment(helper.Doc) + `<p>Declared in ` + sourceLink(helper) + `</p>
+`)
+		}
+	}
+
+	for _, kind := range testFuncKinds {
+		funcs := d.Funcs[kind.Prefix]
+		if len(funcs) == 0 {
+			continue
+		}
+
+		buf.WriteString(`<h2 id="` + kind.ID + `">` + kind.Heading + `</h2>
+<table>
+`)
+		for _, fn := range funcs {
+			buf.WriteString(`<tr><td><code>` + html.EscapeString(fn.Name) + `</code></td><td>` + sourceLink(fn) + `</td><td>` + html.EscapeString(doc.Synopsis(fn.Doc)) + `</td></tr>
+`)
+		}
+		buf.WriteString(`</table>
+`)
+	}
+
+	buf.WriteString(`<div id="footer">` + siteFooterText(basePath) + `</div>
+</div>
+</div>
+` + searchTags(basePath) + `</body>
+</html>
+`)
+
+	err := writeFile(ctx, buf, outPkg, testDocsPage)
+	if err != nil {
+		return fmt.Errorf("failed to write tests of %s: %s", pkg, err)
+	}
+	return nil
+}
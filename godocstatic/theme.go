@@ -0,0 +1,421 @@
+package godocstatic
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/chroma"
+)
+
+const (
+	themeClassic = "classic"
+	themeModern  = "modern"
+)
+
+var themeName string
+
+const lightTheme = `
+	color-scheme: light;
+	--background: #fff;
+	--text: #222;
+	--text-muted: #666;
+	--link: #375eab;
+	--heading: #375eab;
+	--heading-secondary: #5279c7;
+	--heading-background: #e0ebf5;
+	--topbar-background: #e0ebf5;
+	--code-background: #efefef;
+	--line-number: #999;
+	--comment: #006600;
+	--highlight: #ffff00;
+	--selection: #ff9632;
+	--alert: #aa0000;
+	--border: #375eab;
+	--input-background: #fff;
+	--warning-text: #7a5300;
+	--warning-background: #fff8e1;
+	--warning-border: #b8860b;
+`
+
+const darkTheme = `
+	color-scheme: dark;
+	--background: #1b1d23;
+	--text: #e0e0e0;
+	--text-muted: #a0a4ab;
+	--link: #8ab4f8;
+	--heading: #8ab4f8;
+	--heading-secondary: #a7c1f2;
+	--heading-background: #263043;
+	--topbar-background: #263043;
+	--code-background: #262a33;
+	--line-number: #8a8f98;
+	--comment: #7ec699;
+	--highlight: #6b5d00;
+	--selection: #8a4b00;
+	--alert: #ff8a80;
+	--border: #5b7fc7;
+	--input-background: #262a33;
+	--warning-text: #e0b44c;
+	--warning-background: #3a2f12;
+	--warning-border: #c9a227;
+`
+
+// paletteCSS defines the colors of the light and dark themes as custom
+// properties. The dark theme is used when preferred by the browser, unless the
+// light theme is selected with the theme toggle.
+func paletteCSS(light string, dark string) string {
+	return `
+:root {` + light + `}
+:root[data-theme="dark"] {` + dark + `}
+@media (prefers-color-scheme: dark) {
+:root:not([data-theme="light"]) {` + dark + `}
+}
+`
+}
+
+// themeCSS applies the colors of the palette to the elements styled by godoc.
+const themeCSS = `body { color: var(--text); background-color: var(--background); }
+a, .exampleHeading .text, .expandAll { color: var(--link); }
+h1, h2, h3, h4, .rootHeading { color: var(--heading); }
+h2 { background: var(--heading-background); }
+h2 > span, h3 > span { color: var(--heading-secondary); }
+h1 .text-muted, div#footer { color: var(--text-muted); }
+div#topbar { background: var(--topbar-background); }
+.top-heading a { color: var(--text); }
+pre { background: var(--code-background); }
+pre .ln { color: var(--line-number); background: var(--code-background); }
+pre .comment { color: var(--comment); }
+pre .highlight, pre .highlight-comment, pre .selection-highlight, pre .selection-highlight-comment { background: var(--highlight); }
+pre .selection, pre .selection-comment { background: var(--selection); }
+.alert { color: var(--alert); }
+hr { border-top-color: var(--text-muted); }
+input, select { color: var(--text); background: var(--input-background); }
+.theme-toggle { margin-left: 0.625rem; padding: 0.3125rem 0.625rem; font-size: 1rem; color: var(--text); background: var(--input-background); border: 0.0625rem solid var(--border); border-radius: 0.3125rem; cursor: pointer; }
+`
+
+// themeJS applies the theme selected with the theme toggle before the page is
+// displayed and persists the selection across pages. It also marks the page
+// as scripted, which displays controls requiring scripts.
+const themeJS = `(function() {
+	var root = document.documentElement;
+	root.classList.add('js');
+	var key = 'godoc-static-theme';
+	function stored() {
+		try {
+			return window.localStorage.getItem(key);
+		} catch (e) {
+			return null;
+		}
+	}
+	function store(value) {
+		try {
+			window.localStorage.setItem(key, value);
+		} catch (e) {
+		}
+	}
+	function current() {
+		var theme = root.getAttribute('data-theme');
+		if (theme) {
+			return theme;
+		}
+		return window.matchMedia && window.matchMedia('(prefers-color-scheme: dark)').matches ? 'dark' : 'light';
+	}
+	var theme = stored();
+	if (theme === 'light' || theme === 'dark') {
+		root.setAttribute('data-theme', theme);
+	}
+	document.addEventListener('DOMContentLoaded', function() {
+		var buttons = document.querySelectorAll('.theme-toggle');
+		function update() {
+			Array.prototype.forEach.call(buttons, function(button) {
+				button.setAttribute('aria-pressed', current() === 'dark' ? 'true' : 'false');
+			});
+		}
+		Array.prototype.forEach.call(buttons, function(button) {
+			button.addEventListener('click', function() {
+				var theme = current() === 'dark' ? 'light' : 'dark';
+				root.setAttribute('data-theme', theme);
+				store(theme);
+				update();
+			});
+		});
+		update();
+	});
+})();
+`
+
+// themeToggle returns a button switching between the light and dark themes.
+func themeToggle() string {
+	if noJS {
+		return ""
+	}
+	return `<button type="button" class="theme-toggle js-only" aria-label="Dark theme" title="Toggle dark theme">&#9680;</button>`
+}
+
+const modernLightPalette = `
+	--background: #fff;
+	--text: #202224;
+	--text-muted: #5f6368;
+	--link: #007d9c;
+	--heading: #202224;
+	--heading-secondary: #5f6368;
+	--heading-background: transparent;
+	--topbar-background: #fff;
+	--code-background: #f8f9fa;
+	--border: #dadce0;
+	--rule: #dadce0;
+	--card-background: #fff;
+`
+
+const modernDarkPalette = `
+	--background: #202124;
+	--text: #e8eaed;
+	--text-muted: #9aa0a6;
+	--link: #4fc3e0;
+	--heading: #e8eaed;
+	--heading-secondary: #9aa0a6;
+	--heading-background: transparent;
+	--topbar-background: #292a2d;
+	--code-background: #2b2d31;
+	--border: #5f6368;
+	--rule: #3c4043;
+	--card-background: #292a2d;
+`
+
+// modernCSS lays out pages like pkg.go.dev: full width, with a flat top bar,
+// unshaded headings and packages listed as cards.
+const modernCSS = `
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, Helvetica, Arial, sans-serif; text-align: left; line-height: 1.5; }
+pre, code { font-family: "Source Code Pro", Menlo, Consolas, monospace; }
+div#topbar { height: auto; overflow: visible; box-shadow: 0 0.0625rem 0.25rem rgba(0, 0, 0, 0.15); }
+div#topbar > .container, div#page > .container { max-width: 75rem; margin: 0 auto; padding: 0 1.5rem; text-align: left; }
+.top-bar { display: flex; flex-wrap: wrap; align-items: center; justify-content: space-between; min-height: 3.5rem; }
+.top-brand { font-size: 1.25rem; font-weight: 600; color: var(--text); text-decoration: none; }
+nav#menu { display: flex; flex-wrap: wrap; align-items: center; }
+nav#menu > * { margin-left: 0.625rem; }
+nav#menu > a { font-weight: 500; text-decoration: none; }
+h1 { font-size: 2rem; font-weight: 600; margin: 1.5rem 0 1rem; }
+h2 { padding: 0.5rem 0; font-size: 1.375rem; font-weight: 600; border-bottom: 0.0625rem solid var(--rule); }
+pre { padding: 0.75rem 1rem; border-radius: 0.375rem; }
+.pkg-cards { display: grid; grid-template-columns: repeat(auto-fill, minmax(18rem, 1fr)); grid-gap: 1rem; margin: 1rem 0; padding: 0; list-style: none; }
+.pkg-card { padding: 1rem; background: var(--card-background); border: 0.0625rem solid var(--rule); border-radius: 0.5rem; }
+.pkg-card-name { font-weight: 600; overflow-wrap: anywhere; }
+.pkg-card-synopsis { margin: 0.5rem 0 0; color: var(--text-muted); }
+div#footer { padding-top: 1rem; border-top: 0.0625rem solid var(--rule); }
+`
+
+// siteTheme describes the presentation of generated pages.
+type siteTheme struct {
+	// LightPalette and DarkPalette override the colors of the light and dark
+	// palettes.
+	LightPalette string
+	DarkPalette  string
+
+	// CSS is appended to the style sheet.
+	CSS string
+
+	// TopBar returns the content of the top bar of each page.
+	TopBar func(basePath string, siteName string) string
+
+	// IndexList writes the packages listed on each page of the index.
+	IndexList func(buf *bytes.Buffer, rows []indexRow, index string)
+}
+
+var siteThemes = map[string]*siteTheme{
+	themeClassic: {
+		TopBar:    classicTopBar,
+		IndexList: writeIndexTable,
+	},
+	themeModern: {
+		LightPalette: modernLightPalette,
+		DarkPalette:  modernDarkPalette,
+		CSS:          modernCSS,
+		TopBar:       modernTopBar,
+		IndexList:    writeIndexCards,
+	},
+}
+
+// currentTheme returns the theme selected with -theme.
+func currentTheme() *siteTheme {
+	if t, ok := siteThemes[themeName]; ok {
+		return t
+	}
+	return siteThemes[themeClassic]
+}
+
+// themeStyleSheet returns the styles appended to the style sheet of godoc.
+func themeStyleSheet() string {
+	t := currentTheme()
+
+	css := paletteCSS(lightTheme, darkTheme) + themeCSS
+	if t.LightPalette != "" || t.DarkPalette != "" {
+		css += paletteCSS(t.LightPalette, t.DarkPalette)
+	}
+	return css
+}
+
+func validateTheme() error {
+	if themeName == "" {
+		return nil
+	}
+	if _, ok := siteThemes[themeName]; ok {
+		return nil
+	}
+
+	var names []string
+	for name := range siteThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("unknown theme %s: must be one of %s", themeName, strings.Join(names, ", "))
+}
+
+// colorPalette is an alternative palette of the light and dark themes and of
+// the tokens of highlighted source files.
+type colorPalette struct {
+	Light string
+	Dark  string
+	// LightTokens and DarkTokens color the tokens of highlighted source files
+	// in the light and dark themes, by token category.
+	LightTokens map[chroma.TokenType]string
+	DarkTokens  map[chroma.TokenType]string
+}
+
+// themeVariants are alternative highlight and link color palettes. Each
+// palette keeps a contrast ratio of at least 4.5:1 against the page background
+// of its theme and avoids color pairs which are indistinguishable to viewers
+// with the named type of color vision deficiency, such as the red and green
+// of deleted and inserted lines, which are replaced with orange and blue.
+var themeVariants = map[string]colorPalette{
+	"deuteranopia": {
+		Light: `
+	--link: #005a9c;
+	--heading: #005a9c;
+	--heading-secondary: #0060a0;
+	--comment: #8f4b00;
+	--highlight: #f0e442;
+	--selection: #56b4e9;
+	--alert: #a3005c;
+`,
+		Dark: `
+	--link: #56b4e9;
+	--heading: #56b4e9;
+	--heading-secondary: #8fcbf0;
+	--comment: #e69f00;
+	--highlight: #6b5d00;
+	--selection: #00507a;
+	--alert: #e58fbf;
+`,
+		LightTokens: map[chroma.TokenType]string{
+			chroma.Keyword:         "#005a9c",
+			chroma.LiteralString:   "#8f4b00",
+			chroma.LiteralNumber:   "#6a3d9a",
+			chroma.Comment:         "#595959",
+			chroma.GenericDeleted:  "#8f4b00",
+			chroma.GenericInserted: "#005a9c",
+		},
+		DarkTokens: map[chroma.TokenType]string{
+			chroma.Keyword:         "#56b4e9",
+			chroma.LiteralString:   "#e69f00",
+			chroma.LiteralNumber:   "#e58fbf",
+			chroma.Comment:         "#a0a4ab",
+			chroma.GenericDeleted:  "#e69f00",
+			chroma.GenericInserted: "#56b4e9",
+		},
+	},
+	"protanopia": {
+		Light: `
+	--link: #005a9c;
+	--heading: #005a9c;
+	--heading-secondary: #0060a0;
+	--comment: #7a4a00;
+	--highlight: #f0e442;
+	--selection: #56b4e9;
+	--alert: #5b3a96;
+`,
+		Dark: `
+	--link: #56b4e9;
+	--heading: #56b4e9;
+	--heading-secondary: #8fcbf0;
+	--comment: #e0b44c;
+	--highlight: #6b5d00;
+	--selection: #00507a;
+	--alert: #b8a2e8;
+`,
+		LightTokens: map[chroma.TokenType]string{
+			chroma.Keyword:         "#005a9c",
+			chroma.LiteralString:   "#7a4a00",
+			chroma.LiteralNumber:   "#5b3a96",
+			chroma.Comment:         "#595959",
+			chroma.GenericDeleted:  "#7a4a00",
+			chroma.GenericInserted: "#005a9c",
+		},
+		DarkTokens: map[chroma.TokenType]string{
+			chroma.Keyword:         "#56b4e9",
+			chroma.LiteralString:   "#e0b44c",
+			chroma.LiteralNumber:   "#b8a2e8",
+			chroma.Comment:         "#a0a4ab",
+			chroma.GenericDeleted:  "#e0b44c",
+			chroma.GenericInserted: "#56b4e9",
+		},
+	},
+}
+
+// themeVariantCSS returns the rules applying the palette of -theme-variant to
+// the light and dark themes and to highlighted source files, which follow the
+// rules they override.
+func themeVariantCSS() string {
+	palette, ok := themeVariants[themeVariant]
+	if !ok {
+		return ""
+	}
+
+	css := paletteCSS(palette.Light, palette.Dark)
+	if !sourceHighlighting() {
+		return css
+	}
+	css += tokenColorRules("", palette.LightTokens)
+	if sourceStyleDark != sourceStyleNone {
+		css += tokenColorRules(`:root[data-theme="dark"] `, palette.DarkTokens) +
+			"@media (prefers-color-scheme: dark) {\n" +
+			tokenColorRules(`:root:not([data-theme="light"]) `, palette.DarkTokens) +
+			"}\n"
+	}
+	return css
+}
+
+// tokenColorRules returns CSS rules coloring the tokens of highlighted source
+// files which belong to each token category. Each rule begins with prefix.
+func tokenColorRules(prefix string, colors map[chroma.TokenType]string) string {
+	var rules []string
+	for t, class := range chroma.StandardTypes {
+		if class == "" {
+			continue
+		}
+		for category, color := range colors {
+			if t == category || (category%1000 == 0 && t.InCategory(category)) || (category%100 == 0 && t.InSubCategory(category)) {
+				rules = append(rules, prefix+"pre.chroma ."+class+" { color: "+color+"; }\n")
+			}
+		}
+	}
+	sort.Strings(rules)
+	return strings.Join(rules, "")
+}
+
+func validateThemeVariant() error {
+	if themeVariant == "" {
+		return nil
+	}
+	if _, ok := themeVariants[themeVariant]; ok {
+		return nil
+	}
+
+	var variants []string
+	for variant := range themeVariants {
+		variants = append(variants, variant)
+	}
+	sort.Strings(variants)
+	return fmt.Errorf("unknown theme variant %s: must be one of %s", themeVariant, strings.Join(variants, ", "))
+}
@@ -0,0 +1,95 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// PageTransformer applies custom rewrites to a generated page. Transformers
+// are invoked in order after updatePage for each package and source page.
+type PageTransformer interface {
+	// TransformPage modifies doc, the page which will be written to page, a
+	// path relative to the site destination.
+	TransformPage(ctx context.Context, page string, doc *goquery.Document) error
+}
+
+var pageTransformers []PageTransformer
+
+// stringsFlag is a flag which may be supplied multiple times.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+var transformExec stringsFlag
+
+// execTransformer pipes the HTML of each page through an external command.
+// The page path is supplied in the GODOC_STATIC_PAGE environment variable and
+// the transformed HTML is read from the command's standard output.
+type execTransformer struct {
+	command []string
+}
+
+func (t *execTransformer) TransformPage(ctx context.Context, page string, doc *goquery.Document) error {
+	var in, out, stderr bytes.Buffer
+	err := html.Render(&in, doc.Nodes[0])
+	if err != nil {
+		return fmt.Errorf("failed to render HTML: %s", err)
+	}
+
+	cmd := exec.CommandContext(ctx, t.command[0], t.command[1:]...)
+	cmd.Env = append(os.Environ(), "GODOC_STATIC_PAGE="+page)
+	cmd.Stdin = &in
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	setDeathSignal(cmd)
+
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("failed to execute %s: %s: %s", strings.Join(t.command, " "), err, strings.TrimSpace(stderr.String()))
+	} else if out.Len() == 0 {
+		return fmt.Errorf("failed to execute %s: no output", strings.Join(t.command, " "))
+	}
+
+	newDoc, err := goquery.NewDocumentFromReader(&out)
+	if err != nil {
+		return fmt.Errorf("failed to parse output of %s: %s", strings.Join(t.command, " "), err)
+	}
+	*doc = *newDoc
+	return nil
+}
+
+func addExecTransformers() error {
+	for _, command := range transformExec {
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			return errors.New("--transform-exec must not be blank")
+		}
+		pageTransformers = append(pageTransformers, &execTransformer{command: fields})
+	}
+	return nil
+}
+
+func transformPage(ctx context.Context, page string, doc *goquery.Document) error {
+	for _, t := range pageTransformers {
+		err := t.TransformPage(ctx, page, doc)
+		if err != nil {
+			return fmt.Errorf("failed to transform %s: %s", page, err)
+		}
+	}
+	return nil
+}
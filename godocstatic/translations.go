@@ -0,0 +1,290 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	gohtml "golang.org/x/net/html"
+)
+
+const defaultLanguage = "en"
+
+var (
+	translationsDir string
+	siteLanguage    string
+)
+
+var (
+	// translations maps the import paths of packages to the files of their
+	// translated overviews, by language.
+	translations map[string]map[string]string
+
+	// translationLanguages lists the languages packages are translated to,
+	// excluding siteLanguage. A tree of package pages is written for each.
+	translationLanguages []string
+)
+
+// languageTag matches BCP 47 language tags such as fr and pt-BR.
+var languageTag = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+const translationsCSS = `
+.language-switcher { margin-left: 0.625rem; }
+.language-switcher a, .language-switcher strong { margin-left: 0.3125rem; }
+`
+
+// loadTranslations reads the translated overviews of packages from
+// translationsDir, which contains a directory for each translated package,
+// named by its import path, holding a Markdown file for each language, such
+// as example.com/pkg/fr.md.
+func loadTranslations() error {
+	translations, translationLanguages = nil, nil
+	if translationsDir == "" {
+		return nil
+	}
+
+	if !languageTag.MatchString(siteLanguage) {
+		return fmt.Errorf("invalid language %s: must be a language tag such as en or pt-BR", siteLanguage)
+	}
+
+	translations = make(map[string]map[string]string)
+	languages := make(map[string]bool)
+	err := filepath.Walk(translationsDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(p) != ".md" {
+			return err
+		}
+
+		lang := strings.TrimSuffix(info.Name(), ".md")
+		if !languageTag.MatchString(lang) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(translationsDir, filepath.Dir(p))
+		if err != nil || rel == "." {
+			return err
+		}
+		pkg := filepath.ToSlash(rel)
+
+		if translations[pkg] == nil {
+			translations[pkg] = make(map[string]string)
+		}
+		translations[pkg][lang] = p
+		if lang != siteLanguage {
+			languages[lang] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read translations: %s", err)
+	}
+
+	for lang := range languages {
+		translationLanguages = append(translationLanguages, lang)
+	}
+	sort.Strings(translationLanguages)
+	return nil
+}
+
+// translationsHash returns a hash of the translated overviews, or an empty
+// string when packages are not translated.
+func translationsHash() string {
+	var pkgs []string
+	for pkg := range translations {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+
+	var b strings.Builder
+	for _, pkg := range pkgs {
+		var languages []string
+		for lang := range translations[pkg] {
+			languages = append(languages, lang)
+		}
+		sort.Strings(languages)
+
+		for _, lang := range languages {
+			data, _ := ioutil.ReadFile(translations[pkg][lang])
+			b.WriteString(pkg + " " + lang + " " + hashBytes(data) + "\n")
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return hashBytes([]byte(b.String()))
+}
+
+// languagePage returns the path of the page of a package in the tree of a
+// language.
+func languagePage(outPkg string, lang string) string {
+	var index string
+	if linkIndex {
+		index = "index.html"
+	}
+
+	p := outPkg + "/" + index
+	if lang != siteLanguage {
+		p = lang + "/" + p
+	}
+	return p
+}
+
+// localizePage marks the language of a package page, links the page to its
+// translations and adds a language switcher to its top bar.
+func localizePage(doc *goquery.Document, outPkg string, lang string) {
+	doc.Find("html").First().SetAttr("lang", lang)
+
+	dir := outPkg
+	if lang != siteLanguage {
+		dir = lang + "/" + outPkg
+	}
+	basePath := relativeBasePath(dir)
+
+	languages := append([]string{siteLanguage}, translationLanguages...)
+
+	head := doc.Find("head").First()
+	switcher := `<span class="language-switcher" aria-label="Language">`
+	for _, l := range languages {
+		target := languagePage(outPkg, l)
+
+		href := basePath + target
+		if u := pageURL(target); u != "" {
+			href = u
+		}
+		head.AppendHtml(`<link rel="alternate" hreflang="` + l + `" href="` + html.EscapeString(href) + `">`)
+
+		if l == lang {
+			switcher += `<strong>` + l + `</strong>`
+		} else {
+			switcher += `<a href="` + html.EscapeString(basePath+target) + `" hreflang="` + l + `" lang="` + l + `">` + l + `</a>`
+		}
+	}
+	switcher += `</span>`
+
+	doc.Find("#menu").First().AppendHtml(switcher)
+}
+
+// relocateLinks rewrites the relative links of a package page moved into the
+// tree of a language, keeping links to the pages of other packages within
+// the tree.
+func relocateLinks(doc *goquery.Document, outPkg string) {
+	relocate := func(selection *goquery.Selection, attr string) {
+		value, ok := selection.Attr(attr)
+		if !ok || value == "" || strings.HasPrefix(value, "#") || strings.HasPrefix(value, "/") {
+			return
+		}
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme != "" {
+			return
+		}
+
+		target := strings.TrimSuffix(path.Join(outPkg, u.Path), "/index.html")
+		if attr == "href" && documentedPackages[originalPath(target)] {
+			return
+		}
+		selection.SetAttr(attr, "../"+value)
+	}
+
+	doc.Find("[href]").Each(func(_ int, selection *goquery.Selection) {
+		relocate(selection, "href")
+	})
+	doc.Find("[src]").Each(func(_ int, selection *goquery.Selection) {
+		relocate(selection, "src")
+	})
+	doc.Find("script[data-base]").Each(func(_ int, selection *goquery.Selection) {
+		selection.SetAttr("data-base", "../"+selection.AttrOr("data-base", ""))
+	})
+}
+
+// translateOverview replaces the overview of a package page written to outDir
+// with its translation.
+func translateOverview(ctx context.Context, doc *goquery.Document, file string, outDir string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	var translated bytes.Buffer
+	err = markdownRenderer().Convert(data, &translated)
+	if err != nil {
+		return err
+	}
+
+	heading := doc.Find("#pkg-overview h2").First()
+	if heading.Length() == 0 {
+		return nil
+	}
+
+	content, err := docImagesHTML(ctx, translated.Bytes(), file, outDir)
+	if err != nil {
+		return err
+	}
+	heading.NextAllFiltered("p, h3, pre, ul, ol, figure").Remove()
+	heading.AfterHtml(string(content))
+	return nil
+}
+
+// translatePage replaces the overview of a package page with its translation
+// to a language, when the package is translated to it, and localizes the
+// page.
+func translatePage(ctx context.Context, doc *goquery.Document, pkg string, outPkg string, lang string) error {
+	file := translations[pkg][lang]
+	if file == "" {
+		file = translations[outPkg][lang]
+	}
+	if file != "" {
+		outDir := outPkg
+		if lang != siteLanguage {
+			outDir = path.Join(lang, outPkg)
+		}
+
+		err := translateOverview(ctx, doc, file, outDir)
+		if err != nil {
+			return fmt.Errorf("failed to translate %s to %s: %s", pkg, lang, err)
+		}
+	}
+
+	localizePage(doc, outPkg, lang)
+	return nil
+}
+
+// writeTranslations writes the page of a package in the tree of each
+// language, with its translated overview. Packages which are not translated
+// to a language are written with their original overview.
+func writeTranslations(ctx context.Context, buf *bytes.Buffer, pkg string, outPkg string, doc *goquery.Document) error {
+	for _, lang := range translationLanguages {
+		translated := goquery.CloneDocument(doc)
+		relocateLinks(translated, outPkg)
+
+		err := translatePage(ctx, translated, pkg, outPkg, lang)
+		if err != nil {
+			return err
+		}
+
+		dir := path.Join(lang, outPkg)
+		err = os.MkdirAll(filepath.Join(siteDestination, dir), 0755)
+		if err != nil {
+			return fmt.Errorf("failed to make directory %s: %s", dir, err)
+		}
+
+		buf.Reset()
+		err = gohtml.Render(buf, translated.Nodes[0])
+		if err != nil {
+			return fmt.Errorf("failed to render HTML: %s", err)
+		}
+		err = writeFile(ctx, buf, dir, "index.html")
+		if err != nil {
+			return fmt.Errorf("failed to write %s translation of %s: %s", lang, pkg, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// sourcePackage is a package listed by go list -deps -json.
+type sourcePackage struct {
+	ImportPath string
+	Dir        string
+	GoFiles    []string
+	CgoFiles   []string
+	ImportMap  map[string]string
+	DepOnly    bool
+	Error      *struct {
+		Err string
+	}
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+// typeCheckPackages type-checks the packages matching the patterns and their
+// dependencies from source. Packages are listed by go list run in dir, so
+// that they are resolved within the module containing dir. Packages already
+// in checked are not type-checked again, so that the types they share are
+// identical, and those type-checked are added to it. The packages matching
+// the patterns are returned, with the first error encountered while
+// type-checking each of them.
+func typeCheckPackages(ctx context.Context, dir string, fset *token.FileSet, checked map[string]*types.Package, patterns ...string) (map[string]*types.Package, map[string]error, error) {
+	var buf, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "go", append([]string{"list", "-e", "-deps", "-json"}, patterns...)...)
+	cmd.Env = godocEnv
+	cmd.Dir = dir
+	cmd.Stdout = &buf
+	cmd.Stderr = &stderr
+	setDeathSignal(cmd)
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	pkgs := make(map[string]*types.Package)
+	pkgErrs := make(map[string]error)
+	listErrs := make(map[string]error)
+
+	// Dependencies are listed before the packages importing them.
+	dec := json.NewDecoder(&buf)
+	for {
+		p := &sourcePackage{}
+		err = dec.Decode(p)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		} else if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+
+		if p.ImportPath == "unsafe" {
+			checked[p.ImportPath] = types.Unsafe
+		}
+		if checked[p.ImportPath] != nil {
+			if !p.DepOnly {
+				pkgs[p.ImportPath] = checked[p.ImportPath]
+			}
+			continue
+		}
+
+		var firstErr error
+		if p.Error != nil {
+			firstErr = errors.New(p.Error.Err)
+			if len(p.GoFiles)+len(p.CgoFiles) == 0 {
+				listErrs[p.ImportPath] = firstErr
+				if !p.DepOnly {
+					pkgErrs[p.ImportPath] = firstErr
+				}
+				continue
+			}
+		}
+
+		var files []*ast.File
+		for _, name := range append(p.GoFiles, p.CgoFiles...) {
+			f, err := parser.ParseFile(fset, filepath.Join(p.Dir, name), nil, parser.SkipObjectResolution)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			files = append(files, f)
+		}
+
+		importMap := p.ImportMap
+		conf := &types.Config{
+			Importer: importerFunc(func(path string) (*types.Package, error) {
+				if mapped, ok := importMap[path]; ok {
+					path = mapped
+				}
+				if imported := checked[path]; imported != nil {
+					return imported, nil
+				} else if err := listErrs[path]; err != nil {
+					return nil, err
+				}
+				return nil, fmt.Errorf("package %s was not listed", path)
+			}),
+			FakeImportC:      true,
+			IgnoreFuncBodies: true,
+			Error: func(err error) {
+				if firstErr == nil {
+					firstErr = err
+				}
+			},
+		}
+		// Packages are type-checked even when there are errors, so that
+		// their dependents may be type-checked as well.
+		checked[p.ImportPath], _ = conf.Check(p.ImportPath, fset, files, nil)
+
+		if !p.DepOnly {
+			pkgs[p.ImportPath] = checked[p.ImportPath]
+			if firstErr != nil {
+				pkgErrs[p.ImportPath] = firstErr
+			}
+		}
+	}
+	return pkgs, pkgErrs, nil
+}
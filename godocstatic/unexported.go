@@ -0,0 +1,76 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/doc"
+	"path"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+const unexportedPage = "internal.html"
+
+var unexported bool
+
+// addUnexportedLink links the internal view of a package, documenting its
+// unexported identifiers, from its page. It reports whether the package has
+// an internal view, which commands and directories without source files do
+// not.
+func addUnexportedLink(doc *goquery.Document) bool {
+	if !unexported || doc.Find("#pkg-index").Length() == 0 {
+		return false
+	}
+
+	doc.Find("#short-nav").First().Find("dl").Last().AppendHtml(`<dd><a href="` + unexportedPage + `">Internal view</a></dd>`)
+	return true
+}
+
+// writeUnexportedPage writes the internal view of a package, which documents
+// its unexported identifiers along with those which are exported, as godoc
+// does with ?m=all.
+func writeUnexportedPage(ctx context.Context, buf *bytes.Buffer, pkg string, outPkg string) error {
+	page, err := packageDocument(ctx, pkg, doc.AllDecls)
+	if err != nil {
+		return err
+	}
+
+	var index string
+	if linkIndex {
+		index = "index.html"
+	}
+
+	page.Find("title").First().SetHtml(fmt.Sprintf("%s (internal) - %s", pageTitle(pkg), siteName))
+
+	updatePage(page, path.Join(outPkg, unexportedPage), relativeBasePath(outPkg), siteName)
+
+	addNoindex(page, pkg, path.Join(outPkg, unexportedPage))
+
+	err = addExampleOutputs(ctx, page, pkg)
+	if err != nil {
+		return fmt.Errorf("failed to add example output to %s: %s", pkg, err)
+	}
+
+	placeExamples(page)
+
+	page.Find("#short-nav").First().Find("dl").Last().AppendHtml(`<dd><a href="./` + index + `">Exported view</a></dd>`)
+	page.Find("h1").First().AfterHtml(`<p class="internal-view">This internal view documents the unexported identifiers of the package as well as those which are exported.</p>`)
+
+	err = transformPage(ctx, path.Join(outPkg, unexportedPage), page)
+	if err != nil {
+		return err
+	}
+
+	buf.Reset()
+	err = html.Render(buf, page.Nodes[0])
+	if err != nil {
+		return fmt.Errorf("failed to render HTML: %s", err)
+	}
+	err = writeFile(ctx, buf, outPkg, unexportedPage)
+	if err != nil {
+		return fmt.Errorf("failed to write internal view of %s: %s", pkg, err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package godocstatic
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+type vanityPrefix struct {
+	Old string
+	New string
+}
+
+var (
+	vanity         stringsFlag
+	vanityPrefixes []vanityPrefix
+	trimPrefix     string
+)
+
+func parseVanity() error {
+	vanityPrefixes = nil
+	for _, mapping := range vanity {
+		equals := strings.IndexRune(mapping, '=')
+		if equals <= 0 || equals == len(mapping)-1 {
+			return fmt.Errorf("invalid vanity mapping %s: expected old-prefix=new-prefix", mapping)
+		}
+		vanityPrefixes = append(vanityPrefixes, vanityPrefix{
+			Old: strings.Trim(mapping[:equals], "/"),
+			New: strings.Trim(mapping[equals+1:], "/"),
+		})
+	}
+	return nil
+}
+
+func hasPathPrefix(p string, prefix string) bool {
+	return p == prefix || strings.HasPrefix(p, prefix+"/")
+}
+
+// replacePathPrefix replaces the longest matching prefix of p. When mappings
+// are nested, the most specific mapping is applied.
+func replacePathPrefix(p string, from func(vanityPrefix) string, to func(vanityPrefix) string) string {
+	var match *vanityPrefix
+	for i := range vanityPrefixes {
+		v := &vanityPrefixes[i]
+		if hasPathPrefix(p, from(*v)) && (match == nil || len(from(*v)) > len(from(*match))) {
+			match = v
+		}
+	}
+	if match == nil {
+		return p
+	}
+	return to(*match) + p[len(from(*match)):]
+}
+
+// vanityPath returns the import path under which a package is displayed and
+// written.
+func vanityPath(p string) string {
+	if len(vanityPrefixes) == 0 {
+		return p
+	}
+	return replacePathPrefix(p, func(v vanityPrefix) string { return v.Old }, func(v vanityPrefix) string { return v.New })
+}
+
+// originalPath returns the import path of a package displayed as p.
+func originalPath(p string) string {
+	if len(vanityPrefixes) == 0 {
+		return p
+	}
+	return replacePathPrefix(p, func(v vanityPrefix) string { return v.New }, func(v vanityPrefix) string { return v.Old })
+}
+
+// vanityParent returns whether p is only a parent directory of a vanity
+// prefix, which has no corresponding package to document.
+func vanityParent(p string) bool {
+	for _, v := range vanityPrefixes {
+		if strings.HasPrefix(v.New, p+"/") && !hasPathPrefix(p, v.New) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortByVanityPath sorts packages by their displayed import path.
+func sortByVanityPath(pkgs []string) {
+	sort.Slice(pkgs, func(i, j int) bool {
+		return strings.ToLower(vanityPath(pkgs[i])) < strings.ToLower(vanityPath(pkgs[j]))
+	})
+}
+
+// displayPath returns the import path of a package as displayed on pages,
+// without -trim-prefix. p is the vanity path of the package.
+func displayPath(p string) string {
+	prefix := strings.Trim(trimPrefix, "/")
+	if prefix == "" || !strings.HasPrefix(p, prefix+"/") {
+		return p
+	}
+	return p[len(prefix)+1:]
+}
+
+// pageTitle returns the name of a package as displayed in page titles.
+func pageTitle(pkg string) string {
+	outPkg := vanityPath(pkg)
+	if display := displayPath(outPkg); display != outPkg {
+		return display
+	}
+	return path.Base(pkg)
+}
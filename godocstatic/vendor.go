@@ -0,0 +1,235 @@
+package godocstatic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// moduleVendorPage is the name of the page listing the modules vendored into
+// a module, written to the directory of each documented module with a vendor
+// directory when -with-deps=vendor is supplied.
+const moduleVendorPage = "vendor.html"
+
+var (
+	// vendoredModules lists the modules vendored into each supplied module,
+	// by module path.
+	vendoredModules = make(map[string][]vendoredModule)
+	// vendoredVersions lists the version of each vendored module, by the
+	// directory it was vendored to.
+	vendoredVersions = make(map[string]string)
+	// moduleVendorPages lists the path of the vendored modules page of each
+	// module which has one, by module path.
+	moduleVendorPages = make(map[string]string)
+)
+
+// vendoredModule is a module listed in vendor/modules.txt.
+type vendoredModule struct {
+	Path     string
+	Version  string
+	Replace  string
+	Explicit bool
+	Go       string
+	Packages []string
+}
+
+// readVendoredModules reads the modules and packages vendored into the module
+// in dir from vendor/modules.txt. No modules are returned when the module has
+// no vendor directory.
+func readVendoredModules(dir string) ([]vendoredModule, error) {
+	f, err := os.Open(filepath.Join(dir, "vendor", "modules.txt"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mods []vendoredModule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "## "):
+			if len(mods) == 0 {
+				continue
+			}
+			m := &mods[len(mods)-1]
+			for _, annotation := range strings.Split(line[3:], ";") {
+				annotation = strings.TrimSpace(annotation)
+				if annotation == "explicit" {
+					m.Explicit = true
+				} else if strings.HasPrefix(annotation, "go ") {
+					m.Go = strings.TrimSpace(annotation[3:])
+				}
+			}
+		case strings.HasPrefix(line, "# "):
+			// Lines are formatted as path version, optionally followed by
+			// => path version, or => dir for replacements by directories.
+			fields := strings.Fields(line[2:])
+			if len(fields) == 0 {
+				continue
+			}
+			m := vendoredModule{Path: fields[0]}
+			if len(fields) > 1 && fields[1] != "=>" {
+				m.Version = fields[1]
+				fields = fields[1:]
+			}
+			if len(fields) > 2 && fields[1] == "=>" {
+				m.Replace = strings.Join(fields[2:], "@")
+			}
+			mods = append(mods, m)
+		case strings.HasPrefix(line, "#"):
+		default:
+			if len(mods) > 0 {
+				mods[len(mods)-1].Packages = append(mods[len(mods)-1].Packages, line)
+			}
+		}
+	}
+	return mods, scanner.Err()
+}
+
+// vendoredVersion returns the version of a vendored module which is built:
+// the version of its replacement when it is replaced by another version.
+func (m vendoredModule) vendoredVersion() string {
+	if at := strings.LastIndex(m.Replace, "@"); at >= 0 {
+		return m.Replace[at+1:]
+	}
+	return m.Version
+}
+
+// listVendoredDependencies lists the packages vendored into each supplied
+// module, at the versions listed in vendor/modules.txt, so that the
+// documentation matches what is built. Modules without a vendor directory
+// have no dependencies listed.
+func listVendoredDependencies(ctx context.Context) ([]dependencyPackage, error) {
+	vendoredModules = make(map[string][]vendoredModule)
+	vendoredVersions = make(map[string]string)
+
+	var (
+		pkgs   []dependencyPackage
+		listed = make(map[string]bool)
+	)
+	for _, name := range moduleNames() {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		m := modules[name]
+		mods, err := readVendoredModules(m.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vendored modules of %s: %s", name, err)
+		} else if len(mods) == 0 {
+			continue
+		}
+		vendoredModules[name] = mods
+
+		vendorDir := filepath.Join(m.Dir, "vendor")
+		for _, mod := range mods {
+			if _, ok := modules[mod.Path]; ok || listed[mod.Path] {
+				continue
+			}
+			listed[mod.Path] = true
+
+			depDir := filepath.Join(vendorDir, filepath.FromSlash(mod.Path))
+			vendoredVersions[depDir] = mod.vendoredVersion()
+			for _, pkg := range mod.Packages {
+				pkgs = append(pkgs, dependencyPackage{
+					listedPackage:  listedPackage{Pkg: pkg, Dir: filepath.Join(vendorDir, filepath.FromSlash(pkg))},
+					ModuleDir:      m.Dir,
+					DependencyPath: mod.Path,
+					DependencyDir:  depDir,
+				})
+			}
+		}
+	}
+	return pkgs, nil
+}
+
+// writeModuleVendorPages writes a page for each documented module with a
+// vendor directory, listing the vendored modules at the versions which are
+// built, each linked to the documentation of its vendored packages.
+func writeModuleVendorPages(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	moduleVendorPages = make(map[string]string)
+
+	documented := make(map[string]bool)
+	for _, pkg := range pkgs {
+		documented[pkg] = true
+	}
+
+	var index string
+	if linkIndex {
+		index = "/index.html"
+	}
+
+	for _, name := range moduleNames() {
+		mods := vendoredModules[name]
+		if len(mods) == 0 {
+			continue
+		}
+
+		outDir := vanityPath(name)
+		basePath := relativeBasePath(outDir)
+
+		buf.Reset()
+		buf.WriteString(pageHeader("Vendored modules of "+html.EscapeString(displayPath(outDir))+" - "+siteName, basePath))
+		buf.WriteString(`
+<h1>
+	Vendored modules of ` + html.EscapeString(displayPath(outDir)) + `
+</h1>
+<p>Modules vendored into the module, as listed in <code>vendor/modules.txt</code>. Their packages are documented at the versions which are built.</p>
+<table id="module-vendor">
+<tr><th>Module</th><th>Version</th><th>Replaced by</th><th>Packages</th></tr>
+`)
+		for _, mod := range mods {
+			var links []string
+			for _, pkg := range mod.Packages {
+				label := html.EscapeString(displayPath(vanityPath(pkg)))
+				if documented[pkg] {
+					label = `<a href="` + html.EscapeString(basePath+vanityPath(pkg)+index) + `">` + label + `</a>`
+				}
+				links = append(links, label)
+			}
+			packages := strings.Join(links, "<br>")
+			if packages == "" {
+				packages = "-"
+			}
+
+			version, replace := mod.Version, mod.Replace
+			if version == "" {
+				version = "-"
+			}
+			if replace == "" {
+				replace = "-"
+			}
+			buf.WriteString(`<tr><td><code>` + html.EscapeString(mod.Path) + `</code></td><td>` + html.EscapeString(version) + `</td><td>` + html.EscapeString(replace) + `</td><td>` + packages + `</td></tr>
+`)
+		}
+		buf.WriteString(`</table>
+<div id="footer">` + siteFooterText(basePath) + `</div>
+</div>
+</div>
+` + searchTags(basePath) + `</body>
+</html>
+`)
+
+		err := os.MkdirAll(filepath.Join(siteDestination, outDir), 0755)
+		if err != nil {
+			return fmt.Errorf("failed to make directory %s: %s", outDir, err)
+		}
+
+		err = writeFile(ctx, buf, outDir, moduleVendorPage)
+		if err != nil {
+			return err
+		}
+		moduleVendorPages[name] = path.Join(outDir, moduleVendorPage)
+	}
+	return nil
+}
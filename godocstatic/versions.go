@@ -0,0 +1,307 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	versions       string
+	versionList    []string
+	currentVersion string
+)
+
+// versionDir returns the name of the directory documentation of a version is
+// written to.
+func versionDir(version string) string {
+	return strings.Replace(version, "/", "-", -1)
+}
+
+// versionSwitcher returns a menu which navigates to the same page of another
+// version of the documentation, and links to the index of each version which
+// are displayed instead when scripts are unavailable.
+func versionSwitcher(basePath string) string {
+	if len(versionList) == 0 {
+		return ""
+	}
+
+	var index string
+	if linkIndex {
+		index = "index.html"
+	}
+
+	links := `<span class="version-links no-js-only">`
+	if noJS {
+		links = `<span class="version-links">`
+	}
+	for _, version := range versionList {
+		if version == currentVersion {
+			links += `<strong>` + html.EscapeString(version) + `</strong>`
+		} else {
+			links += `<a href="` + basePath + `../` + html.EscapeString(versionDir(version)) + `/` + index + `">` + html.EscapeString(version) + `</a>`
+		}
+	}
+	links += `</span>` + apiChangesLink(basePath)
+	if noJS {
+		return links
+	}
+
+	switcher := `<select class="version-switcher js-only" aria-label="Version" data-version="` + html.EscapeString(versionDir(currentVersion)) + `" onchange="var p = window.location.pathname, c = '/' + this.getAttribute('data-version') + '/', i = p.lastIndexOf(c); window.location.href = i >= 0 ? p.substr(0, i) + '/' + this.value + '/' + p.substr(i + c.length) : this.value + '/';">`
+	for _, version := range versionList {
+		selected := ""
+		if version == currentVersion {
+			selected = ` selected`
+		}
+		switcher += `<option value="` + html.EscapeString(versionDir(version)) + `"` + selected + `>` + html.EscapeString(version) + `</option>`
+	}
+	return switcher + `</select>` + links
+}
+
+func run(ctx context.Context, pkgs []string) error {
+	// The environment is initialized before versions are listed and loaded.
+	err := initGodocEnv()
+	if err != nil {
+		return err
+	}
+
+	versionList = nil
+	for _, version := range strings.Split(versions, ",") {
+		version = strings.TrimSpace(version)
+		if version != "" {
+			versionList = append(versionList, version)
+		}
+	}
+	if versionTags != "" {
+		tags, err := listVersionTags(ctx, pkgs)
+		if err != nil {
+			return err
+		} else if len(tags) == 0 {
+			return fmt.Errorf("no git tags match --version-tags %s", versionTags)
+		}
+		versionList = uniqueStrings(append(versionList, tags...))
+	}
+	if len(versionList) == 0 {
+		if apiDiff {
+			return errors.New("--api-diff requires --versions or --version-tags")
+		}
+		return generate(ctx, pkgs)
+	}
+	if watch {
+		return errors.New("--watch may not be used with --versions")
+	} else if loadStateFile != "" || saveStateFile != "" {
+		return errors.New("--save-state and --load-state may not be used with --versions")
+	}
+	return generateVersions(ctx, pkgs)
+}
+
+// resetGenerationState clears the state collected while generating
+// documentation, so that documentation may be generated again. The state
+// collected before versions are generated, such as the versions listed and
+// their APIs, is kept.
+func resetGenerationState() {
+	modules = make(map[string]*moduleInfo)
+	listFailures = make(map[string]string)
+	searchEntries = nil
+	a11yIssues = nil
+	outZipPart = 0
+	sitePages = nil
+	provenanceDirs = make(map[string]*sourceProvenance)
+	moduleLandingPages = make(map[string]string)
+	moduleDependencyPages = make(map[string]string)
+	moduleInfoPages = make(map[string]string)
+	moduleVendorPages = make(map[string]string)
+	vendoredModules = make(map[string][]vendoredModule)
+	vendoredVersions = make(map[string]string)
+	licenseCache = make(map[string][]moduleLicense)
+	importVersions = make(map[string]string)
+	noindexPages = make(map[string]bool)
+	rewriteMisses = nil
+	skippedDocs, skippedSources = nil, nil
+	symbolRedirects = nil
+	packageReferences = nil
+	interfaceImplementers, typeInterfaces = nil, nil
+	packageSourceLinks = make(map[string]map[string]map[int]sourceLink)
+	sourceLinksLoaded = make(map[string]bool)
+	documentedPackages = make(map[string]bool)
+	pkgPaths, pkgDirs = make(map[string]string), make(map[string]string)
+	workspaceDirs, workspaceModules = make(map[string]bool), nil
+	downloadedPackages = make(map[string]string)
+	annotations = nil
+	ownerRules, ownersRoot = nil, ""
+	dictionary = nil
+	translations, translationLanguages = nil, nil
+	siteTemplates = nil
+	extraStyleSheets, extraScripts = nil, nil
+	themeSCSSCSS = ""
+	indexPages = 0
+	cacheReuse, cacheDependencies, filesHashes = nil, nil, nil
+	previousCache, currentCache = nil, nil
+	godocPresentation, godocModules = nil, nil
+}
+
+// checkoutVersion checks out a version of the git repository containing dir
+// into a worktree below workTree, returning the path of dir within it.
+func checkoutVersion(ctx context.Context, dir string, version string, workTree string) (string, error) {
+	var out, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--show-toplevel")
+	cmd.Dir = dir
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	setDeathSignal(cmd)
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate git repository of %s: %s", dir, strings.TrimSpace(stderr.String()))
+	}
+	repo := strings.TrimSpace(out.String())
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	absDir, err = filepath.EvalSymlinks(absDir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(repo, absDir)
+	if err != nil {
+		return "", err
+	}
+
+	stderr.Reset()
+	cmd = exec.CommandContext(ctx, "git", "worktree", "add", "--detach", workTree, version)
+	cmd.Dir = repo
+	cmd.Stderr = &stderr
+	setDeathSignal(cmd)
+
+	err = cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to check out %s of %s: %s", version, repo, strings.TrimSpace(stderr.String()))
+	}
+	return filepath.Join(workTree, rel), nil
+}
+
+// removeWorktree removes a worktree created by checkoutVersion.
+func removeWorktree(dir string, workTree string) {
+	cmd := exec.Command("git", "worktree", "remove", "--force", workTree)
+	cmd.Dir = dir
+	setDeathSignal(cmd)
+	cmd.Run()
+}
+
+// generateVersions generates documentation for each version listed by
+// -versions into a subdirectory of the destination. Packages supplied as
+// paths are checked out at each version using git.
+func generateVersions(ctx context.Context, pkgs []string) error {
+	if siteDestination == "" {
+		return errors.New("--destination must be set")
+	}
+
+	var (
+		destination = siteDestination
+		base        = baseURL
+		description = siteDescription
+		footer      = siteFooter
+		transforms  = pageTransformers
+	)
+	defer func() {
+		siteDestination = destination
+		baseURL = base
+		siteDescription = description
+		siteFooter = footer
+		pageTransformers = transforms
+		currentVersion = ""
+	}()
+
+	if apiDiff {
+		err := loadVersionAPIs(ctx, pkgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, version := range versionList {
+		if !quiet {
+			logger.Printf("Generating documentation for %s...", version)
+		}
+
+		tmpDir, err := ioutil.TempDir(getTmpDir(), "godoc-static-version")
+		if err != nil {
+			return err
+		}
+
+		var (
+			versionPkgs []string
+			workTrees   [][2]string
+		)
+		for i, pkg := range pkgs {
+			if info, err := os.Stat(pkg); err != nil || !info.IsDir() {
+				versionPkgs = append(versionPkgs, pkg)
+				continue
+			}
+
+			workTree := filepath.Join(tmpDir, fmt.Sprint(i))
+			versionPkg, err := checkoutVersion(ctx, pkg, version, workTree)
+			if err != nil {
+				os.RemoveAll(tmpDir)
+				return err
+			}
+			workTrees = append(workTrees, [2]string{pkg, workTree})
+			versionPkgs = append(versionPkgs, versionPkg)
+		}
+
+		resetGenerationState()
+		siteDestination = filepath.Join(destination, versionDir(version))
+		if base != "" {
+			baseURL = strings.TrimSuffix(base, "/") + "/" + versionDir(version)
+		}
+		siteDescription = description
+		siteFooter = footer
+		pageTransformers = transforms
+		currentVersion = version
+
+		_, err = os.Stat(siteDestination)
+		created := os.IsNotExist(err)
+		err = os.MkdirAll(siteDestination, 0755)
+		if err == nil {
+			err = generate(ctx, versionPkgs)
+		}
+		if err != nil && created && ctx.Err() != nil {
+			os.RemoveAll(siteDestination)
+		}
+
+		for _, w := range workTrees {
+			removeWorktree(w[0], w[1])
+		}
+		os.RemoveAll(tmpDir)
+
+		if err != nil {
+			return fmt.Errorf("failed to generate documentation for %s: %s", version, err)
+		}
+	}
+
+	target := html.EscapeString(versionDir(versionList[0])) + "/"
+	if linkIndex {
+		target += "index.html"
+	}
+	return ioutil.WriteFile(filepath.Join(destination, "index.html"), []byte(`<!DOCTYPE html>
+<html>
+<head>
+<meta http-equiv="Content-Type" content="text/html; charset=utf-8">
+<meta http-equiv="refresh" content="0; url=`+target+`">
+<title>`+html.EscapeString(siteName)+`</title>
+</head>
+<body>
+<p><a href="`+target+`">`+html.EscapeString(versionList[0])+`</a></p>
+</body>
+</html>
+`), 0644)
+}
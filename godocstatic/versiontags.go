@@ -0,0 +1,191 @@
+package godocstatic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// tagsFlag is the value of -version-tags, which generates documentation for
+// every semantic version tag when supplied without a value.
+type tagsFlag string
+
+func (f *tagsFlag) String() string {
+	return string(*f)
+}
+
+func (f *tagsFlag) Set(value string) error {
+	switch value {
+	case "true":
+		value = tagsAll
+	case "false":
+		value = ""
+	}
+	*f = tagsFlag(value)
+	return nil
+}
+
+func (f *tagsFlag) IsBoolFlag() bool {
+	return true
+}
+
+const tagsAll = "all"
+
+var versionTags tagsFlag
+
+// versionConstraint is a comparison of a semantic version, such as >=v1.2.0.
+type versionConstraint struct {
+	Op      string
+	Version string
+}
+
+// parseVersionRange parses a range of semantic versions consisting of
+// comparisons separated by commas or spaces, such as >=v1.2.0,<v2.0.0. A
+// version without an operator matches itself.
+func parseVersionRange(r string) ([]versionConstraint, error) {
+	if r == tagsAll {
+		return nil, nil
+	}
+
+	var constraints []versionConstraint
+	for _, field := range strings.FieldsFunc(r, func(c rune) bool { return c == ',' || c == ' ' }) {
+		c := versionConstraint{Op: "="}
+		for _, op := range []string{">=", "<=", ">", "<", "="} {
+			if strings.HasPrefix(field, op) {
+				c.Op = op
+				field = field[len(op):]
+				break
+			}
+		}
+
+		if !strings.HasPrefix(field, "v") {
+			field = "v" + field
+		}
+		if !semver.IsValid(field) {
+			return nil, fmt.Errorf("invalid version range %s: %s is not a semantic version", r, field)
+		}
+		c.Version = field
+		constraints = append(constraints, c)
+	}
+	if len(constraints) == 0 {
+		return nil, fmt.Errorf("invalid version range %s", r)
+	}
+	return constraints, nil
+}
+
+// versionInRange returns whether a semantic version satisfies all of the
+// constraints of a range.
+func versionInRange(version string, constraints []versionConstraint) bool {
+	for _, c := range constraints {
+		cmp := semver.Compare(version, c.Version)
+		var ok bool
+		switch c.Op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// repositoryTags returns the semantic version tags of the git repository
+// containing dir by version. Tags of modules in subdirectories are prefixed
+// with the directory.
+func repositoryTags(ctx context.Context, dir string) (map[string]string, error) {
+	top := commandOutput(ctx, dir, "git", "rev-parse", "--show-toplevel")
+	if top == "" {
+		return nil, fmt.Errorf("failed to locate git repository of %s", dir)
+	}
+
+	var prefix string
+	absDir, err := filepath.Abs(dir)
+	if err == nil {
+		absDir, err = filepath.EvalSymlinks(absDir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rel, err := filepath.Rel(top, absDir); err == nil && rel != "." {
+		prefix = filepath.ToSlash(rel) + "/"
+	}
+
+	tags := make(map[string]string)
+	for _, tag := range strings.Fields(commandOutput(ctx, dir, "git", "tag", "--list")) {
+		if !strings.HasPrefix(tag, prefix) {
+			continue
+		}
+		if v := tag[len(prefix):]; semver.IsValid(v) {
+			tags[v] = tag
+		}
+	}
+	return tags, nil
+}
+
+// listVersionTags returns the semantic version tags, within the range of
+// -version-tags, shared by the git repositories of the packages supplied as
+// paths, newest first.
+func listVersionTags(ctx context.Context, pkgs []string) ([]string, error) {
+	constraints, err := parseVersionRange(string(versionTags))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		shared map[string]string
+		found  bool
+	)
+	for _, pkg := range pkgs {
+		if info, err := os.Stat(pkg); err != nil || !info.IsDir() {
+			continue
+		}
+
+		tags, err := repositoryTags(ctx, pkg)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			shared, found = tags, true
+			continue
+		}
+		for v, tag := range shared {
+			if tags[v] != tag {
+				delete(shared, v)
+			}
+		}
+	}
+	if !found {
+		return nil, errors.New("--version-tags requires at least one package supplied as a path")
+	}
+
+	var semvers []string
+	for v := range shared {
+		if versionInRange(v, constraints) {
+			semvers = append(semvers, v)
+		}
+	}
+	sort.Slice(semvers, func(i, j int) bool {
+		return semver.Compare(semvers[i], semvers[j]) > 0
+	})
+
+	tags := make([]string, len(semvers))
+	for i, v := range semvers {
+		tags[i] = shared[v]
+	}
+	return tags, nil
+}
@@ -0,0 +1,111 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watchInterval is the interval at which package directories are checked for
+// changes.
+const watchInterval = time.Second
+
+var (
+	watch bool
+
+	// pkgDirs maps packages to the directories containing their source files.
+	pkgDirs map[string]string
+)
+
+type watchedFile struct {
+	modTime time.Time
+	size    int64
+}
+
+// watchedFiles returns the modification time and size of each Go and
+// markdown file in a directory.
+func watchedFiles(dir string) map[string]watchedFile {
+	files := make(map[string]watchedFile)
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return files
+	}
+	for _, info := range infos {
+		ext := filepath.Ext(info.Name())
+		if info.IsDir() || (ext != ".go" && ext != ".md") {
+			continue
+		}
+		files[info.Name()] = watchedFile{modTime: info.ModTime(), size: info.Size()}
+	}
+	return files
+}
+
+func sameFiles(a map[string]watchedFile, b map[string]watchedFile) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, v := range a {
+		if w, ok := b[name]; !ok || !w.modTime.Equal(v.modTime) || w.size != v.size {
+			return false
+		}
+	}
+	return true
+}
+
+// watchPackages regenerates the documentation and source pages of packages
+// when their Go or markdown files change, until the context is canceled.
+func watchPackages(ctx context.Context, buf *bytes.Buffer, pkgs []string) error {
+	watched := make(map[string]map[string]watchedFile)
+	for _, pkg := range pkgs {
+		if dir := pkgDirs[pkg]; dir != "" {
+			watched[pkg] = watchedFiles(dir)
+		}
+	}
+
+	if !quiet {
+		logger.Printf("Watching %d packages for changes...", len(watched))
+	}
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		var changed []string
+		for pkg, files := range watched {
+			current := watchedFiles(pkgDirs[pkg])
+			if !sameFiles(files, current) {
+				watched[pkg] = current
+				changed = append(changed, pkg)
+			}
+		}
+		sort.Strings(changed)
+		changed = prioritizePackages(changed)
+
+		for _, pkg := range changed {
+			if !quiet {
+				logger.Printf("Regenerating %s...", pkg)
+			}
+
+			delete(listFailures, pkg)
+			err := copyPackageDocs(ctx, buf, pkg)
+			if err == nil {
+				err = copyPackageSources(ctx, buf, pkg)
+			}
+			if ctx.Err() != nil {
+				return nil
+			} else if err != nil {
+				logger.Printf("failed to regenerate %s: %s", pkg, strings.TrimSpace(err.Error()))
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package godocstatic
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// scrapePackages calls scrape for each package using up to -workers
+// goroutines, each with its own buffer. The first error returned by scrape
+// cancels the remaining packages.
+func scrapePackages(ctx context.Context, pkgs []string, scrape func(ctx context.Context, buf *bytes.Buffer, pkg string) error) error {
+	n := workers
+	if n < 1 {
+		n = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		firstErr error
+		errLock  sync.Mutex
+	)
+
+	queue := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var buf bytes.Buffer
+			for pkg := range queue {
+				err := scrape(ctx, &buf, pkg)
+				if err != nil {
+					errLock.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					errLock.Unlock()
+					cancel()
+				}
+			}
+		}()
+	}
+
+	for _, pkg := range pkgs {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- pkg
+	}
+	close(queue)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
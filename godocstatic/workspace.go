@@ -0,0 +1,156 @@
+package godocstatic
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+var (
+	// workspaceDirs lists the module directories used by the workspaces
+	// supplied.
+	workspaceDirs map[string]bool
+
+	// workspaceModules lists the paths of the modules used by the workspaces
+	// supplied, in the order they are used. The package index is grouped by
+	// module when there are several.
+	workspaceModules []string
+)
+
+// workspaceUses returns the directories of the modules used by a go.work
+// file, relative to the directory of the file unless they are absolute.
+func workspaceUses(workFilePath string) ([]string, error) {
+	data, err := ioutil.ReadFile(workFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// go.work files share the syntax of go.mod files. Directives which are
+	// not valid in go.mod files, such as use, are retained by ParseLax.
+	f, err := modfile.ParseLax(workFilePath, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var uses []string
+	addUse := func(tokens []string) error {
+		if len(tokens) != 1 {
+			return fmt.Errorf("invalid use directive: %s", strings.Join(tokens, " "))
+		}
+		dir := tokens[0]
+		if strings.HasPrefix(dir, `"`) || strings.HasPrefix(dir, "`") {
+			dir, err = strconv.Unquote(dir)
+			if err != nil {
+				return fmt.Errorf("invalid use directive: %s", tokens[0])
+			}
+		}
+		uses = append(uses, dir)
+		return nil
+	}
+
+	for _, stmt := range f.Syntax.Stmt {
+		switch stmt := stmt.(type) {
+		case *modfile.Line:
+			if len(stmt.Token) > 0 && stmt.Token[0] == "use" {
+				err = addUse(stmt.Token[1:])
+			}
+		case *modfile.LineBlock:
+			if len(stmt.Token) == 1 && stmt.Token[0] == "use" {
+				for _, line := range stmt.Line {
+					err = addUse(line.Token)
+					if err != nil {
+						break
+					}
+				}
+			}
+		}
+		if err != nil {
+			start, _ := stmt.Span()
+			return nil, fmt.Errorf("line %d: %s", start.Line, err)
+		}
+	}
+	return uses, nil
+}
+
+// expandWorkspaces replaces each supplied directory containing a go.work file
+// with the directories of the modules it uses, so that documentation is
+// generated for every module of the workspace.
+func expandWorkspaces(args []string) ([]string, error) {
+	workspaceDirs = make(map[string]bool)
+	workspaceModules = nil
+
+	var expanded []string
+	for _, arg := range args {
+		workFilePath := filepath.Join(arg, "go.work")
+		if _, err := os.Stat(workFilePath); err != nil {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		uses, err := workspaceUses(workFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read workspace file %s: %s", workFilePath, err)
+		} else if len(uses) == 0 {
+			return nil, fmt.Errorf("workspace file %s uses no modules", workFilePath)
+		}
+
+		for _, dir := range uses {
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(arg, filepath.FromSlash(dir))
+			}
+			workspaceDirs[dir] = true
+			expanded = append(expanded, dir)
+		}
+	}
+	return expanded, nil
+}
+
+// workspaceModule returns the workspace module containing a package, or an
+// empty string when it is not part of one.
+func workspaceModule(pkg string) string {
+	var module string
+	for _, modulePath := range workspaceModules {
+		if (pkg == modulePath || strings.HasPrefix(pkg, modulePath+"/")) && len(modulePath) > len(module) {
+			module = modulePath
+		}
+	}
+	return module
+}
+
+// writeWorkspaceIndexList writes the rows of the package index grouped by
+// the workspace module containing each package. Packages outside of the
+// workspace modules are listed afterwards, while directories outside of them
+// are omitted.
+func writeWorkspaceIndexList(buf *bytes.Buffer, rows []indexRow, index string) {
+	grouped := make(map[string][]indexRow)
+	for _, row := range rows {
+		module := workspaceModule(row.Pkg)
+		if module == "" && !row.Package {
+			continue
+		}
+		grouped[module] = append(grouped[module], row)
+	}
+
+	for i, module := range append(append([]string{}, workspaceModules...), "") {
+		moduleRows := grouped[module]
+		if len(moduleRows) == 0 {
+			continue
+		}
+
+		if module == "" {
+			buf.WriteString(`<h2 id="pkg-other">Other packages</h2>
+`)
+		} else {
+			buf.WriteString(`<h2 id="pkg-module-` + strconv.Itoa(i+1) + `">Module ` + html.EscapeString(displayPath(vanityPath(module))) + `</h2>
+`)
+		}
+		currentTheme().IndexList(buf, moduleRows, index)
+	}
+}
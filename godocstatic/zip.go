@@ -0,0 +1,186 @@
+package godocstatic
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// zipEntryOverhead is a generous estimate of the header and directory bytes
+// added to an archive for each file.
+const zipEntryOverhead = 1024
+
+const (
+	// zipDirectoryHeaderLen is the length of the central directory header of
+	// each file, excluding its name.
+	zipDirectoryHeaderLen = 46
+	// zipDirectoryEndLen is the length of the end of central directory record.
+	zipDirectoryEndLen = 22
+)
+
+type countingWriter struct {
+	f *os.File
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.f.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+var (
+	outZip        *zip.Writer
+	outZipFile    *countingWriter
+	outZipPart    int
+	outZipEntries int
+	// outZipDirectory is the length of the central directory of the current
+	// part, which is written when the part is closed.
+	outZipDirectory int64
+	// outZipPending is the length of the last file added to the current part,
+	// which bounds the compressed data of the file which is not yet written
+	// until the next file is added.
+	outZipPending int64
+	zipSplitBytes int64
+	outZipLock    sync.Mutex
+)
+
+// parseSize parses a size such as 500000, 300K, 200MB or 1G into bytes.
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimSuffix(s, "B")
+	if s == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	switch unit := s[len(s)-1]; unit {
+	case 'K':
+		multiplier = 1 << 10
+	case 'M':
+		multiplier = 1 << 20
+	case 'G':
+		multiplier = 1 << 30
+	default:
+		if !unicode.IsDigit(rune(unit)) {
+			return 0, fmt.Errorf("unknown size unit %c", unit)
+		}
+	}
+	if multiplier > 1 {
+		s = s[:len(s)-1]
+	}
+
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return v * multiplier, nil
+}
+
+// zipPartName returns the file name of a numbered part of the site ZIP file.
+func zipPartName(part int) string {
+	if zipSplitBytes == 0 {
+		return siteZip
+	}
+	return splitZipPartName(part)
+}
+
+func splitZipPartName(part int) string {
+	ext := filepath.Ext(siteZip)
+	return strings.TrimSuffix(siteZip, ext) + "-" + strconv.Itoa(part) + ext
+}
+
+// removeStaleZipParts removes the parts of the site ZIP file written by a
+// previous generation which are not replaced by the parts written by this
+// one, as when fewer parts are written or the file is no longer split.
+func removeStaleZipParts() {
+	part := 1
+	if zipSplitBytes > 0 {
+		os.Remove(filepath.Join(siteDestination, siteZip))
+		part = outZipPart + 1
+	}
+	for ; ; part++ {
+		err := os.Remove(filepath.Join(siteDestination, splitZipPartName(part)))
+		if err != nil {
+			return
+		}
+	}
+}
+
+func openZip() error {
+	outZipPart++
+	outZipEntries = 0
+	outZipDirectory = zipDirectoryEndLen
+	outZipPending = 0
+
+	fn := filepath.Join(siteDestination, zipPartName(outZipPart))
+	f, err := os.Create(fn)
+	if err != nil {
+		return fmt.Errorf("failed to create zip file %s: %s", fn, err)
+	}
+
+	outZipFile = &countingWriter{f: f}
+	outZip = zip.NewWriter(outZipFile)
+	return nil
+}
+
+func closeZip() error {
+	if outZip == nil {
+		return nil
+	}
+
+	err := outZip.Close()
+	closeErr := outZipFile.f.Close()
+	outZip = nil
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// writeZipFile adds a file to the site ZIP file, starting a new part first
+// when the file would cause the current part, including its central
+// directory, to exceed -zip-split-size.
+func writeZipFile(fn string, data []byte) error {
+	outZipLock.Lock()
+	defer outZipLock.Unlock()
+
+	if zipSplitBytes > 0 && outZipEntries > 0 {
+		err := outZip.Flush()
+		if err != nil {
+			return fmt.Errorf("failed to write zip file %s: %s", fn, err)
+		}
+
+		if outZipFile.n+outZipPending+outZipDirectory+int64(len(data))+zipEntryOverhead > zipSplitBytes {
+			err = closeZip()
+			if err != nil {
+				return fmt.Errorf("failed to close zip file %s: %s", zipPartName(outZipPart), err)
+			}
+
+			err = openZip()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	outZipEntry, err := outZip.Create(fn)
+	if err != nil {
+		return fmt.Errorf("failed to create zip file %s: %s", fn, err)
+	}
+
+	_, err = outZipEntry.Write(data)
+	if err != nil {
+		return fmt.Errorf("failed to write zip file %s: %s", fn, err)
+	}
+
+	outZipEntries++
+	outZipDirectory += zipDirectoryHeaderLen + int64(len(fn))
+	outZipPending = int64(len(data))
+	return nil
+}